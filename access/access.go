@@ -0,0 +1,300 @@
+// Package access assigns roles to users per tenant and decides which
+// permissions those roles grant.
+package access
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/phuangpheth/assessment/txn"
+)
+
+// ErrNotFound is returned when the role assignment could not be found.
+var ErrNotFound = errors.New("not found")
+
+// ErrRoleInvalid is returned when the role is unknown.
+var ErrRoleInvalid = errors.New("role must be one of viewer, editor, approver or admin")
+
+// ErrUserEmpty is returned when a role is assigned to an empty user.
+var ErrUserEmpty = errors.New("user must not be empty")
+
+// ErrForbidden is returned when none of the roles of the caller grants the
+// required permission.
+var ErrForbidden = errors.New("missing permission for this action")
+
+// Role is a named set of permissions.
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleEditor   Role = "editor"
+	RoleApprover Role = "approver"
+	RoleAdmin    Role = "admin"
+	// RoleOperator runs the deployment. It is granted by configuration
+	// rather than assigned within a tenant, since what it changes is shared
+	// by every tenant.
+	RoleOperator Role = "operator"
+)
+
+// Permission is what a route or operation requires from the caller.
+type Permission string
+
+const (
+	// PermRead allows reading expenses and everything derived from them.
+	PermRead Permission = "read"
+	// PermWrite allows creating, changing and submitting expenses.
+	PermWrite Permission = "write"
+	// PermApprove allows approving, rejecting and reimbursing expenses.
+	PermApprove Permission = "approve"
+	// PermAdmin allows managing roles, webhooks and API keys.
+	PermAdmin Permission = "admin"
+	// PermOperate allows changing what every tenant shares, such as
+	// exchange rates.
+	PermOperate Permission = "operate"
+)
+
+// permissions are the permissions each role grants.
+var permissions = map[Role][]Permission{
+	RoleViewer:   {PermRead},
+	RoleEditor:   {PermRead, PermWrite},
+	RoleApprover: {PermRead, PermWrite, PermApprove},
+	RoleAdmin:    {PermRead, PermWrite, PermApprove, PermAdmin},
+	RoleOperator: {PermOperate},
+}
+
+// Valid reports whether r is a role that can be assigned within a tenant.
+func (r Role) Valid() bool {
+	_, ok := permissions[r]
+	return ok && r != RoleOperator
+}
+
+// Can reports whether r grants p.
+func (r Role) Can(p Permission) bool {
+	for _, granted := range permissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed reports whether any of roles grants p.
+func Allowed(roles []Role, p Permission) bool {
+	for _, r := range roles {
+		if r.Can(p) {
+			return true
+		}
+	}
+	return false
+}
+
+type rolesKey struct{}
+
+// WithRoles returns a copy of ctx that carries the roles of the caller.
+func WithRoles(ctx context.Context, roles []Role) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// RolesFromContext returns the roles stored in ctx by WithRoles, or nil for
+// callers without roles.
+func RolesFromContext(ctx context.Context) []Role {
+	roles, _ := ctx.Value(rolesKey{}).([]Role)
+	return roles
+}
+
+// Require returns ErrForbidden unless the roles in ctx grant p.
+func Require(ctx context.Context, p Permission) error {
+	if !Allowed(RolesFromContext(ctx), p) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Assignment gives User the Role within Tenant.
+type Assignment struct {
+	Tenant    string    `json:"tenant,omitempty"`
+	User      string    `json:"user"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (a *Assignment) Validate() error {
+	if a.User == "" {
+		return ErrUserEmpty
+	}
+	if !a.Role.Valid() {
+		return ErrRoleInvalid
+	}
+	return nil
+}
+
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// Roles returns the roles of user within tenant.
+func (s *Service) Roles(ctx context.Context, tenant, user string) ([]Role, error) {
+	roles, err := listRoles(ctx, s.db, tenant, user)
+	if err != nil {
+		return nil, fmt.Errorf("listRoles(%s): %w", user, err)
+	}
+	return roles, nil
+}
+
+// Grant assigns the role to the user. Granting a role the user already has
+// keeps the original assignment.
+func (s *Service) Grant(ctx context.Context, a *Assignment) (*Assignment, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	if err := createAssignment(ctx, s.db, a); err != nil {
+		return nil, fmt.Errorf("createAssignment(): %w", err)
+	}
+	return a, nil
+}
+
+// Revoke removes the role from the user within tenant.
+func (s *Service) Revoke(ctx context.Context, tenant, user string, role Role) error {
+	n, err := deleteAssignment(ctx, s.db, tenant, user, role)
+	if err != nil {
+		return fmt.Errorf("deleteAssignment(%s, %s): %w", user, role, err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeAll removes every role of the user within tenant, in the unit of
+// work of ctx, and returns how many were removed.
+func (s *Service) RevokeAll(ctx context.Context, tenant, user string) (int64, error) {
+	n, err := deleteAssignments(ctx, txn.From(ctx, s.db), tenant, user)
+	if err != nil {
+		return 0, fmt.Errorf("deleteAssignments(%s): %w", user, err)
+	}
+	return n, nil
+}
+
+// List returns every role assignment within tenant.
+func (s *Service) List(ctx context.Context, tenant string) ([]Assignment, error) {
+	as, err := listAssignments(ctx, s.db, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("listAssignments(): %w", err)
+	}
+	return as, nil
+}
+
+func listRoles(ctx context.Context, db *sql.DB, tenant, user string) ([]Role, error) {
+	query, args, err := sq.Select("role").
+		From("role_assignments").
+		Where(sq.Eq{"tenant_id": tenant, "user_id": user}).
+		OrderBy("role").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make([]Role, 0)
+	for rows.Next() {
+		var r Role
+		if err := rows.Scan(&r); err != nil {
+			return nil, err
+		}
+		roles = append(roles, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func createAssignment(ctx context.Context, db *sql.DB, a *Assignment) error {
+	query, args, err := sq.Insert("role_assignments").
+		Columns("tenant_id", "user_id", "role").
+		Values(a.Tenant, a.User, a.Role).
+		Suffix("ON CONFLICT (tenant_id, user_id, role) DO UPDATE SET role = EXCLUDED.role RETURNING created_at").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	return db.QueryRowContext(ctx, query, args...).Scan(&a.CreatedAt)
+}
+
+func deleteAssignment(ctx context.Context, db *sql.DB, tenant, user string, role Role) (int64, error) {
+	query, args, err := sq.Delete("role_assignments").
+		Where(sq.Eq{"tenant_id": tenant, "user_id": user, "role": role}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func deleteAssignments(ctx context.Context, db txn.Querier, tenant, user string) (int64, error) {
+	query, args, err := sq.Delete("role_assignments").
+		Where(sq.Eq{"tenant_id": tenant, "user_id": user}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func listAssignments(ctx context.Context, db *sql.DB, tenant string) ([]Assignment, error) {
+	query, args, err := sq.Select("tenant_id", "user_id", "role", "created_at").
+		From("role_assignments").
+		Where(sq.Eq{"tenant_id": tenant}).
+		OrderBy("user_id", "role").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	as := make([]Assignment, 0)
+	for rows.Next() {
+		var a Assignment
+		if err := rows.Scan(&a.Tenant, &a.User, &a.Role, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		as = append(as, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return as, nil
+}
@@ -0,0 +1,110 @@
+package access
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleCan(t *testing.T) {
+	tests := []struct {
+		role Role
+		perm Permission
+		want bool
+	}{
+		{RoleViewer, PermRead, true},
+		{RoleViewer, PermWrite, false},
+		{RoleEditor, PermWrite, true},
+		{RoleEditor, PermApprove, false},
+		{RoleApprover, PermApprove, true},
+		{RoleApprover, PermAdmin, false},
+		{RoleAdmin, PermAdmin, true},
+		{RoleAdmin, PermOperate, false},
+		{RoleOperator, PermOperate, true},
+		{"owner", PermRead, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role)+" "+string(tt.perm), func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.role.Can(tt.perm))
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	ctx := WithRoles(context.Background(), []Role{RoleViewer, RoleApprover})
+
+	assert.NoError(t, Require(ctx, PermApprove))
+	assert.ErrorIs(t, Require(ctx, PermAdmin), ErrForbidden)
+	assert.ErrorIs(t, Require(context.Background(), PermRead), ErrForbidden)
+}
+
+func TestAssignmentValidate(t *testing.T) {
+	t.Run("ErrUserEmpty", func(t *testing.T) {
+		a := &Assignment{Role: RoleViewer}
+
+		assert.ErrorIs(t, a.Validate(), ErrUserEmpty)
+	})
+
+	t.Run("ErrRoleInvalid", func(t *testing.T) {
+		a := &Assignment{User: "ana", Role: "owner"}
+
+		assert.ErrorIs(t, a.Validate(), ErrRoleInvalid)
+	})
+
+	t.Run("operators are not assigned within a tenant", func(t *testing.T) {
+		a := &Assignment{User: "ana", Role: RoleOperator}
+
+		assert.ErrorIs(t, a.Validate(), ErrRoleInvalid)
+	})
+}
+
+func TestService(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	ctx := context.Background()
+
+	t.Run("Roles() returns the roles of the user in the tenant", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT role FROM role_assignments WHERE (.+) ORDER BY role`).
+			WithArgs("acme", "ana").
+			WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("approver").AddRow("viewer"))
+
+		roles, err := svc.Roles(ctx, "acme", "ana")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []Role{RoleApprover, RoleViewer}, roles)
+		}
+	})
+
+	t.Run("Grant()", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(`INSERT INTO role_assignments (.+) ON CONFLICT (.+) RETURNING created_at`).
+			WithArgs("acme", "ana", RoleEditor).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+
+		a, err := svc.Grant(ctx, &Assignment{Tenant: "acme", User: "ana", Role: RoleEditor})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, now, a.CreatedAt)
+		}
+	})
+
+	t.Run("Revoke() returns not found", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM role_assignments`).
+			WithArgs(RoleAdmin, "acme", "ana").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := svc.Revoke(ctx, "acme", "ana", RoleAdmin)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,367 @@
+// Package apikey issues API keys for machine clients. Only a hash of each
+// key is stored; the plaintext is returned once, when the key is created.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/access"
+)
+
+// ErrNotFound is returned when the key could not be found.
+var ErrNotFound = errors.New("not found")
+
+// ErrNameEmpty is returned when a key has no name.
+var ErrNameEmpty = errors.New("name must not be empty")
+
+// ErrScopesEmpty is returned when a key has no scopes.
+var ErrScopesEmpty = errors.New("scopes must not be empty")
+
+// ErrScopeInvalid is returned when a key has an unknown scope.
+var ErrScopeInvalid = errors.New("scope must be one of read, write or admin")
+
+// ErrExpiresInvalid is returned when a key expires in the past.
+var ErrExpiresInvalid = errors.New("expires_at must be in the future")
+
+// ErrKeyInvalid is returned when a key is malformed, unknown, revoked or
+// expired.
+var ErrKeyInvalid = errors.New("invalid api key")
+
+// Prefix starts every key, so that leaked keys are easy to search for.
+const Prefix = "exp_"
+
+// Scope limits what a key may do.
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"
+	ScopeWrite Scope = "write"
+	ScopeAdmin Scope = "admin"
+)
+
+// roles are the roles each scope acts with.
+var roles = map[Scope]access.Role{
+	ScopeRead:  access.RoleViewer,
+	ScopeWrite: access.RoleEditor,
+	ScopeAdmin: access.RoleAdmin,
+}
+
+// Key is an API key of a tenant. Key holds the plaintext key only in the
+// result of Create and Rotate.
+type Key struct {
+	ID         int64      `json:"id"`
+	Tenant     string     `json:"tenant,omitempty"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	Scopes     []Scope    `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (k *Key) Validate() error {
+	if strings.TrimSpace(k.Name) == "" {
+		return ErrNameEmpty
+	}
+	if len(k.Scopes) == 0 {
+		return ErrScopesEmpty
+	}
+	for _, s := range k.Scopes {
+		if _, ok := roles[s]; !ok {
+			return ErrScopeInvalid
+		}
+	}
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		return ErrExpiresInvalid
+	}
+	return nil
+}
+
+// Roles returns the roles the key acts with.
+func (k *Key) Roles() []access.Role {
+	rs := make([]access.Role, 0, len(k.Scopes))
+	for _, s := range k.Scopes {
+		rs = append(rs, roles[s])
+	}
+	return rs
+}
+
+// Actor identifies the key in audit trails.
+func (k *Key) Actor() string {
+	return "apikey:" + k.Prefix
+}
+
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// Create issues a new key. The plaintext key is set on the result and is
+// not stored.
+func (s *Service) Create(ctx context.Context, k *Key) (*Key, error) {
+	if err := k.Validate(); err != nil {
+		return nil, err
+	}
+	prefix, key, err := generate()
+	if err != nil {
+		return nil, fmt.Errorf("generate(): %w", err)
+	}
+	k.Prefix = prefix
+	if err := createKey(ctx, s.db, k, hash(key)); err != nil {
+		return nil, fmt.Errorf("createKey(): %w", err)
+	}
+	k.Key = key
+	return k, nil
+}
+
+// List returns the keys of tenant that are not revoked, without their
+// plaintext.
+func (s *Service) List(ctx context.Context, tenant string) ([]Key, error) {
+	ks, err := listKeys(ctx, s.db, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("listKeys(): %w", err)
+	}
+	return ks, nil
+}
+
+// Revoke makes the key of tenant unusable.
+func (s *Service) Revoke(ctx context.Context, tenant string, id int64) error {
+	n, err := revokeKey(ctx, s.db, tenant, id)
+	if err != nil {
+		return fmt.Errorf("revokeKey(%d): %w", id, err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Rotate issues a new key with the name, scopes and expiry of the key of
+// tenant, then revokes the old key.
+func (s *Service) Rotate(ctx context.Context, tenant string, id int64) (*Key, error) {
+	old, err := getKey(ctx, s.db, tenant, id)
+	if err != nil {
+		return nil, fmt.Errorf("getKey(%d): %w", id, err)
+	}
+	k, err := s.Create(ctx, &Key{
+		Tenant:    old.Tenant,
+		Name:      old.Name,
+		Scopes:    old.Scopes,
+		ExpiresAt: old.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Revoke(ctx, tenant, id); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Authenticate returns the key whose plaintext is key and records that it
+// was used.
+func (s *Service) Authenticate(ctx context.Context, key string) (*Key, error) {
+	prefix, ok := parse(key)
+	if !ok {
+		return nil, ErrKeyInvalid
+	}
+	k, h, err := getKeyByPrefix(ctx, s.db, prefix)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrKeyInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getKeyByPrefix(%s): %w", prefix, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(h), []byte(hash(key))) != 1 {
+		return nil, ErrKeyInvalid
+	}
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		return nil, ErrKeyInvalid
+	}
+	if err := touchKey(ctx, s.db, k.ID); err != nil {
+		return nil, fmt.Errorf("touchKey(%d): %w", k.ID, err)
+	}
+	return k, nil
+}
+
+// generate returns a new key "exp_{prefix}_{secret}" and its prefix.
+func generate() (prefix, key string, err error) {
+	b := make([]byte, 4+32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	prefix = hex.EncodeToString(b[:4])
+	return prefix, Prefix + prefix + "_" + hex.EncodeToString(b[4:]), nil
+}
+
+// parse returns the prefix of key.
+func parse(key string) (string, bool) {
+	if !strings.HasPrefix(key, Prefix) {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(strings.TrimPrefix(key, Prefix), "_")
+	return prefix, ok && len(prefix) == 8 && len(secret) == 64
+}
+
+func hash(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+var keyColumns = []string{"id", "tenant_id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at"}
+
+func scanKey(scan func(dest ...any) error, extra ...any) (Key, error) {
+	var (
+		k      Key
+		scopes []string
+	)
+	dest := append([]any{&k.ID, &k.Tenant, &k.Name, &k.Prefix, pq.Array(&scopes), &k.ExpiresAt, &k.LastUsedAt, &k.CreatedAt}, extra...)
+	if err := scan(dest...); err != nil {
+		return Key{}, err
+	}
+	for _, s := range scopes {
+		k.Scopes = append(k.Scopes, Scope(s))
+	}
+	return k, nil
+}
+
+func scopeStrings(scopes []Scope) []string {
+	ss := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		ss = append(ss, string(s))
+	}
+	return ss
+}
+
+func createKey(ctx context.Context, db *sql.DB, k *Key, hash string) error {
+	query, args, err := sq.Insert("api_keys").
+		Columns("tenant_id", "name", "prefix", "hash", "scopes", "expires_at").
+		Values(k.Tenant, k.Name, k.Prefix, hash, pq.Array(scopeStrings(k.Scopes)), k.ExpiresAt).
+		Suffix("RETURNING id, created_at").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	return db.QueryRowContext(ctx, query, args...).Scan(&k.ID, &k.CreatedAt)
+}
+
+func getKey(ctx context.Context, db *sql.DB, tenant string, id int64) (*Key, error) {
+	query, args, err := sq.Select(keyColumns...).
+		From("api_keys").
+		Where(sq.Eq{"id": id, "tenant_id": tenant, "revoked_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	k, err := scanKey(db.QueryRowContext(ctx, query, args...).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// getKeyByPrefix returns the unrevoked key with prefix and its hash.
+func getKeyByPrefix(ctx context.Context, db *sql.DB, prefix string) (*Key, string, error) {
+	query, args, err := sq.Select(append(keyColumns, "hash")...).
+		From("api_keys").
+		Where(sq.Eq{"prefix": prefix, "revoked_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, "", err
+	}
+	var h string
+	k, err := scanKey(db.QueryRowContext(ctx, query, args...).Scan, &h)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &k, h, nil
+}
+
+func listKeys(ctx context.Context, db *sql.DB, tenant string) ([]Key, error) {
+	query, args, err := sq.Select(keyColumns...).
+		From("api_keys").
+		Where(sq.Eq{"tenant_id": tenant, "revoked_at": nil}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ks := make([]Key, 0)
+	for rows.Next() {
+		k, err := scanKey(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		ks = append(ks, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+func revokeKey(ctx context.Context, db *sql.DB, tenant string, id int64) (int64, error) {
+	query, args, err := sq.Update("api_keys").
+		Set("revoked_at", sq.Expr("now()")).
+		Where(sq.Eq{"id": id, "tenant_id": tenant, "revoked_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// touchKey sets the last use of the key, at most once a minute to keep
+// authentication from writing on every request.
+func touchKey(ctx context.Context, db *sql.DB, id int64) error {
+	query, args, err := sq.Update("api_keys").
+		Set("last_used_at", sq.Expr("now()")).
+		Where(sq.Eq{"id": id}).
+		Where("(last_used_at IS NULL OR last_used_at < now() - interval '1 minute')").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,167 @@
+package apikey
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyValidate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name string
+		key  Key
+		err  error
+	}{
+		{"valid", Key{Name: "ci", Scopes: []Scope{ScopeRead}}, nil},
+		{"ErrNameEmpty", Key{Name: " ", Scopes: []Scope{ScopeRead}}, ErrNameEmpty},
+		{"ErrScopesEmpty", Key{Name: "ci"}, ErrScopesEmpty},
+		{"ErrScopeInvalid", Key{Name: "ci", Scopes: []Scope{"approve"}}, ErrScopeInvalid},
+		{"ErrExpiresInvalid", Key{Name: "ci", Scopes: []Scope{ScopeRead}, ExpiresAt: &past}, ErrExpiresInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.key.Validate(), tt.err)
+		})
+	}
+}
+
+func TestKeyRoles(t *testing.T) {
+	k := Key{Scopes: []Scope{ScopeRead, ScopeWrite}}
+
+	assert.Equal(t, []access.Role{access.RoleViewer, access.RoleEditor}, k.Roles())
+}
+
+func TestServiceCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	var stored string
+	mock.ExpectQuery(`INSERT INTO api_keys (.+) RETURNING id, created_at`).
+		WithArgs("acme", "ci", sqlmock.AnyArg(), hashArg{&stored}, pq.Array([]string{"read"}), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+
+	k, err := svc.Create(context.Background(), &Key{Tenant: "acme", Name: "ci", Scopes: []Scope{ScopeRead}})
+
+	if assert.NoError(t, err) {
+		assert.True(t, strings.HasPrefix(k.Key, Prefix+k.Prefix+"_"))
+		assert.Equal(t, hash(k.Key), stored)
+		assert.NotContains(t, stored, k.Key)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// hashArg matches any string argument and keeps it.
+type hashArg struct{ v *string }
+
+func (a hashArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	*a.v = s
+	return ok
+}
+
+func TestServiceAuthenticate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	ctx := context.Background()
+	key := Prefix + "0a1b2c3d_" + strings.Repeat("f", 64)
+	columns := append(keyColumns, "hash")
+	row := func(expiresAt any) *sqlmock.Rows {
+		return sqlmock.NewRows(columns).
+			AddRow(1, "acme", "ci", "0a1b2c3d", pq.Array([]string{"write"}), expiresAt, nil, time.Now(), hash(key))
+	}
+
+	t.Run("Authenticate() records the use of the key", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM api_keys WHERE (.+)revoked_at IS NULL`).
+			WithArgs("0a1b2c3d").
+			WillReturnRows(row(nil))
+		mock.ExpectExec(`UPDATE api_keys SET last_used_at = now\(\)`).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		k, err := svc.Authenticate(ctx, key)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "acme", k.Tenant)
+			assert.Equal(t, []Scope{ScopeWrite}, k.Scopes)
+			assert.Equal(t, "apikey:0a1b2c3d", k.Actor())
+		}
+	})
+
+	t.Run("Authenticate() rejects a wrong secret", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM api_keys`).WillReturnRows(row(nil))
+
+		_, err := svc.Authenticate(ctx, Prefix+"0a1b2c3d_"+strings.Repeat("e", 64))
+
+		assert.ErrorIs(t, err, ErrKeyInvalid)
+	})
+
+	t.Run("Authenticate() rejects an expired key", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM api_keys`).WillReturnRows(row(time.Now().Add(-time.Minute)))
+
+		_, err := svc.Authenticate(ctx, key)
+
+		assert.ErrorIs(t, err, ErrKeyInvalid)
+	})
+
+	t.Run("Authenticate() rejects an unknown key", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM api_keys`).WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := svc.Authenticate(ctx, key)
+
+		assert.ErrorIs(t, err, ErrKeyInvalid)
+	})
+
+	t.Run("Authenticate() rejects a malformed key", func(t *testing.T) {
+		_, err := svc.Authenticate(ctx, "November 10, 2009")
+
+		assert.ErrorIs(t, err, ErrKeyInvalid)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceRotate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	mock.ExpectQuery(`SELECT (.+) FROM api_keys`).
+		WithArgs(1, "acme").
+		WillReturnRows(sqlmock.NewRows(keyColumns).
+			AddRow(1, "acme", "ci", "0a1b2c3d", pq.Array([]string{"read", "write"}), nil, nil, time.Now()))
+	mock.ExpectQuery(`INSERT INTO api_keys`).
+		WithArgs("acme", "ci", sqlmock.AnyArg(), sqlmock.AnyArg(), pq.Array([]string{"read", "write"}), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, time.Now()))
+	mock.ExpectExec(`UPDATE api_keys SET revoked_at = now\(\)`).
+		WithArgs(1, "acme").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	k, err := svc.Rotate(context.Background(), "acme", 1)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2), k.ID)
+		assert.NotEqual(t, "0a1b2c3d", k.Prefix)
+		assert.NotEmpty(t, k.Key)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
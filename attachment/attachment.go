@@ -0,0 +1,380 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/txn"
+)
+
+// ErrNotFound is returned when the attachment could not be found.
+var ErrNotFound = errors.New("not found")
+
+// ErrTooLarge is returned when the attachment exceeds the size limit.
+var ErrTooLarge = errors.New("attachment is too large")
+
+// ErrEmpty is returned when the attachment has no content.
+var ErrEmpty = errors.New("empty attachment")
+
+// ErrContentType is returned when the content type of the attachment is not allowed.
+var ErrContentType = errors.New("content type is not allowed")
+
+// DefaultMaxSize is the size limit used when Limits.MaxSize is zero.
+const DefaultMaxSize = 10 << 20
+
+// DefaultContentTypes are the content types used when Limits.ContentTypes is empty.
+var DefaultContentTypes = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+}
+
+// Limits restricts what may be uploaded as an attachment.
+type Limits struct {
+	MaxSize      int64
+	ContentTypes []string
+}
+
+type Service struct {
+	db     *sql.DB
+	store  BlobStore
+	limits Limits
+}
+
+func NewService(db *sql.DB, store BlobStore, limits Limits) *Service {
+	if limits.MaxSize <= 0 {
+		limits.MaxSize = DefaultMaxSize
+	}
+	if len(limits.ContentTypes) == 0 {
+		limits.ContentTypes = DefaultContentTypes
+	}
+	return &Service{
+		db:     db,
+		store:  store,
+		limits: limits,
+	}
+}
+
+// MaxSize returns the size limit of attachments.
+func (s *Service) MaxSize() int64 {
+	return s.limits.MaxSize
+}
+
+// Save stores the content of r as an attachment of the expense. The content
+// type is sniffed from the content itself rather than trusted from the client.
+func (s *Service) Save(ctx context.Context, expenseID int64, filename string, r io.Reader) (*Attachment, error) {
+	byt, err := io.ReadAll(io.LimitReader(r, s.limits.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(byt) == 0 {
+		return nil, ErrEmpty
+	}
+	if int64(len(byt)) > s.limits.MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	contentType, err := s.contentType(byt)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := newStorageKey(expenseID)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(byt)
+	a := &Attachment{
+		ExpenseID:   expenseID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        int64(len(byt)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		storageKey:  key,
+	}
+
+	if err := s.store.Put(ctx, key, bytes.NewReader(byt), a.Size, contentType); err != nil {
+		return nil, fmt.Errorf("store.Put(%s): %w", key, err)
+	}
+	if err := createAttachment(ctx, s.db, a); err != nil {
+		_ = s.store.Delete(ctx, key)
+		return nil, fmt.Errorf("createAttachment(): %w", err)
+	}
+	return a, nil
+}
+
+func (s *Service) GetByID(ctx context.Context, expenseID, id int64) (*Attachment, error) {
+	a, err := getAttachmentByID(ctx, s.db, expenseID, id)
+	if err != nil {
+		return nil, fmt.Errorf("getAttachmentByID(%d): %w", id, err)
+	}
+	return a, nil
+}
+
+// Open returns the metadata and the content of the attachment. The caller
+// must close the returned reader.
+func (s *Service) Open(ctx context.Context, expenseID, id int64) (*Attachment, io.ReadCloser, error) {
+	a, err := s.GetByID(ctx, expenseID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := s.store.Get(ctx, a.storageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("store.Get(%s): %w", a.storageKey, err)
+	}
+	return a, rc, nil
+}
+
+func (s *Service) List(ctx context.Context, expenseID int64) ([]Attachment, error) {
+	as, err := listAttachments(ctx, s.db, expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("listAttachments(%d): %w", expenseID, err)
+	}
+	return as, nil
+}
+
+func (s *Service) Delete(ctx context.Context, expenseID, id int64) error {
+	a, err := s.GetByID(ctx, expenseID, id)
+	if err != nil {
+		return err
+	}
+	if err := deleteAttachment(ctx, s.db, a.ID); err != nil {
+		return fmt.Errorf("deleteAttachment(%d): %w", a.ID, err)
+	}
+	if err := s.store.Delete(ctx, a.storageKey); err != nil {
+		return fmt.Errorf("store.Delete(%s): %w", a.storageKey, err)
+	}
+	return nil
+}
+
+// Remove deletes the attachments of the expenses in the unit of work of ctx
+// and returns them. Their content is left in the store: delete it with
+// DeleteContent once the unit of work committed.
+func (s *Service) Remove(ctx context.Context, expenseIDs []int64) ([]Attachment, error) {
+	as, err := removeAttachments(ctx, txn.From(ctx, s.db), expenseIDs)
+	if err != nil {
+		return nil, fmt.Errorf("removeAttachments(): %w", err)
+	}
+	return as, nil
+}
+
+// DeleteContent deletes the content of the attachments from the store. It
+// tries every attachment and returns the first error.
+func (s *Service) DeleteContent(ctx context.Context, as []Attachment) error {
+	keys := make([]string, 0, len(as))
+	for _, a := range as {
+		keys = append(keys, a.storageKey)
+	}
+	return s.DeleteKeys(ctx, keys)
+}
+
+// DeleteKeys deletes the content stored under keys, such as the storage keys
+// recorded in the archive. It tries every key and returns the first error.
+func (s *Service) DeleteKeys(ctx context.Context, keys []string) error {
+	var first error
+	for _, key := range keys {
+		err := s.store.Delete(ctx, key)
+		if err != nil && !errors.Is(err, ErrBlobNotFound) && first == nil {
+			first = fmt.Errorf("store.Delete(%s): %w", key, err)
+		}
+	}
+	return first
+}
+
+func (s *Service) contentType(byt []byte) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(byt))
+	if err != nil {
+		return "", ErrContentType
+	}
+	for _, t := range s.limits.ContentTypes {
+		if t == mediaType {
+			return mediaType, nil
+		}
+	}
+	return "", ErrContentType
+}
+
+func newStorageKey(expenseID int64) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("expenses/%d/%s", expenseID, hex.EncodeToString(b)), nil
+}
+
+type Attachment struct {
+	ID          int64     `json:"id"`
+	ExpenseID   int64     `json:"expense_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	storageKey string
+}
+
+func createAttachment(ctx context.Context, db *sql.DB, a *Attachment) error {
+	query, args, err := sq.Insert("attachments").
+		Columns(
+			"expense_id",
+			"filename",
+			"content_type",
+			"size",
+			"sha256",
+			"storage_key",
+		).
+		Values(
+			a.ExpenseID,
+			a.Filename,
+			a.ContentType,
+			a.Size,
+			a.SHA256,
+			a.storageKey,
+		).
+		Suffix(`
+      RETURNING id, created_at
+    `).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	row := db.QueryRowContext(ctx, query, args...)
+	return row.Scan(&a.ID, &a.CreatedAt)
+}
+
+func getAttachmentByID(ctx context.Context, db *sql.DB, expenseID, id int64) (*Attachment, error) {
+	query, args, err := sq.Select(attachmentColumns...).
+		From("attachments").
+		Where(sq.Eq{"id": id, "expense_id": expenseID}).
+		Limit(1).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	row := db.QueryRowContext(ctx, query, args...)
+	a, err := scanAttachment(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func listAttachments(ctx context.Context, db *sql.DB, expenseID int64) ([]Attachment, error) {
+	query, args, err := sq.Select(attachmentColumns...).
+		From("attachments").
+		Where(sq.Eq{"expense_id": expenseID}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	as := make([]Attachment, 0)
+	for rows.Next() {
+		a, err := scanAttachment(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		as = append(as, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+func deleteAttachment(ctx context.Context, db *sql.DB, id int64) error {
+	query, args, err := sq.Delete("attachments").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func removeAttachments(ctx context.Context, db txn.Querier, expenseIDs []int64) ([]Attachment, error) {
+	query, args, err := sq.Delete("attachments").
+		Where("expense_id = ANY(?)", pq.Array(expenseIDs)).
+		Suffix("RETURNING " + strings.Join(attachmentColumns, ", ")).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	as := make([]Attachment, 0)
+	for rows.Next() {
+		a, err := scanAttachment(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		as = append(as, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+var attachmentColumns = []string{
+	"id",
+	"expense_id",
+	"filename",
+	"content_type",
+	"size",
+	"sha256",
+	"storage_key",
+	"created_at",
+}
+
+func scanAttachment(scan func(...any) error) (a Attachment, _ error) {
+	return a, scan(
+		&a.ID,
+		&a.ExpenseID,
+		&a.Filename,
+		&a.ContentType,
+		&a.Size,
+		&a.SHA256,
+		&a.storageKey,
+		&a.CreatedAt,
+	)
+}
@@ -0,0 +1,110 @@
+package attachment
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+func TestServiceSave(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService(db, store, Limits{MaxSize: 64})
+	ctx := context.Background()
+
+	t.Run("Save()", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(`INSERT INTO attachments (.+) RETURNING`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, now))
+
+		a, err := svc.Save(ctx, 10, "receipt.png", strings.NewReader(pngHeader+"receipt"))
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), a.ID)
+			assert.Equal(t, "image/png", a.ContentType)
+			assert.Equal(t, int64(15), a.Size)
+			assert.Len(t, a.SHA256, 64)
+
+			rc, err := store.Get(ctx, a.storageKey)
+			if assert.NoError(t, err) {
+				byt, _ := io.ReadAll(rc)
+				rc.Close()
+				assert.Equal(t, pngHeader+"receipt", string(byt))
+			}
+		}
+	})
+
+	t.Run("Save() returns ErrTooLarge", func(t *testing.T) {
+		_, err := svc.Save(ctx, 10, "receipt.png", strings.NewReader(pngHeader+strings.Repeat("x", 64)))
+
+		assert.ErrorIs(t, err, ErrTooLarge)
+	})
+
+	t.Run("Save() returns ErrContentType", func(t *testing.T) {
+		_, err := svc.Save(ctx, 10, "receipt.txt", strings.NewReader("plain text"))
+
+		assert.ErrorIs(t, err, ErrContentType)
+	})
+
+	t.Run("Save() returns ErrEmpty", func(t *testing.T) {
+		_, err := svc.Save(ctx, 10, "receipt.png", strings.NewReader(""))
+
+		assert.ErrorIs(t, err, ErrEmpty)
+	})
+}
+
+func TestServiceDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService(db, store, Limits{})
+	ctx := context.Background()
+
+	t.Run("Delete()", func(t *testing.T) {
+		err := store.Put(ctx, "expenses/10/abc", strings.NewReader(pngHeader), 8, "image/png")
+		assert.NoError(t, err)
+
+		rows := sqlmock.NewRows(attachmentColumns).
+			AddRow(1, 10, "receipt.png", "image/png", 8, "sum", "expenses/10/abc", time.Now())
+		mock.ExpectQuery("SELECT (.+) FROM attachments").WithArgs(10, 1).WillReturnRows(rows)
+		mock.ExpectExec("DELETE FROM attachments").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = svc.Delete(ctx, 10, 1)
+
+		if assert.NoError(t, err) {
+			_, err := store.Get(ctx, "expenses/10/abc")
+			assert.ErrorIs(t, err, ErrBlobNotFound)
+		}
+	})
+
+	t.Run("Delete() returns ErrNotFound", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM attachments").WithArgs(10, 2).
+			WillReturnRows(sqlmock.NewRows(attachmentColumns))
+
+		err := svc.Delete(ctx, 10, 2)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,17 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned when the blob does not exist in the store.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores the raw content of attachments.
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,87 @@
+package attachment
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a minimal stand-in for an S3-compatible server such as MinIO.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=minio/") ||
+		r.Header.Get("X-Amz-Date") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		byt, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = byt
+	case http.MethodGet:
+		byt, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(byt)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	store := NewS3Store(S3Config{
+		Endpoint:  srv.URL,
+		Bucket:    "receipts",
+		AccessKey: "minio",
+		SecretKey: "minio123",
+	}, srv.Client())
+	ctx := context.Background()
+
+	t.Run("Put() and Get()", func(t *testing.T) {
+		err := store.Put(ctx, "expenses/1/abc", strings.NewReader("receipt"), 7, "image/png")
+		assert.NoError(t, err)
+		assert.Contains(t, fake.objects, "/receipts/expenses/1/abc")
+
+		rc, err := store.Get(ctx, "expenses/1/abc")
+		if assert.NoError(t, err) {
+			byt, _ := io.ReadAll(rc)
+			rc.Close()
+			assert.Equal(t, "receipt", string(byt))
+		}
+	})
+
+	t.Run("Delete()", func(t *testing.T) {
+		err := store.Delete(ctx, "expenses/1/abc")
+		assert.NoError(t, err)
+
+		_, err = store.Get(ctx, "expenses/1/abc")
+		assert.ErrorIs(t, err, ErrBlobNotFound)
+	})
+
+	t.Run("Put() returns error on bad credentials", func(t *testing.T) {
+		bad := NewS3Store(S3Config{Endpoint: srv.URL, Bucket: "receipts", AccessKey: "other"}, srv.Client())
+
+		err := bad.Put(ctx, "expenses/1/abc", strings.NewReader("receipt"), 7, "image/png")
+		assert.Error(t, err)
+	})
+}
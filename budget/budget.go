@@ -0,0 +1,407 @@
+package budget
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/txn"
+	"go.uber.org/zap"
+)
+
+// ErrNotFound is returned when the budget could not be found.
+var ErrNotFound = errors.New("not found")
+
+// ErrLimitInvalid is returned when the limit of budget is less than zero.
+var ErrLimitInvalid = errors.New("limit must be greater than zero")
+
+// ErrTagEmpty is returned when the budget has no tag.
+var ErrTagEmpty = errors.New("empty tag")
+
+// ErrPeriodInvalid is returned when the period of budget is unknown.
+var ErrPeriodInvalid = errors.New("period must be one of weekly, monthly or yearly")
+
+// Thresholds are the fractions of a budget limit that raise an Alert when
+// spending crosses them.
+var Thresholds = []float64{0.8, 1}
+
+type Period string
+
+const (
+	Weekly  Period = "weekly"
+	Monthly Period = "monthly"
+	Yearly  Period = "yearly"
+)
+
+// Bounds returns the start (inclusive) and end (exclusive) of the period
+// that contains t. Weeks start on Monday.
+func (p Period) Bounds(t time.Time) (start, end time.Time) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch p {
+	case Weekly:
+		start = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7)
+	case Yearly:
+		start = time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, 0)
+	default:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+// Notifier receives the alerts raised by budgets.
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// LogNotifier writes alerts to the global zap logger.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, a Alert) error {
+	zap.L().Warn("budget threshold crossed",
+		zap.Int64("budget_id", a.BudgetID),
+		zap.String("tag", a.Tag),
+		zap.Float64("threshold", a.Threshold),
+		zap.Float64("spent", a.Spent),
+		zap.Float64("limit", a.Limit),
+		zap.Int64("expense_id", a.ExpenseID),
+	)
+	return nil
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier = txn.Querier
+
+type Service struct {
+	db       *sql.DB
+	notifier Notifier
+	now      func() time.Time
+}
+
+func NewService(db *sql.DB, notifier Notifier) *Service {
+	if notifier == nil {
+		notifier = LogNotifier{}
+	}
+	return &Service{
+		db:       db,
+		notifier: notifier,
+		now:      time.Now,
+	}
+}
+
+// conn returns the transaction of the unit of work of ctx, or the database.
+func (s *Service) conn(ctx context.Context) querier {
+	return txn.From(ctx, s.db)
+}
+
+// inTenant selects the budgets of the tenant of ctx.
+func inTenant(ctx context.Context) sq.Eq {
+	return sq.Eq{"tenant_id": expense.TenantFromContext(ctx)}
+}
+
+func (s *Service) Save(ctx context.Context, b *Budget) (*Budget, error) {
+	if err := createBudget(ctx, s.conn(ctx), b); err != nil {
+		return nil, fmt.Errorf("createBudget(): %w", err)
+	}
+	return b, nil
+}
+
+func (s *Service) GetByID(ctx context.Context, id int64) (*Budget, error) {
+	b, err := getBudgetByID(ctx, s.conn(ctx), id)
+	if err != nil {
+		return nil, fmt.Errorf("getBudgetByID(%d): %w", id, err)
+	}
+	return b, nil
+}
+
+func (s *Service) List(ctx context.Context) ([]Budget, error) {
+	bs, err := listBudgets(ctx, s.conn(ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("listBudgets(): %w", err)
+	}
+	return bs, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	if err := deleteBudget(ctx, s.conn(ctx), id); err != nil {
+		return fmt.Errorf("deleteBudget(%d): %w", id, err)
+	}
+	return nil
+}
+
+// Status returns the spending of the budget in its current period, by the
+// tenant of ctx.
+func (s *Service) Status(ctx context.Context, id int64) (*Status, error) {
+	b, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.status(ctx, b)
+}
+
+func (s *Service) status(ctx context.Context, b *Budget) (*Status, error) {
+	start, end := b.Period.Bounds(s.now())
+	spent, err := sumExpenses(ctx, s.conn(ctx), expense.TenantFromContext(ctx), b.Tag, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("sumExpenses(%s): %w", b.Tag, err)
+	}
+	return &Status{
+		Budget:      *b,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Spent:       spent,
+		Remaining:   b.Limit - spent,
+		Percent:     spent / b.Limit * 100,
+	}, nil
+}
+
+// ExpenseSaved implements expense.Listener. It raises an Alert for every
+// threshold that the change from prev to e pushed the spending past.
+func (s *Service) ExpenseSaved(ctx context.Context, prev, e *expense.Expense) {
+	tags := e.Tags
+	if prev != nil {
+		tags = append(append([]string(nil), prev.Tags...), e.Tags...)
+	}
+	if len(tags) == 0 {
+		return
+	}
+
+	bs, err := listBudgets(ctx, s.conn(ctx), tags)
+	if err != nil {
+		zap.L().Error("failed to list budgets", zap.Error(err))
+		return
+	}
+	for i := range bs {
+		b := &bs[i]
+		st, err := s.status(ctx, b)
+		if err != nil {
+			zap.L().Error("failed to get budget status", zap.Int64("budget_id", b.ID), zap.Error(err))
+			continue
+		}
+
+		before := st.Spent - st.contribution(e) + st.contribution(prev)
+		for _, t := range Thresholds {
+			limit := t * b.Limit
+			if before >= limit || st.Spent < limit {
+				continue
+			}
+			a := Alert{
+				BudgetID:  b.ID,
+				Tag:       b.Tag,
+				Threshold: t,
+				Spent:     st.Spent,
+				Limit:     b.Limit,
+				ExpenseID: e.ID,
+				At:        s.now(),
+			}
+			if err := s.notifier.Notify(ctx, a); err != nil {
+				zap.L().Error("failed to notify budget alert", zap.Int64("budget_id", b.ID), zap.Error(err))
+			}
+		}
+	}
+}
+
+// contribution returns what e adds to the spending of the status: its base
+// amount when it has the tag of the budget and was spent within the period.
+func (st *Status) contribution(e *expense.Expense) float64 {
+	if e == nil {
+		return 0
+	}
+	on, err := time.Parse(expense.DateLayout, e.SpentOn)
+	if err != nil || on.Before(st.PeriodStart) || !on.Before(st.PeriodEnd) {
+		return 0
+	}
+	for _, t := range e.Tags {
+		if t == st.Budget.Tag {
+			return e.BaseAmount
+		}
+	}
+	return 0
+}
+
+// Budget limits the total amount of expenses tagged with Tag per Period.
+type Budget struct {
+	ID     int64   `json:"id"`
+	Name   string  `json:"name"`
+	Tag    string  `json:"tag"`
+	Limit  float64 `json:"limit"`
+	Period Period  `json:"period"`
+}
+
+func (b *Budget) Validate() error {
+	if b.Limit <= 0 {
+		return ErrLimitInvalid
+	}
+	if b.Tag == "" {
+		return ErrTagEmpty
+	}
+	if b.Period == "" {
+		b.Period = Monthly
+	}
+	switch b.Period {
+	case Weekly, Monthly, Yearly:
+	default:
+		return ErrPeriodInvalid
+	}
+	return nil
+}
+
+// Status is the spending of a budget in one period.
+type Status struct {
+	Budget      Budget    `json:"budget"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Spent       float64   `json:"spent"`
+	Remaining   float64   `json:"remaining"`
+	Percent     float64   `json:"percent"`
+}
+
+// Alert is raised when spending crosses a threshold of a budget.
+type Alert struct {
+	BudgetID  int64     `json:"budget_id"`
+	Tag       string    `json:"tag"`
+	Threshold float64   `json:"threshold"`
+	Spent     float64   `json:"spent"`
+	Limit     float64   `json:"limit"`
+	ExpenseID int64     `json:"expense_id"`
+	At        time.Time `json:"at"`
+}
+
+func createBudget(ctx context.Context, db querier, b *Budget) error {
+	query, args, err := sq.Insert("budgets").
+		Columns("tenant_id", "name", "tag", "amount_limit", "period").
+		Values(expense.TenantFromContext(ctx), b.Name, b.Tag, b.Limit, b.Period).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	return db.QueryRowContext(ctx, query, args...).Scan(&b.ID)
+}
+
+func getBudgetByID(ctx context.Context, db querier, id int64) (*Budget, error) {
+	query, args, err := sq.Select(budgetColumns...).
+		From("budgets").
+		Where(sq.Eq{"id": id}).
+		Where(inTenant(ctx)).
+		Limit(1).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	row := db.QueryRowContext(ctx, query, args...)
+	b, err := scanBudget(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// listBudgets returns every budget of the tenant of ctx, or only those for
+// the given tags when tags is not nil.
+func listBudgets(ctx context.Context, db querier, tags []string) ([]Budget, error) {
+	qb := sq.Select(budgetColumns...).
+		From("budgets").
+		Where(inTenant(ctx)).
+		OrderBy("id DESC").
+		PlaceholderFormat(sq.Dollar)
+	if tags != nil {
+		qb = qb.Where("tag = ANY(?)", pq.Array(tags))
+	}
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bs := make([]Budget, 0)
+	for rows.Next() {
+		b, err := scanBudget(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		bs = append(bs, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+func deleteBudget(ctx context.Context, db querier, id int64) error {
+	query, args, err := sq.Delete("budgets").
+		Where(sq.Eq{"id": id}).
+		Where(inTenant(ctx)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// sumExpenses totals the expenses of tenant tagged with tag, spent within
+// [start, end).
+func sumExpenses(ctx context.Context, db querier, tenant, tag string, start, end time.Time) (float64, error) {
+	query, args, err := sq.Select("COALESCE(SUM(base_amount), 0)").
+		From("expenses").
+		Where(sq.Eq{"tenant_id": tenant}).
+		Where("? = ANY(tags)", tag).
+		Where(sq.GtOrEq{"spent_on": start.Format(expense.DateLayout)}).
+		Where(sq.Lt{"spent_on": end.Format(expense.DateLayout)}).
+		Where(sq.Eq{"deleted_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var spent float64
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&spent); err != nil {
+		return 0, err
+	}
+	return spent, nil
+}
+
+var budgetColumns = []string{
+	"id",
+	"name",
+	"tag",
+	"amount_limit",
+	"period",
+}
+
+func scanBudget(scan func(...any) error) (b Budget, _ error) {
+	return b, scan(
+		&b.ID,
+		&b.Name,
+		&b.Tag,
+		&b.Limit,
+		&b.Period,
+	)
+}
@@ -0,0 +1,163 @@
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNotifier struct {
+	alerts []Alert
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, a Alert) error {
+	f.alerts = append(f.alerts, a)
+	return nil
+}
+
+func TestPeriodBounds(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 4, 5, 0, time.UTC) // Sunday
+	tests := []struct {
+		period     Period
+		start, end time.Time
+	}{
+		{Weekly, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{Monthly, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{Yearly, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.period), func(t *testing.T) {
+			start, end := tt.period.Bounds(now)
+
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.end, end)
+		})
+	}
+}
+
+func TestServiceExpenseSaved(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	notifier := &fakeNotifier{}
+	svc := NewService(db, notifier)
+	svc.now = func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	t.Run("ExpenseSaved() alerts when crossing 80%", func(t *testing.T) {
+		notifier.alerts = nil
+		mock.ExpectQuery("SELECT (.+) FROM budgets").
+			WillReturnRows(sqlmock.NewRows(budgetColumns).AddRow(1, "food", "food", 1000, "monthly"))
+		mock.ExpectQuery("SELECT COALESCE(.+) FROM expenses").
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(850))
+
+		svc.ExpenseSaved(ctx, nil, &expense.Expense{ID: 3, Amount: 100, BaseAmount: 100, Tags: []string{"food"}, SpentOn: "2026-10-17"})
+
+		if assert.Len(t, notifier.alerts, 1) {
+			assert.Equal(t, 0.8, notifier.alerts[0].Threshold)
+			assert.Equal(t, int64(3), notifier.alerts[0].ExpenseID)
+		}
+	})
+
+	t.Run("ExpenseSaved() alerts for every crossed threshold", func(t *testing.T) {
+		notifier.alerts = nil
+		mock.ExpectQuery("SELECT (.+) FROM budgets").
+			WillReturnRows(sqlmock.NewRows(budgetColumns).AddRow(1, "food", "food", 1000, "monthly"))
+		mock.ExpectQuery("SELECT COALESCE(.+) FROM expenses").
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(1200))
+
+		prev := &expense.Expense{ID: 3, Amount: 100, BaseAmount: 100, Tags: []string{"food"}, SpentOn: "2026-10-17"}
+		svc.ExpenseSaved(ctx, prev, &expense.Expense{ID: 3, Amount: 600, BaseAmount: 600, Tags: []string{"food"}, SpentOn: "2026-10-17"})
+
+		assert.Len(t, notifier.alerts, 2)
+	})
+
+	t.Run("ExpenseSaved() does not alert below threshold", func(t *testing.T) {
+		notifier.alerts = nil
+		mock.ExpectQuery("SELECT (.+) FROM budgets").
+			WillReturnRows(sqlmock.NewRows(budgetColumns).AddRow(1, "food", "food", 1000, "monthly"))
+		mock.ExpectQuery("SELECT COALESCE(.+) FROM expenses").
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(500))
+
+		svc.ExpenseSaved(ctx, nil, &expense.Expense{ID: 3, Amount: 100, BaseAmount: 100, Tags: []string{"food"}, SpentOn: "2026-10-17"})
+
+		assert.Empty(t, notifier.alerts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ExpenseSaved() does not count expenses spent in another period", func(t *testing.T) {
+		notifier.alerts = nil
+		mock.ExpectQuery("SELECT (.+) FROM budgets").
+			WillReturnRows(sqlmock.NewRows(budgetColumns).AddRow(1, "food", "food", 1000, "monthly"))
+		mock.ExpectQuery("SELECT COALESCE(.+) FROM expenses WHERE (.+) spent_on >= \\$3 AND spent_on < \\$4").
+			WithArgs(sqlmock.AnyArg(), "food", "2026-10-01", "2026-11-01").
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(850))
+
+		svc.ExpenseSaved(ctx, nil, &expense.Expense{ID: 4, Amount: 900, BaseAmount: 900, Tags: []string{"food"}, SpentOn: "2026-09-30"})
+
+		assert.Empty(t, notifier.alerts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ExpenseSaved() skips untagged expenses", func(t *testing.T) {
+		notifier.alerts = nil
+
+		svc.ExpenseSaved(ctx, nil, &expense.Expense{ID: 3, Amount: 100})
+
+		assert.Empty(t, notifier.alerts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestServiceTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	notifier := &fakeNotifier{}
+	svc := NewService(db, notifier)
+	ctx := expense.WithTenant(context.Background(), "acme")
+
+	t.Run("GetByID() returns ErrNotFound for the budget of another tenant", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM budgets WHERE id = \$1 AND tenant_id = \$2`).
+			WithArgs(1, "acme").
+			WillReturnRows(sqlmock.NewRows(budgetColumns))
+
+		_, err := svc.GetByID(ctx, 1)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Delete() returns ErrNotFound for the budget of another tenant", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM budgets WHERE id = \$1 AND tenant_id = \$2`).
+			WithArgs(1, "acme").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := svc.Delete(ctx, 1)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ExpenseSaved() compares against the budgets of the tenant", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM budgets WHERE tenant_id = \$1 AND tag = ANY\(\$2\)`).
+			WithArgs("acme", pq.Array([]string{"food"})).
+			WillReturnRows(sqlmock.NewRows(budgetColumns))
+
+		svc.ExpenseSaved(ctx, nil, &expense.Expense{ID: 3, Amount: 100, BaseAmount: 100, Tags: []string{"food"}})
+
+		assert.Empty(t, notifier.alerts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
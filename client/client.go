@@ -0,0 +1,151 @@
+// Package client calls the REST API of a remote expenses server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phuangpheth/assessment/expense"
+)
+
+// Error is a non-2xx response of the server.
+type Error struct {
+	StatusCode int    `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s", e.StatusCode, e.Message)
+}
+
+// Unwrap maps 404 responses to expense.ErrNotFound.
+func (e *Error) Unwrap() error {
+	if e.StatusCode == http.StatusNotFound {
+		return expense.ErrNotFound
+	}
+	return nil
+}
+
+// Client has the same expense methods as expense.Service.
+type Client struct {
+	baseURL string
+	token   string
+	tenant  string
+	user    string
+	http    *http.Client
+}
+
+// New returns a client of the server at baseURL that authenticates with
+// token, the value of the Authorization header, and acts on behalf of
+// tenant, which may be empty.
+func New(baseURL, token, tenant string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		tenant:  tenant,
+		http:    httpClient,
+	}
+}
+
+// SetUser names the user the client acts on behalf of. The server rejects a
+// user other than the one token was issued to. An empty user leaves it to
+// the token.
+func (c *Client) SetUser(user string) {
+	c.user = user
+}
+
+// Save creates the expense. A ctx from expense.AllowDuplicates asks the
+// server to save it even when it is a likely duplicate.
+func (c *Client) Save(ctx context.Context, e *expense.Expense) (*expense.Expense, error) {
+	path := "/expenses"
+	if expense.DuplicatesAllowed(ctx) {
+		path += "?force=true"
+	}
+	var out expense.Expense
+	if err := c.do(ctx, http.MethodPost, path, e, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) Update(ctx context.Context, e *expense.Expense) (*expense.Expense, error) {
+	var out expense.Expense
+	if err := c.do(ctx, http.MethodPut, "/expenses/"+strconv.FormatInt(e.ID, 10), e, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) GetByID(ctx context.Context, id int64) (*expense.Expense, error) {
+	var out expense.Expense
+	if err := c.do(ctx, http.MethodGet, "/expenses/"+strconv.FormatInt(id, 10), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) List(ctx context.Context) ([]expense.Expense, error) {
+	out := make([]expense.Expense, 0)
+	if err := c.do(ctx, http.MethodGet, "/expenses", nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) Delete(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, "/expenses/"+strconv.FormatInt(id, 10), nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", c.token)
+	if c.tenant != "" {
+		req.Header.Set("X-Tenant-ID", c.tenant)
+	}
+	if c.user != "" {
+		req.Header.Set("X-User-ID", c.user)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		e := &Error{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(e); err != nil || e.Message == "" {
+			e.Message = http.StatusText(resp.StatusCode)
+		}
+		e.StatusCode = resp.StatusCode
+		return e
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "POST /expenses":
+			var e expense.Expense
+			json.NewDecoder(r.Body).Decode(&e)
+			e.ID = 1
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(e)
+		case "GET /expenses":
+			w.Write([]byte(`[{"id":2,"amount":30,"title":"Milk","note":"","tags":[]}]`))
+		case "DELETE /expenses/1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":404,"message":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL+"/", "November 10, 2009", "acme", srv.Client())
+	c.SetUser("ana")
+	ctx := context.Background()
+
+	t.Run("Save()", func(t *testing.T) {
+		e, err := c.Save(ctx, &expense.Expense{Amount: 75, Title: "Halo Kitty"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), e.ID)
+			assert.Equal(t, "November 10, 2009", got.Header.Get("Authorization"))
+			assert.Equal(t, "acme", got.Header.Get("X-Tenant-ID"))
+			assert.Equal(t, "ana", got.Header.Get("X-User-ID"))
+		}
+	})
+
+	t.Run("Save() forces duplicates", func(t *testing.T) {
+		_, err := c.Save(expense.AllowDuplicates(ctx), &expense.Expense{Amount: 75, Title: "Halo Kitty"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "true", got.URL.Query().Get("force"))
+		}
+	})
+
+	t.Run("List()", func(t *testing.T) {
+		exps, err := c.List(ctx)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []expense.Expense{{ID: 2, Amount: 30, Title: "Milk", Tags: []string{}}}, exps)
+		}
+	})
+
+	t.Run("Delete()", func(t *testing.T) {
+		err := c.Delete(ctx, 1)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("GetByID() returns not found", func(t *testing.T) {
+		_, err := c.GetByID(ctx, 3)
+
+		assert.ErrorIs(t, err, expense.ErrNotFound)
+		assert.EqualError(t, err, "404 not found")
+	})
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/expense"
+)
+
+type accessHandler struct {
+	accessSvc *access.Service
+}
+
+func NewAccessHandler(router *echo.Echo, svc *access.Service) error {
+	if router == nil || svc == nil {
+		return errors.New("invalid argument")
+	}
+	h := accessHandler{
+		accessSvc: svc,
+	}
+
+	router.GET("/roles", h.ListRoles, Auth)
+	router.PUT("/users/:user/roles/:role", h.GrantRole, Auth)
+	router.DELETE("/users/:user/roles/:role", h.RevokeRole, Auth)
+	return nil
+}
+
+// ListRoles returns the role assignments of the tenant of the caller.
+func (h *accessHandler) ListRoles(c echo.Context) error {
+	ctx := c.Request().Context()
+	as, err := h.accessSvc.List(ctx, expense.TenantFromContext(ctx))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, as)
+}
+
+// GrantRole assigns the role to the user within the tenant of the caller.
+func (h *accessHandler) GrantRole(c echo.Context) error {
+	ctx := c.Request().Context()
+	a, err := h.accessSvc.Grant(ctx, &access.Assignment{
+		Tenant: expense.TenantFromContext(ctx),
+		User:   c.Param("user"),
+		Role:   access.Role(c.Param("role")),
+	})
+	if errors.Is(err, access.ErrUserEmpty) || errors.Is(err, access.ErrRoleInvalid) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, a)
+}
+
+// RevokeRole removes the role from the user within the tenant of the caller.
+func (h *accessHandler) RevokeRole(c echo.Context) error {
+	ctx := c.Request().Context()
+	err := h.accessSvc.Revoke(ctx, expense.TenantFromContext(ctx), c.Param("user"), access.Role(c.Param("role")))
+	if errors.Is(err, access.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": access.ErrNotFound.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
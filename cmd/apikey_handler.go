@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/apikey"
+	"github.com/phuangpheth/assessment/expense"
+)
+
+type apiKeyHandler struct {
+	apiKeySvc *apikey.Service
+}
+
+func NewAPIKeyHandler(router *echo.Echo, svc *apikey.Service) error {
+	if router == nil || svc == nil {
+		return errors.New("invalid argument")
+	}
+	h := apiKeyHandler{
+		apiKeySvc: svc,
+	}
+
+	router.GET("/api-keys", h.ListAPIKeys, Auth)
+	router.POST("/api-keys", h.CreateAPIKey, Auth)
+	router.DELETE("/api-keys/:id", h.RevokeAPIKey, Auth)
+	router.POST("/api-keys/:id/rotate", h.RotateAPIKey, Auth)
+	return nil
+}
+
+// CreateAPIKey issues a key for the tenant of the caller. The response is
+// the only time the plaintext key is shown.
+func (h *apiKeyHandler) CreateAPIKey(c echo.Context) error {
+	var k apikey.Key
+	if err := c.Bind(&k); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid request body",
+		})
+	}
+	if err := k.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+
+	ctx := c.Request().Context()
+	k.Tenant = expense.TenantFromContext(ctx)
+	key, err := h.apiKeySvc.Create(ctx, &k)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusCreated, key)
+}
+
+func (h *apiKeyHandler) ListAPIKeys(c echo.Context) error {
+	ctx := c.Request().Context()
+	ks, err := h.apiKeySvc.List(ctx, expense.TenantFromContext(ctx))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, ks)
+}
+
+func (h *apiKeyHandler) RevokeAPIKey(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	err = h.apiKeySvc.Revoke(ctx, expense.TenantFromContext(ctx), id)
+	if errors.Is(err, apikey.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": apikey.ErrNotFound.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RotateAPIKey replaces a key with a new one of the same name and scopes and
+// returns the new plaintext key.
+func (h *apiKeyHandler) RotateAPIKey(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	key, err := h.apiKeySvc.Rotate(ctx, expense.TenantFromContext(ctx), id)
+	if errors.Is(err, apikey.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": apikey.ErrNotFound.Error(),
+		})
+	}
+	if errors.Is(err, apikey.ErrExpiresInvalid) {
+		return c.JSON(http.StatusConflict, echo.Map{
+			"code":    http.StatusConflict,
+			"message": "expired keys cannot be rotated",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusCreated, key)
+}
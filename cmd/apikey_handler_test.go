@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/apikey"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerAPIKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := echo.New()
+	h := &apiKeyHandler{apikey.NewService(db)}
+	createdAt := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	t.Run("CreateAPIKey() shows the key once", func(t *testing.T) {
+		body := `{"name":"ci","scopes":["read","write"]}`
+		mock.ExpectQuery("INSERT INTO api_keys (.+) RETURNING").
+			WithArgs("acme", "ci", sqlmock.AnyArg(), sqlmock.AnyArg(), pq.Array([]string{"read", "write"}), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt))
+
+		req := httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req = req.WithContext(expense.WithTenant(req.Context(), "acme"))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := h.CreateAPIKey(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			var got apikey.Key
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, "acme", got.Tenant)
+			assert.True(t, strings.HasPrefix(got.Key, apikey.Prefix+got.Prefix+"_"))
+		}
+	})
+
+	t.Run("CreateAPIKey() returns invalid scope", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(`{"name":"ci","scopes":["root"]}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":400,"message":"scope must be one of read, write or admin"}`
+
+		err := h.CreateAPIKey(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("ListAPIKeys() hides the keys", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM api_keys").
+			WithArgs("").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at"}).
+				AddRow(1, "", "ci", "0a1b2c3d", pq.Array([]string{"read"}), nil, createdAt, createdAt))
+
+		req := httptest.NewRequest(http.MethodGet, "/api-keys", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `[{"id":1,"name":"ci","prefix":"0a1b2c3d","scopes":["read"],"last_used_at":"2026-10-18T00:00:00Z","created_at":"2026-10-18T00:00:00Z"}]`
+
+		err := h.ListAPIKeys(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("RevokeAPIKey() returns not found", func(t *testing.T) {
+		mock.ExpectExec("UPDATE api_keys SET revoked_at").
+			WithArgs(2, "").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		req := httptest.NewRequest(http.MethodDelete, "/api-keys/2", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("2")
+		want := `{"code":404,"message":"not found"}`
+
+		err := h.RevokeAPIKey(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/attachment"
+	"github.com/phuangpheth/assessment/expense"
+)
+
+// attachmentFormOverhead is what the multipart form of an upload may add to
+// the size of the attachment.
+const attachmentFormOverhead = 64 << 10
+
+type attachmentHandler struct {
+	expenseSvc    *expense.Service
+	attachmentSvc *attachment.Service
+}
+
+func NewAttachmentHandler(router *echo.Echo, expenseSvc *expense.Service, attachmentSvc *attachment.Service) error {
+	if router == nil || expenseSvc == nil || attachmentSvc == nil {
+		return errors.New("invalid argument")
+	}
+	h := attachmentHandler{
+		expenseSvc:    expenseSvc,
+		attachmentSvc: attachmentSvc,
+	}
+
+	router.POST("/expenses/:id/attachments", h.SaveAttachment, Auth)
+	router.GET("/expenses/:id/attachments", h.ListAttachments, Auth)
+	router.GET("/expenses/:id/attachments/:attachmentID", h.GetAttachment, Auth)
+	router.DELETE("/expenses/:id/attachments/:attachmentID", h.DeleteAttachment, Auth)
+	return nil
+}
+
+func (h *attachmentHandler) SaveAttachment(c echo.Context) error {
+	expenseID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, h.attachmentSvc.MaxSize()+attachmentFormOverhead)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid request body",
+		})
+	}
+
+	ctx := req.Context()
+	if _, err := h.expenseSvc.GetByID(ctx, expenseID); err != nil {
+		return attachmentError(c, err)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid request body",
+		})
+	}
+	defer f.Close()
+
+	a, err := h.attachmentSvc.Save(ctx, expenseID, fh.Filename, f)
+	if err != nil {
+		return attachmentError(c, err)
+	}
+	return c.JSON(http.StatusCreated, a)
+}
+
+func (h *attachmentHandler) ListAttachments(c echo.Context) error {
+	expenseID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.expenseSvc.GetByID(ctx, expenseID); err != nil {
+		return attachmentError(c, err)
+	}
+	as, err := h.attachmentSvc.List(ctx, expenseID)
+	if err != nil {
+		return attachmentError(c, err)
+	}
+	return c.JSON(http.StatusOK, as)
+}
+
+func (h *attachmentHandler) GetAttachment(c echo.Context) error {
+	expenseID, id, err := attachmentParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.expenseSvc.GetByID(ctx, expenseID); err != nil {
+		return attachmentError(c, err)
+	}
+	a, rc, err := h.attachmentSvc.Open(ctx, expenseID, id)
+	if err != nil {
+		return attachmentError(c, err)
+	}
+	defer rc.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentLength, strconv.FormatInt(a.Size, 10))
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", a.Filename))
+	return c.Stream(http.StatusOK, a.ContentType, rc)
+}
+
+func (h *attachmentHandler) DeleteAttachment(c echo.Context) error {
+	expenseID, id, err := attachmentParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.expenseSvc.GetByID(ctx, expenseID); err != nil {
+		return attachmentError(c, err)
+	}
+	if err := h.attachmentSvc.Delete(ctx, expenseID, id); err != nil {
+		return attachmentError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func attachmentParams(c echo.Context) (expenseID, id int64, err error) {
+	expenseID, err = strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	id, err = strconv.ParseInt(c.Param("attachmentID"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return expenseID, id, nil
+}
+
+func attachmentError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, expense.ErrNotFound), errors.Is(err, attachment.ErrNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": "not found",
+		})
+	case errors.Is(err, attachment.ErrTooLarge):
+		return c.JSON(http.StatusRequestEntityTooLarge, echo.Map{
+			"code":    http.StatusRequestEntityTooLarge,
+			"message": err.Error(),
+		})
+	case errors.Is(err, attachment.ErrContentType):
+		return c.JSON(http.StatusUnsupportedMediaType, echo.Map{
+			"code":    http.StatusUnsupportedMediaType,
+			"message": err.Error(),
+		})
+	case errors.Is(err, attachment.ErrEmpty):
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, echo.Map{
+		"code":    http.StatusInternalServerError,
+		"message": "Internal Server Error",
+	})
+}
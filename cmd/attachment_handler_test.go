@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/attachment"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMultipartRequest(t *testing.T, target, filename, content string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	fw, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(content))
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	return req
+}
+
+func TestNewAttachmentHandler(t *testing.T) {
+	t.Run("NewAttachmentHandler() returns invalid argument", func(t *testing.T) {
+		want := "invalid argument"
+
+		err := NewAttachmentHandler(echo.New(), &expense.Service{}, nil)
+		assert.EqualError(t, err, want)
+	})
+}
+
+func TestHandlerSaveAttachment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store, err := attachment.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
+	e := echo.New()
+	h := &attachmentHandler{
+		expenseSvc:    expense.NewService(db),
+		attachmentSvc: attachment.NewService(db, store, attachment.Limits{MaxSize: 32}),
+	}
+
+	t.Run("SaveAttachment()", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(1, 75, "taxi", "", pq.Array([]string{}), "", nil, 0, nil, "draft", nil)
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(1, "").WillReturnRows(rows)
+		mock.ExpectQuery(`INSERT INTO attachments (.+) RETURNING`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(5, time.Now()))
+
+		req := newMultipartRequest(t, "/expenses/1/attachments", "receipt.pdf", "%PDF-1.4 receipt")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		err := h.SaveAttachment(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"content_type":"application/pdf"`)
+		}
+	})
+
+	t.Run("SaveAttachment() returns not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(2, "").WillReturnRows(sqlmock.NewRows(columns))
+
+		req := newMultipartRequest(t, "/expenses/2/attachments", "receipt.pdf", "%PDF-1.4 receipt")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("2")
+		want := `{"code":404,"message":"not found"}`
+
+		err := h.SaveAttachment(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("SaveAttachment() returns unsupported media type", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(1, 75, "taxi", "", pq.Array([]string{}), "", nil, 0, nil, "draft", nil)
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(1, "").WillReturnRows(rows)
+
+		req := newMultipartRequest(t, "/expenses/1/attachments", "receipt.txt", "plain text")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		want := `{"code":415,"message":"content type is not allowed"}`
+
+		err := h.SaveAttachment(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("SaveAttachment() stops reading a body larger than the limit", func(t *testing.T) {
+		req := newMultipartRequest(t, "/expenses/1/attachments", "receipt.pdf", strings.Repeat("x", 32+attachmentFormOverhead))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		want := `{"code":400,"message":"invalid request body"}`
+
+		err := h.SaveAttachment(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("SaveAttachment() returns invalid request body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/expenses/1/attachments", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		want := `{"code":400,"message":"invalid request body"}`
+
+		err := h.SaveAttachment(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
+
+func TestHandlerListAttachments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store, err := attachment.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
+	e := echo.New()
+	h := &attachmentHandler{
+		expenseSvc:    expense.NewService(db),
+		attachmentSvc: attachment.NewService(db, store, attachment.Limits{}),
+	}
+
+	t.Run("ListAttachments()", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(1, 75, "taxi", "", pq.Array([]string{}), "", nil, 0, nil, "draft", nil)
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(1, "").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT (.+) FROM attachments").WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "expense_id", "filename", "content_type", "size", "sha256", "storage_key", "created_at"}))
+
+		req := httptest.NewRequest(http.MethodGet, "/expenses/1/attachments", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		err := h.ListAttachments(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "[]", strings.TrimSpace(rec.Body.String()))
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListAttachments() returns not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(2, "").WillReturnRows(sqlmock.NewRows(columns))
+
+		req := httptest.NewRequest(http.MethodGet, "/expenses/2/attachments", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("2")
+		want := `{"code":404,"message":"not found"}`
+
+		err := h.ListAttachments(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestHandlerAttachmentOfOtherTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store, err := attachment.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
+	e := echo.New()
+	h := &attachmentHandler{
+		expenseSvc:    expense.NewService(db),
+		attachmentSvc: attachment.NewService(db, store, attachment.Limits{}),
+	}
+	newContext := func(method string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, "/expenses/1/attachments/4", nil)
+		req = req.WithContext(expense.WithTenant(req.Context(), "globex"))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id", "attachmentID")
+		c.SetParamValues("1", "4")
+		return c, rec
+	}
+	want := `{"code":404,"message":"not found"}`
+
+	t.Run("GetAttachment() returns not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(1, "globex").WillReturnRows(sqlmock.NewRows(columns))
+		c, rec := newContext(http.MethodGet)
+
+		err := h.GetAttachment(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteAttachment() returns not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(1, "globex").WillReturnRows(sqlmock.NewRows(columns))
+		c, rec := newContext(http.MethodDelete)
+
+		err := h.DeleteAttachment(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
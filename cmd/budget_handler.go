@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/budget"
+)
+
+type budgetHandler struct {
+	budgetSvc *budget.Service
+}
+
+func NewBudgetHandler(router *echo.Echo, svc *budget.Service) error {
+	if router == nil || svc == nil {
+		return errors.New("invalid argument")
+	}
+	h := budgetHandler{
+		budgetSvc: svc,
+	}
+
+	router.GET("/budgets", h.ListBudgets, Auth)
+	router.GET("/budgets/:id", h.GetBudgetByID, Auth)
+	router.GET("/budgets/:id/status", h.GetBudgetStatus, Auth)
+	router.POST("/budgets", h.SaveBudget, Auth)
+	router.DELETE("/budgets/:id", h.DeleteBudget, Auth)
+	return nil
+}
+
+func (h *budgetHandler) SaveBudget(c echo.Context) error {
+	var b budget.Budget
+	if err := c.Bind(&b); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid request body",
+		})
+	}
+	if err := b.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+
+	ctx := c.Request().Context()
+	bud, err := h.budgetSvc.Save(ctx, &b)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusCreated, bud)
+}
+
+func (h *budgetHandler) ListBudgets(c echo.Context) error {
+	ctx := c.Request().Context()
+	bs, err := h.budgetSvc.List(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, bs)
+}
+
+func (h *budgetHandler) GetBudgetByID(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	b, err := h.budgetSvc.GetByID(ctx, id)
+	if errors.Is(err, budget.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": errors.Unwrap(err).Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, b)
+}
+
+func (h *budgetHandler) GetBudgetStatus(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	st, err := h.budgetSvc.Status(ctx, id)
+	if errors.Is(err, budget.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": errors.Unwrap(err).Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, st)
+}
+
+func (h *budgetHandler) DeleteBudget(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	err = h.budgetSvc.Delete(ctx, id)
+	if errors.Is(err, budget.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": errors.Unwrap(err).Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
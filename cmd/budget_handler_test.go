@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/budget"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerGetBudgetStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	columns := []string{"id", "name", "tag", "amount_limit", "period"}
+	e := echo.New()
+	h := &budgetHandler{budget.NewService(db, nil)}
+
+	t.Run("GetBudgetStatus()", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM budgets").WithArgs(1, "").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "food", "food", 1000, "monthly"))
+		mock.ExpectQuery("SELECT COALESCE(.+) FROM expenses").
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(250))
+
+		req := httptest.NewRequest(http.MethodGet, "/budgets/1/status", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		err := h.GetBudgetStatus(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"spent":250,"remaining":750,"percent":25`)
+		}
+	})
+
+	t.Run("GetBudgetStatus() returns not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM budgets").WithArgs(2, "").WillReturnRows(sqlmock.NewRows(columns))
+
+		req := httptest.NewRequest(http.MethodGet, "/budgets/2/status", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("2")
+		want := `{"code":404,"message":"not found"}`
+
+		err := h.GetBudgetStatus(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
+
+func TestHandlerSaveBudget(t *testing.T) {
+	e := echo.New()
+	h := &budgetHandler{budget.NewService(nil, nil)}
+
+	t.Run("SaveBudget() returns invalid period", func(t *testing.T) {
+		body := `{"tag":"food","limit":1000,"period":"daily"}`
+		req := httptest.NewRequest(http.MethodPost, "/budgets", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":400,"message":"period must be one of weekly, monthly or yearly"}`
+
+		err := h.SaveBudget(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
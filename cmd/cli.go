@@ -0,0 +1,699 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/attachment"
+	"github.com/phuangpheth/assessment/client"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/retention"
+)
+
+const usage = `Usage: assessment [flags] <command> [args]
+
+Commands:
+  serve                      start the HTTP and gRPC servers (default)
+  migrate                    create or update the database schema
+  expense add                create an expense
+  expense list               list expenses
+  expense get <id>           show an expense
+  expense update <id>        change an expense
+  expense delete <id>        delete an expense
+  import <file|->            create expenses from a CSV or JSON file
+  export                     write every expense as JSON or CSV
+  report                     total expenses per tag
+  role grant <user> <role>   give a user a role in -tenant
+  role revoke <user> <role>  take a role away from a user in -tenant
+  role list                  list the roles of every user in -tenant
+  retention run              archive and purge expenses by the retention policy
+  retention log              list the archived and purged expenses
+  token issue                sign a bearer token for -user in -tenant
+
+The expense, import, export and report commands call the server given by
+-server, or use the database given by -database-url when -server is empty.
+The migrate, role and retention commands always use the database. The token
+command signs with AUTH_SECRET, as the server verifies.
+
+Flags:
+`
+
+// ErrUsage is returned when the command line is invalid.
+var ErrUsage = errors.New("invalid usage")
+
+// expenseStore is implemented by *expense.Service, which works on the
+// database, and by *client.Client, which calls a remote server.
+type expenseStore interface {
+	Save(ctx context.Context, e *expense.Expense) (*expense.Expense, error)
+	Update(ctx context.Context, e *expense.Expense) (*expense.Expense, error)
+	GetByID(ctx context.Context, id int64) (*expense.Expense, error)
+	List(ctx context.Context) ([]expense.Expense, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// txStore is implemented by *expense.Service, whose units of work run in
+// one database transaction.
+type txStore interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// withTx runs fn in a unit of work of store when it has them, so that
+// either every change of fn is made or none is. Each call of a remote
+// server commits on its own.
+func withTx(ctx context.Context, store expenseStore, fn func(ctx context.Context) error) error {
+	if s, ok := store.(txStore); ok {
+		return s.WithTx(ctx, fn)
+	}
+	return fn(ctx)
+}
+
+// globals are the flags accepted before and after the command.
+type globals struct {
+	server      string
+	databaseURL string
+	token       string
+	tenant      string
+	user        string
+	output      string
+}
+
+func (g *globals) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.server, "server", g.server, "base URL of a remote server, e.g. http://localhost:3001")
+	fs.StringVar(&g.databaseURL, "database-url", g.databaseURL, "database to use when -server is empty")
+	fs.StringVar(&g.token, "token", g.token, "authorization sent to the server: Bearer <token> or ApiKey <key>")
+	fs.StringVar(&g.tenant, "tenant", g.tenant, "tenant sent to the server")
+	fs.StringVar(&g.user, "user", g.user, "user sent to the server")
+	fs.StringVar(&g.output, "o", g.output, "output format: table, json or csv")
+}
+
+type cli struct {
+	globals
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	// open returns the store used by the expense commands.
+	open func(g globals) (expenseStore, func(), error)
+}
+
+// Execute runs the command given on the command line. Without a command it
+// starts the servers.
+func Execute() {
+	c := &cli{
+		globals: globals{
+			server:      os.Getenv("EXPENSE_SERVER"),
+			databaseURL: os.Getenv("DATABASE_URL"),
+			token:       os.Getenv("EXPENSE_TOKEN"),
+			tenant:      os.Getenv("EXPENSE_TENANT"),
+			user:        os.Getenv("EXPENSE_USER"),
+			output:      "table",
+		},
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		open:   openStore,
+	}
+	if err := c.run(context.Background(), os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+func openStore(g globals) (expenseStore, func(), error) {
+	if g.server != "" {
+		cl := client.New(g.server, g.token, g.tenant, nil)
+		cl.SetUser(g.user)
+		return cl, func() {}, nil
+	}
+	if g.databaseURL == "" {
+		return nil, nil, errors.New("either -server or -database-url is required")
+	}
+	db, err := openDB(context.Background(), g.databaseURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	svc, _, err := newExpenseService(db)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return svc, func() { db.Close() }, nil
+}
+
+// scope returns ctx within the -tenant and acting as the -user, which the
+// database store reads and writes for. The client sends them to the server
+// instead.
+func (c *cli) scope(ctx context.Context) context.Context {
+	ctx = expense.WithTenant(ctx, c.tenant)
+	return expense.WithActor(ctx, expense.Actor{ID: c.user})
+}
+
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	c.globals.register(fs)
+	return fs
+}
+
+// parse parses args and checks the output format before anything is changed.
+func (c *cli) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch c.output {
+	case "table", "json", "csv":
+		return nil
+	}
+	return fmt.Errorf("%w: unknown output format %q", ErrUsage, c.output)
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	fs := c.flagSet("assessment")
+	fs.Usage = func() {
+		fmt.Fprint(c.stderr, usage)
+		fs.PrintDefaults()
+	}
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		serve()
+		return nil
+	}
+
+	switch cmd, args := args[0], args[1:]; cmd {
+	case "serve":
+		serve()
+		return nil
+	case "migrate":
+		return c.migrate(ctx, args)
+	case "expense":
+		return c.expense(ctx, args)
+	case "import":
+		return c.importExpenses(ctx, args)
+	case "export":
+		return c.export(ctx, args)
+	case "report":
+		return c.report(ctx, args)
+	case "role":
+		return c.role(ctx, args)
+	case "retention":
+		return c.retention(ctx, args)
+	case "token":
+		return c.issueToken(args)
+	default:
+		fs.Usage()
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, cmd)
+	}
+}
+
+func (c *cli) migrate(ctx context.Context, args []string) error {
+	fs := c.flagSet("migrate")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if c.databaseURL == "" {
+		return errors.New("-database-url is required")
+	}
+	db, err := openDB(ctx, c.databaseURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := createSchema(ctx, db); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, "schema is up to date")
+	return nil
+}
+
+func (c *cli) expense(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: expense requires one of add, list, get, update or delete", ErrUsage)
+	}
+	sub, args := args[0], args[1:]
+	fs := c.flagSet("expense " + sub)
+
+	var (
+		amount      float64
+		title, note string
+		tags        string
+	)
+	if sub == "add" || sub == "update" {
+		fs.Float64Var(&amount, "amount", 0, "amount")
+		fs.StringVar(&title, "title", "", "title")
+		fs.StringVar(&note, "note", "", "note")
+		fs.StringVar(&tags, "tags", "", "comma-separated tags")
+	}
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	var id int64
+	switch sub {
+	case "get", "update", "delete":
+		if fs.NArg() != 1 {
+			return fmt.Errorf("%w: expense %s requires an id", ErrUsage, sub)
+		}
+		var err error
+		if id, err = strconv.ParseInt(fs.Arg(0), 10, 64); err != nil {
+			return fmt.Errorf("%w: invalid id %q", ErrUsage, fs.Arg(0))
+		}
+	case "add", "list":
+	default:
+		return fmt.Errorf("%w: unknown command expense %s", ErrUsage, sub)
+	}
+
+	store, closeStore, err := c.open(c.globals)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	ctx = c.scope(ctx)
+
+	switch sub {
+	case "add":
+		e := &expense.Expense{Amount: amount, Title: title, Note: note, Tags: splitTags(tags)}
+		if err := e.Validate(); err != nil {
+			return err
+		}
+		if e, err = store.Save(ctx, e); err != nil {
+			return err
+		}
+		return writeExpenses(c.stdout, c.output, []expense.Expense{*e})
+	case "list":
+		exps, err := store.List(ctx)
+		if err != nil {
+			return err
+		}
+		return writeExpenses(c.stdout, c.output, exps)
+	case "get":
+		e, err := store.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return writeExpenses(c.stdout, c.output, []expense.Expense{*e})
+	case "update":
+		e, err := store.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "amount":
+				e.Amount = amount
+			case "title":
+				e.Title = title
+			case "note":
+				e.Note = note
+			case "tags":
+				e.Tags = splitTags(tags)
+			}
+		})
+		if err := e.Validate(); err != nil {
+			return err
+		}
+		if e, err = store.Update(ctx, e); err != nil {
+			return err
+		}
+		return writeExpenses(c.stdout, c.output, []expense.Expense{*e})
+	default:
+		return store.Delete(ctx, id)
+	}
+}
+
+func (c *cli) importExpenses(ctx context.Context, args []string) error {
+	fs := c.flagSet("import")
+	format := fs.String("format", "", "input format: csv or json (default from the file extension)")
+	force := fs.Bool("force", false, "save expenses even when they are likely duplicates")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: import requires a file, or - for stdin", ErrUsage)
+	}
+
+	name := fs.Arg(0)
+	r := c.stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(name), ".")
+	}
+
+	var exps []expense.Expense
+	var err error
+	switch *format {
+	case "csv":
+		exps, err = readCSV(r)
+	case "json":
+		err = json.NewDecoder(r).Decode(&exps)
+	default:
+		return fmt.Errorf("%w: unknown import format %q", ErrUsage, *format)
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	for i := range exps {
+		if err := exps[i].Validate(); err != nil {
+			return fmt.Errorf("expense %d: %w", i+1, err)
+		}
+	}
+
+	store, closeStore, err := c.open(c.globals)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	ctx = c.scope(ctx)
+	if *force {
+		ctx = expense.AllowDuplicates(ctx)
+	}
+
+	var saved []expense.Expense
+	err = withTx(ctx, store, func(ctx context.Context) error {
+		saved = make([]expense.Expense, 0, len(exps))
+		for i := range exps {
+			e := exps[i]
+			e.ID = 0
+			got, err := store.Save(ctx, &e)
+			if err != nil {
+				return fmt.Errorf("expense %d: %w", i+1, err)
+			}
+			saved = append(saved, *got)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return writeExpenses(c.stdout, c.output, saved)
+}
+
+func (c *cli) export(ctx context.Context, args []string) error {
+	fs := c.flagSet("export")
+	file := fs.String("file", "", "write to file instead of stdout")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if c.output == "table" {
+		c.output = "json"
+	}
+
+	store, closeStore, err := c.open(c.globals)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	ctx = c.scope(ctx)
+
+	exps, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	if *file == "" {
+		return writeExpenses(c.stdout, c.output, exps)
+	}
+	f, err := os.Create(*file)
+	if err != nil {
+		return err
+	}
+	if err := writeExpenses(f, c.output, exps); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (c *cli) report(ctx context.Context, args []string) error {
+	fs := c.flagSet("report")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	store, closeStore, err := c.open(c.globals)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	ctx = c.scope(ctx)
+
+	exps, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	return writeGroups(c.stdout, c.output, groupByTag(exps))
+}
+
+func (c *cli) role(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: role requires one of grant, revoke or list", ErrUsage)
+	}
+	sub, args := args[0], args[1:]
+	fs := c.flagSet("role " + sub)
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	switch sub {
+	case "grant", "revoke":
+		if fs.NArg() != 2 {
+			return fmt.Errorf("%w: role %s requires a user and a role", ErrUsage, sub)
+		}
+	case "list":
+	default:
+		return fmt.Errorf("%w: unknown command role %s", ErrUsage, sub)
+	}
+	if c.databaseURL == "" {
+		return errors.New("-database-url is required")
+	}
+	db, err := openDB(ctx, c.databaseURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	svc := access.NewService(db)
+
+	switch sub {
+	case "grant":
+		a, err := svc.Grant(ctx, &access.Assignment{Tenant: c.tenant, User: fs.Arg(0), Role: access.Role(fs.Arg(1))})
+		if err != nil {
+			return err
+		}
+		return writeAssignments(c.stdout, c.output, []access.Assignment{*a})
+	case "revoke":
+		return svc.Revoke(ctx, c.tenant, fs.Arg(0), access.Role(fs.Arg(1)))
+	default:
+		as, err := svc.List(ctx, c.tenant)
+		if err != nil {
+			return err
+		}
+		return writeAssignments(c.stdout, c.output, as)
+	}
+}
+
+func (c *cli) retention(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: retention requires one of run or log", ErrUsage)
+	}
+	sub, args := args[0], args[1:]
+	fs := c.flagSet("retention " + sub)
+
+	var (
+		policy retention.Policy
+		dryRun bool
+		limit  uint64
+	)
+	switch sub {
+	case "run":
+		var err error
+		if policy, err = retentionPolicy(); err != nil {
+			return err
+		}
+		fs.IntVar(&policy.ArchiveAfterYears, "archive-after-years", policy.ArchiveAfterYears, "archive expenses spent more than this many years ago, 0 to skip")
+		fs.IntVar(&policy.PurgeAfterDays, "purge-after-days", policy.PurgeAfterDays, "purge expenses deleted more than this many days ago, 0 to skip")
+		fs.BoolVar(&dryRun, "dry-run", false, "only count the expenses that would be archived and purged")
+	case "log":
+		fs.Uint64Var(&limit, "limit", 50, "number of entries to list")
+	default:
+		return fmt.Errorf("%w: unknown command retention %s", ErrUsage, sub)
+	}
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if sub == "run" {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrUsage, err)
+		}
+		if !policy.Enabled() {
+			return fmt.Errorf("%w: retention run requires -archive-after-years or -purge-after-days", ErrUsage)
+		}
+	}
+	if c.databaseURL == "" {
+		return errors.New("-database-url is required")
+	}
+	db, err := openDB(ctx, c.databaseURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	expenseSvc, _, err := newExpenseService(db)
+	if err != nil {
+		return err
+	}
+	store, err := newBlobStore()
+	if err != nil {
+		return err
+	}
+	svc := retention.NewService(db, expenseSvc, attachment.NewService(db, store, attachment.Limits{}))
+
+	if sub == "log" {
+		es, err := svc.Log(ctx, limit)
+		if err != nil {
+			return err
+		}
+		return writeRetentionLog(c.stdout, c.output, es)
+	}
+	ctx = expense.WithActor(ctx, expense.Actor{ID: c.user})
+	r, err := svc.Run(ctx, policy, time.Now(), dryRun)
+	if err != nil {
+		return err
+	}
+	return writeRetentionReport(c.stdout, c.output, r)
+}
+
+// issueToken prints a bearer token of the -user in the -tenant, signed with
+// AUTH_SECRET, as the value of the Authorization header.
+func (c *cli) issueToken(args []string) error {
+	if len(args) == 0 || args[0] != "issue" {
+		return fmt.Errorf("%w: token requires issue", ErrUsage)
+	}
+	fs := c.flagSet("token issue")
+	ttl := fs.Duration("ttl", time.Hour, "how long the token is valid")
+	if err := c.parse(fs, args[1:]); err != nil {
+		return err
+	}
+	signer, err := newSigner()
+	if err != nil {
+		return err
+	}
+	if signer == nil {
+		return errors.New("AUTH_SECRET is required")
+	}
+	tk, err := signer.Issue(c.tenant, c.user, *ttl)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, SchemeBearer+tk)
+	return nil
+}
+
+// groupByTag totals exps per tag, sorted by tag. Expenses without tags are
+// grouped under an empty key.
+func groupByTag(exps []expense.Expense) []expense.Group {
+	byTag := make(map[string]*expense.Group)
+	add := func(tag string, amount float64) {
+		g, ok := byTag[tag]
+		if !ok {
+			g = &expense.Group{Key: tag}
+			byTag[tag] = g
+		}
+		g.Count++
+		g.Total += amount
+	}
+	for _, e := range exps {
+		if len(e.Tags) == 0 {
+			add("", e.Amount)
+		}
+		for _, t := range e.Tags {
+			add(t, e.Amount)
+		}
+	}
+
+	gs := make([]expense.Group, 0, len(byTag))
+	for _, g := range byTag {
+		gs = append(gs, *g)
+	}
+	sort.Slice(gs, func(i, j int) bool { return gs[i].Key < gs[j].Key })
+	return gs
+}
+
+func splitTags(s string) []string {
+	tags := make([]string, 0)
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// readCSV reads expenses with the header written by writeExpenses. The id
+// column is optional and ignored; the other columns but amount and title
+// are optional.
+func readCSV(r io.Reader) ([]expense.Expense, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, h := range []string{"amount", "title"} {
+		if _, ok := cols[h]; !ok {
+			return nil, fmt.Errorf("missing %s column", h)
+		}
+	}
+	get := func(rec []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(rec) {
+			return rec[i]
+		}
+		return ""
+	}
+
+	var exps []expense.Expense
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return exps, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		amount, err := strconv.ParseFloat(get(rec, "amount"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount", line)
+		}
+		exps = append(exps, expense.Expense{
+			Amount:   amount,
+			Currency: get(rec, "currency"),
+			SpentOn:  get(rec, "spent_on"),
+			Title:    get(rec, "title"),
+			Note:     get(rec, "note"),
+			Tags:     strings.FieldsFunc(get(rec, "tags"), func(r rune) bool { return r == ';' }),
+		})
+	}
+}
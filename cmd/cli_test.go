@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+// memStore is an in-memory expenseStore.
+type memStore struct {
+	exps   []expense.Expense
+	nextID int64
+}
+
+func (m *memStore) Save(ctx context.Context, e *expense.Expense) (*expense.Expense, error) {
+	m.nextID++
+	e.ID = m.nextID
+	m.exps = append(m.exps, *e)
+	return e, nil
+}
+
+func (m *memStore) Update(ctx context.Context, e *expense.Expense) (*expense.Expense, error) {
+	for i := range m.exps {
+		if m.exps[i].ID == e.ID {
+			m.exps[i] = *e
+			return e, nil
+		}
+	}
+	return nil, expense.ErrNotFound
+}
+
+func (m *memStore) GetByID(ctx context.Context, id int64) (*expense.Expense, error) {
+	for _, e := range m.exps {
+		if e.ID == id {
+			return &e, nil
+		}
+	}
+	return nil, expense.ErrNotFound
+}
+
+func (m *memStore) List(ctx context.Context) ([]expense.Expense, error) {
+	return append([]expense.Expense(nil), m.exps...), nil
+}
+
+func (m *memStore) Delete(ctx context.Context, id int64) error {
+	for i := range m.exps {
+		if m.exps[i].ID == id {
+			m.exps = append(m.exps[:i], m.exps[i+1:]...)
+			return nil
+		}
+	}
+	return expense.ErrNotFound
+}
+
+func newTestCLI(store *memStore, stdin string) (*cli, *bytes.Buffer) {
+	var out bytes.Buffer
+	return &cli{
+		globals: globals{output: "table"},
+		stdin:   strings.NewReader(stdin),
+		stdout:  &out,
+		stderr:  &bytes.Buffer{},
+		open: func(globals) (expenseStore, func(), error) {
+			return store, func() {}, nil
+		},
+	}, &out
+}
+
+func TestCLIExpense(t *testing.T) {
+	store := &memStore{}
+	ctx := context.Background()
+
+	t.Run("expense add", func(t *testing.T) {
+		c, out := newTestCLI(store, "")
+		want := "ID  AMOUNT  TITLE       NOTE     TAGS\n" +
+			"1   75      Halo Kitty  buy tea  drinks, juices\n"
+
+		err := c.run(ctx, []string{"expense", "add", "-amount", "75", "-title", "Halo Kitty", "-note", "buy tea", "-tags", "drinks,juices"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, want, out.String())
+		}
+	})
+
+	t.Run("expense add returns validation error", func(t *testing.T) {
+		c, _ := newTestCLI(store, "")
+
+		err := c.run(ctx, []string{"expense", "add", "-title", "Tea"})
+
+		assert.ErrorIs(t, err, expense.ErrAmountInvalid)
+	})
+
+	t.Run("expense update changes only the given flags", func(t *testing.T) {
+		c, out := newTestCLI(store, "")
+		want := `[{"id":1,"amount":80,"title":"Halo Kitty","note":"buy tea","tags":["drinks","juices"]}]`
+
+		err := c.run(ctx, []string{"-o", "json", "expense", "update", "-amount", "80", "1"})
+
+		if assert.NoError(t, err) {
+			assert.JSONEq(t, want, out.String())
+		}
+	})
+
+	t.Run("expense get returns not found", func(t *testing.T) {
+		c, _ := newTestCLI(store, "")
+
+		err := c.run(ctx, []string{"expense", "get", "9"})
+
+		assert.ErrorIs(t, err, expense.ErrNotFound)
+	})
+
+	t.Run("expense list as csv", func(t *testing.T) {
+		c, out := newTestCLI(store, "")
+		want := "id,amount,currency,spent_on,title,note,tags\n1,80,,,Halo Kitty,buy tea,drinks;juices\n"
+
+		err := c.run(ctx, []string{"expense", "list", "-o", "csv"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, want, out.String())
+		}
+	})
+
+	t.Run("expense delete", func(t *testing.T) {
+		c, _ := newTestCLI(store, "")
+
+		err := c.run(ctx, []string{"expense", "delete", "1"})
+
+		if assert.NoError(t, err) {
+			assert.Empty(t, store.exps)
+		}
+	})
+
+	t.Run("returns unknown output format", func(t *testing.T) {
+		c, _ := newTestCLI(store, "")
+
+		err := c.run(ctx, []string{"-o", "xml", "expense", "add", "-amount", "1", "-title", "Tea"})
+
+		assert.ErrorIs(t, err, ErrUsage)
+		assert.Empty(t, store.exps)
+	})
+
+	t.Run("returns unknown command", func(t *testing.T) {
+		c, _ := newTestCLI(store, "")
+
+		err := c.run(ctx, []string{"expenses"})
+
+		assert.ErrorIs(t, err, ErrUsage)
+	})
+
+	t.Run("role grant requires a user and a role", func(t *testing.T) {
+		c, _ := newTestCLI(store, "")
+
+		err := c.run(ctx, []string{"role", "grant", "ana"})
+
+		assert.ErrorIs(t, err, ErrUsage)
+	})
+
+	t.Run("retention run requires a policy", func(t *testing.T) {
+		c, _ := newTestCLI(store, "")
+
+		err := c.run(ctx, []string{"retention", "run", "-dry-run"})
+
+		assert.ErrorIs(t, err, ErrUsage)
+	})
+}
+
+func TestCLIImportExportReport(t *testing.T) {
+	store := &memStore{}
+	ctx := context.Background()
+
+	t.Run("import csv from stdin", func(t *testing.T) {
+		in := "title,amount,tags,currency,spent_on\nTea,30,drinks,,\nRice,50,food;lunch,USD,2026-10-01\n"
+		c, _ := newTestCLI(store, in)
+
+		err := c.run(ctx, []string{"import", "-format", "csv", "-"})
+
+		if assert.NoError(t, err) {
+			assert.Len(t, store.exps, 2)
+			assert.Equal(t, []string{"food", "lunch"}, store.exps[1].Tags)
+			assert.Equal(t, "USD", store.exps[1].Currency)
+			assert.Equal(t, "2026-10-01", store.exps[1].SpentOn)
+		}
+	})
+
+	t.Run("import json file", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "expenses.json")
+		os.WriteFile(name, []byte(`[{"amount":20,"title":"Soup","tags":["food"]}]`), 0o644)
+		c, _ := newTestCLI(store, "")
+
+		err := c.run(ctx, []string{"import", name})
+
+		if assert.NoError(t, err) {
+			assert.Len(t, store.exps, 3)
+		}
+	})
+
+	t.Run("import rejects invalid expenses", func(t *testing.T) {
+		c, _ := newTestCLI(store, "title,amount\nTea,0\n")
+
+		err := c.run(ctx, []string{"import", "-format", "csv", "-"})
+
+		assert.ErrorIs(t, err, expense.ErrAmountInvalid)
+		assert.Len(t, store.exps, 3)
+	})
+
+	t.Run("export defaults to json", func(t *testing.T) {
+		c, out := newTestCLI(store, "")
+
+		err := c.run(ctx, []string{"export"})
+
+		if assert.NoError(t, err) {
+			assert.Contains(t, out.String(), `"title": "Soup"`)
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		c, out := newTestCLI(store, "")
+		want := "TAG     COUNT  TOTAL\n" +
+			"drinks  1      30\n" +
+			"food    2      70\n" +
+			"lunch   1      50\n"
+
+		err := c.run(ctx, []string{"report"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, want, out.String())
+		}
+	})
+}
+
+// txMemStore is a memStore with units of work that keep none of their
+// changes when they fail. It rejects expenses titled like an existing one
+// unless duplicates are allowed.
+type txMemStore struct {
+	*memStore
+	units int
+}
+
+func (m *txMemStore) Save(ctx context.Context, e *expense.Expense) (*expense.Expense, error) {
+	for _, x := range m.exps {
+		if x.Title == e.Title && !expense.DuplicatesAllowed(ctx) {
+			return nil, expense.ErrDuplicate
+		}
+	}
+	return m.memStore.Save(ctx, e)
+}
+
+func (m *txMemStore) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.units++
+	n := len(m.exps)
+	if err := fn(ctx); err != nil {
+		m.exps = m.exps[:n]
+		return err
+	}
+	return nil
+}
+
+func TestCLIImportDuplicates(t *testing.T) {
+	store := &txMemStore{memStore: &memStore{exps: []expense.Expense{{ID: 1, Amount: 30, Title: "Tea"}}, nextID: 1}}
+	ctx := context.Background()
+	in := "title,amount\nRice,50\nTea,30\n"
+	c, _ := newTestCLI(store.memStore, in)
+	c.open = func(globals) (expenseStore, func(), error) {
+		return store, func() {}, nil
+	}
+
+	t.Run("import saves none of the expenses when one is a duplicate", func(t *testing.T) {
+		err := c.run(ctx, []string{"import", "-format", "csv", "-"})
+
+		assert.ErrorIs(t, err, expense.ErrDuplicate)
+		assert.Len(t, store.exps, 1)
+		assert.Equal(t, 1, store.units)
+	})
+
+	t.Run("import -force saves duplicates", func(t *testing.T) {
+		c.stdin = strings.NewReader(in)
+
+		err := c.run(ctx, []string{"import", "-force", "-format", "csv", "-"})
+
+		if assert.NoError(t, err) {
+			assert.Len(t, store.exps, 3)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/currency"
+)
+
+type currencyHandler struct {
+	currencySvc *currency.Service
+}
+
+func NewCurrencyHandler(router *echo.Echo, svc *currency.Service) error {
+	if router == nil || svc == nil {
+		return errors.New("invalid argument")
+	}
+	h := currencyHandler{
+		currencySvc: svc,
+	}
+
+	router.GET("/exchange-rates", h.ListRates, Auth)
+	router.POST("/exchange-rates", h.ImportRates, Auth)
+	return nil
+}
+
+// ImportRates stores a JSON array of rates, or a CSV file with a
+// date,base,quote,rate header when the body is text/csv.
+func (h *currencyHandler) ImportRates(c echo.Context) error {
+	var rates []currency.Rate
+	req := c.Request()
+	if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), "text/csv") {
+		rs, err := currency.ReadCSV(req.Body)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"code":    http.StatusBadRequest,
+				"message": err.Error(),
+			})
+		}
+		rates = rs
+	} else if err := c.Bind(&rates); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid request body",
+		})
+	}
+	for i := range rates {
+		if err := rates[i].Validate(); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"code":    http.StatusBadRequest,
+				"message": err.Error(),
+			})
+		}
+	}
+
+	ctx := req.Context()
+	if err := h.currencySvc.Import(ctx, rates); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusCreated, echo.Map{
+		"imported": len(rates),
+	})
+}
+
+// ListRates returns the stored rates, newest first, optionally filtered by
+// the base and quote query parameters.
+func (h *currencyHandler) ListRates(c echo.Context) error {
+	base := strings.ToUpper(c.QueryParam("base"))
+	quote := strings.ToUpper(c.QueryParam("quote"))
+	if (base != "" && !currency.ValidCode(base)) || (quote != "" && !currency.ValidCode(quote)) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": currency.ErrCodeInvalid.Error(),
+		})
+	}
+
+	ctx := c.Request().Context()
+	rs, err := h.currencySvc.List(ctx, base, quote)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, rs)
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/currency"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerImportRates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := echo.New()
+	h := &currencyHandler{currency.NewService(db)}
+
+	t.Run("ImportRates() reads CSV", func(t *testing.T) {
+		body := "date,base,quote,rate\n2026-10-01,USD,THB,36.5\n"
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO exchange_rates").
+			WithArgs(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), "USD", "THB", 36.5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		req := httptest.NewRequest(http.MethodPost, "/exchange-rates", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, "text/csv")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"imported":1}`
+
+		err := h.ImportRates(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("ImportRates() returns invalid rate", func(t *testing.T) {
+		body := `[{"date":"2026-10-01T00:00:00Z","base":"USD","quote":"THB","rate":0}]`
+		req := httptest.NewRequest(http.MethodPost, "/exchange-rates", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":400,"message":"rate must be greater than zero"}`
+
+		err := h.ImportRates(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandlerListRates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := echo.New()
+	h := &currencyHandler{currency.NewService(db)}
+
+	t.Run("ListRates()", func(t *testing.T) {
+		mock.ExpectQuery("SELECT date, base, quote, rate FROM exchange_rates WHERE base = (.+) ORDER BY date DESC").
+			WithArgs("USD").
+			WillReturnRows(sqlmock.NewRows([]string{"date", "base", "quote", "rate"}).
+				AddRow(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), "USD", "THB", 36.5))
+
+		req := httptest.NewRequest(http.MethodGet, "/exchange-rates?base=usd", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `[{"date":"2026-10-01T00:00:00Z","base":"USD","quote":"THB","rate":36.5}]`
+
+		err := h.ListRates(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/phuangpheth/assessment/postgres"
+)
+
+// dbConfig returns the pool configured by DB_MAX_OPEN_CONNS,
+// DB_MAX_IDLE_CONNS, DB_CONN_MAX_LIFETIME, DB_CONN_MAX_IDLE_TIME and
+// DB_CONNECT_TIMEOUT.
+func dbConfig() (postgres.Config, error) {
+	var cfg postgres.Config
+	var err error
+	if cfg.MaxOpenConns, err = strconv.Atoi(getEnv("DB_MAX_OPEN_CONNS", "25")); err != nil {
+		return cfg, fmt.Errorf("DB_MAX_OPEN_CONNS: %w", err)
+	}
+	if cfg.MaxIdleConns, err = strconv.Atoi(getEnv("DB_MAX_IDLE_CONNS", "25")); err != nil {
+		return cfg, fmt.Errorf("DB_MAX_IDLE_CONNS: %w", err)
+	}
+	if cfg.ConnMaxLifetime, err = time.ParseDuration(getEnv("DB_CONN_MAX_LIFETIME", "30m")); err != nil {
+		return cfg, fmt.Errorf("DB_CONN_MAX_LIFETIME: %w", err)
+	}
+	if cfg.ConnMaxIdleTime, err = time.ParseDuration(getEnv("DB_CONN_MAX_IDLE_TIME", "5m")); err != nil {
+		return cfg, fmt.Errorf("DB_CONN_MAX_IDLE_TIME: %w", err)
+	}
+	if cfg.ConnectTimeout, err = time.ParseDuration(getEnv("DB_CONNECT_TIMEOUT", "60s")); err != nil {
+		return cfg, fmt.Errorf("DB_CONNECT_TIMEOUT: %w", err)
+	}
+	return cfg, nil
+}
+
+// readPolicy returns the policy of reads configured by DB_QUERY_TIMEOUT and
+// DB_READ_ATTEMPTS.
+func readPolicy() (postgres.ReadPolicy, error) {
+	var p postgres.ReadPolicy
+	var err error
+	if p.Timeout, err = time.ParseDuration(getEnv("DB_QUERY_TIMEOUT", "10s")); err != nil {
+		return p, fmt.Errorf("DB_QUERY_TIMEOUT: %w", err)
+	}
+	if p.Attempts, err = strconv.Atoi(getEnv("DB_READ_ATTEMPTS", "3")); err != nil {
+		return p, fmt.Errorf("DB_READ_ATTEMPTS: %w", err)
+	}
+	return p, nil
+}
+
+// openDB opens the database at url with the configured pool and waits until
+// it accepts connections.
+func openDB(ctx context.Context, url string) (*sql.DB, error) {
+	cfg, err := dbConfig()
+	if err != nil {
+		return nil, err
+	}
+	return postgres.Open(ctx, url, cfg)
+}
+
+// newReplica returns the read replica at url, or nil when url is empty.
+// Principals stick to the primary for DATABASE_READ_STICKY after a write.
+// Unlike the primary, the replica is not waited for: reads fall back to the
+// primary while it is unavailable.
+func newReplica(url string) (*postgres.Replica, error) {
+	if url == "" {
+		return nil, nil
+	}
+	sticky, err := time.ParseDuration(getEnv("DATABASE_READ_STICKY", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("DATABASE_READ_STICKY: %w", err)
+	}
+	cfg, err := dbConfig()
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Apply(db)
+	return postgres.NewReplica(db, sticky), nil
+}
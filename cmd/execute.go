@@ -5,16 +5,37 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/apikey"
+	"github.com/phuangpheth/assessment/attachment"
+	"github.com/phuangpheth/assessment/budget"
+	"github.com/phuangpheth/assessment/currency"
 	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/graph"
+	"github.com/phuangpheth/assessment/openapi"
+	"github.com/phuangpheth/assessment/privacy"
+	"github.com/phuangpheth/assessment/ratelimit"
+	"github.com/phuangpheth/assessment/recurring"
+	"github.com/phuangpheth/assessment/retention"
+	"github.com/phuangpheth/assessment/rule"
+	"github.com/phuangpheth/assessment/split"
+	"github.com/phuangpheth/assessment/statement"
+	"github.com/phuangpheth/assessment/stream"
+	"github.com/phuangpheth/assessment/token"
+	"github.com/phuangpheth/assessment/txn"
+	"github.com/phuangpheth/assessment/webhook"
 	"go.uber.org/zap"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 func getEnv(key, fallback string) string {
@@ -24,6 +45,106 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// splitEnv returns the comma-separated values of the environment variable key.
+func splitEnv(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// newExpenseService returns an expense service that converts amounts to
+// BASE_CURRENCY with the exchange rates stored in db, tags new expenses with
+// the rules stored in db and rejects likely duplicates unless
+// DUPLICATE_CHECK is false, and the rule service that tags them.
+func newExpenseService(db *sql.DB) (*expense.Service, *rule.Service, error) {
+	base := getEnv("BASE_CURRENCY", "THB")
+	if !currency.ValidCode(base) {
+		return nil, nil, currency.ErrCodeInvalid
+	}
+	reads, err := readPolicy()
+	if err != nil {
+		return nil, nil, err
+	}
+	svc := expense.NewService(db)
+	svc.SetConverter(base, currency.NewService(db))
+	svc.SetReadPolicy(reads)
+	dupCheck, err := duplicateCheck()
+	if err != nil {
+		return nil, nil, err
+	}
+	if dupCheck != nil {
+		svc.SetDuplicateCheck(*dupCheck)
+	}
+	ruleSvc := rule.NewService(db, svc)
+	svc.SetTagger(ruleSvc)
+	return svc, ruleSvc, nil
+}
+
+// duplicateCheck returns the check that rejects likely duplicates on save,
+// or nil when DUPLICATE_CHECK is false.
+func duplicateCheck() (*expense.DuplicateCheck, error) {
+	on, err := strconv.ParseBool(getEnv("DUPLICATE_CHECK", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("DUPLICATE_CHECK: %w", err)
+	}
+	if !on {
+		return nil, nil
+	}
+	c := expense.DefaultDuplicateCheck
+	if c.WindowDays, err = strconv.Atoi(getEnv("DUPLICATE_WINDOW_DAYS", strconv.Itoa(c.WindowDays))); err != nil {
+		return nil, fmt.Errorf("DUPLICATE_WINDOW_DAYS: %w", err)
+	}
+	if c.MinSimilarity, err = strconv.ParseFloat(getEnv("DUPLICATE_MIN_SIMILARITY", "0.75"), 64); err != nil {
+		return nil, fmt.Errorf("DUPLICATE_MIN_SIMILARITY: %w", err)
+	}
+	return &c, nil
+}
+
+// retentionPolicy returns the policy of RETENTION_ARCHIVE_AFTER_YEARS and
+// RETENTION_PURGE_AFTER_DAYS. Both are disabled by default.
+func retentionPolicy() (retention.Policy, error) {
+	var p retention.Policy
+	var err error
+	if p.ArchiveAfterYears, err = strconv.Atoi(getEnv("RETENTION_ARCHIVE_AFTER_YEARS", "0")); err != nil {
+		return p, fmt.Errorf("RETENTION_ARCHIVE_AFTER_YEARS: %w", err)
+	}
+	if p.PurgeAfterDays, err = strconv.Atoi(getEnv("RETENTION_PURGE_AFTER_DAYS", "0")); err != nil {
+		return p, fmt.Errorf("RETENTION_PURGE_AFTER_DAYS: %w", err)
+	}
+	return p, p.Validate()
+}
+
+// newSigner returns the signer of bearer tokens keyed by AUTH_SECRET, or nil
+// when it is empty, in which case only API keys authenticate.
+func newSigner() (*token.Signer, error) {
+	secret := os.Getenv("AUTH_SECRET")
+	if secret == "" {
+		return nil, nil
+	}
+	return token.NewSigner([]byte(secret))
+}
+
+func newBlobStore() (attachment.BlobStore, error) {
+	switch driver := getEnv("ATTACHMENT_STORE", "local"); driver {
+	case "local":
+		return attachment.NewLocalStore(getEnv("ATTACHMENT_DIR", "attachments"))
+	case "s3":
+		return attachment.NewS3Store(attachment.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		}, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown attachment store %q", driver)
+	}
+}
+
 func failOnError(err error, message string) {
 	if err != nil {
 		log.Printf("%s: %s", message, err)
@@ -31,7 +152,8 @@ func failOnError(err error, message string) {
 	}
 }
 
-func Execute() {
+// serve runs the HTTP and gRPC servers until the process is interrupted.
+func serve() {
 	ctx := context.Background()
 	zLog, err := zap.NewProduction()
 	failOnError(err, "failed to new zap.NewProduction")
@@ -39,35 +161,153 @@ func Execute() {
 
 	zap.ReplaceGlobals(zLog)
 
-	db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
+	db, err := openDB(ctx, os.Getenv("DATABASE_URL"))
 	failOnError(err, "failed to connect to database")
 	defer db.Close()
 
-	_, err = db.ExecContext(ctx, `
-	  CREATE TABLE IF NOT EXISTS expenses (
-	    id SERIAL PRIMARY KEY,
-	    title TEXT,
-	    amount FLOAT,
-	    note TEXT,
-	    tags TEXT[]
-	  );
-	`)
-	failOnError(err, "failed to create table expenses")
+	err = createSchema(ctx, db)
+	failOnError(err, "failed to create schema")
+
+	svc, ruleSvc, err := newExpenseService(db)
+	failOnError(err, "failed to configure expense service")
+	err = svc.SetSearchMode(getEnv("SEARCH_MODE", expense.SearchFullText))
+	failOnError(err, "failed to parse SEARCH_MODE")
+
+	isolation, err := txn.ParseIsolation(os.Getenv("TX_ISOLATION"))
+	failOnError(err, "failed to parse TX_ISOLATION")
+	txRetries, err := strconv.Atoi(getEnv("TX_MAX_RETRIES", "3"))
+	failOnError(err, "failed to parse TX_MAX_RETRIES")
+	svc.SetTxManager(txn.NewManager(db, txn.Options{Isolation: isolation, MaxRetries: txRetries}))
+
+	cacheSize, err := strconv.Atoi(getEnv("EXPENSE_CACHE_SIZE", "10000"))
+	failOnError(err, "failed to parse EXPENSE_CACHE_SIZE")
+	cache := expense.NewCache(cacheSize)
+	svc.SetCache(cache)
+
+	replica, err := newReplica(os.Getenv("DATABASE_READ_URL"))
+	failOnError(err, "failed to configure read replica")
+	if replica != nil {
+		defer replica.DB().Close()
+		svc.SetReplica(replica)
+	}
+
+	accessSvc := access.NewService(db)
+	setRoleStore(accessSvc)
+	setOperators(splitEnv("OPERATORS"))
+	apiKeySvc := apikey.NewService(db)
+	setKeyStore(apiKeySvc)
+	signer, err := newSigner()
+	failOnError(err, "failed to configure bearer tokens")
+	if signer != nil {
+		setTokenVerifier(signer)
+	}
+
+	lim, err := newLimiter(db)
+	failOnError(err, "failed to configure rate limits")
+	setLimiter(lim)
 
-	svc := expense.NewService(db)
 	e := echo.New()
 
-	err = NewHandler(e, svc)
-	failOnError(err, "failed to create handler")
+	budgetSvc := budget.NewService(db, budget.LogNotifier{})
+	svc.AddListener(budgetSvc)
+
+	store, err := newBlobStore()
+	failOnError(err, "failed to create blob store")
+
+	maxSize, err := strconv.ParseInt(getEnv("ATTACHMENT_MAX_SIZE", "0"), 10, 64)
+	failOnError(err, "failed to parse ATTACHMENT_MAX_SIZE")
+
+	attachmentSvc := attachment.NewService(db, store, attachment.Limits{
+		MaxSize:      maxSize,
+		ContentTypes: splitEnv("ATTACHMENT_CONTENT_TYPES"),
+	})
+
+	recurringSvc := recurring.NewService(db, svc)
+
+	maxComplexity, err := strconv.Atoi(getEnv("GRAPHQL_MAX_COMPLEXITY", "0"))
+	failOnError(err, "failed to parse GRAPHQL_MAX_COMPLEXITY")
+
+	schema, err := graph.NewSchema(svc, maxComplexity)
+	failOnError(err, "failed to create graphql schema")
+
+	webhookInterval, err := time.ParseDuration(getEnv("WEBHOOK_INTERVAL", "5s"))
+	failOnError(err, "failed to parse WEBHOOK_INTERVAL")
 
-	errChan := make(chan error, 1)
+	broker := stream.NewBroker(svc)
+	broker.AddListener(cache)
+	err = broker.Start(ctx)
+	failOnError(err, "failed to start expense stream")
+
+	validate, err := strconv.ParseBool(getEnv("OPENAPI_VALIDATE", "false"))
+	failOnError(err, "failed to parse OPENAPI_VALIDATE")
+	if validate {
+		doc, err := openapi.Load()
+		failOnError(err, "failed to load openapi document")
+		e.Use(ValidateRequest(doc))
+	}
+
+	retentionSvc := retention.NewService(db, svc, attachmentSvc)
+	err = registerRoutes(e, services{
+		expense:    svc,
+		attachment: attachmentSvc,
+		budget:     budgetSvc,
+		recurring:  recurringSvc,
+		webhook:    webhook.NewService(db),
+		currency:   currency.NewService(db),
+		split:      split.NewService(db, svc),
+		access:     accessSvc,
+		apiKey:     apiKeySvc,
+		schema:     schema,
+		broker:     broker,
+		cache:      cache,
+		statement:  statement.NewService(db, svc),
+		rule:       ruleSvc,
+		privacy:    privacy.NewService(db, svc, attachmentSvc, accessSvc, retentionSvc),
+	})
+	failOnError(err, "failed to register routes")
+
+	recurringInterval, err := time.ParseDuration(getEnv("RECURRING_INTERVAL", "1m"))
+	failOnError(err, "failed to parse RECURRING_INTERVAL")
+
+	catchUp, err := strconv.ParseBool(getEnv("RECURRING_CATCH_UP", "true"))
+	failOnError(err, "failed to parse RECURRING_CATCH_UP")
+
+	policy, err := retentionPolicy()
+	failOnError(err, "failed to configure retention policy")
+	retentionInterval, err := time.ParseDuration(getEnv("RETENTION_INTERVAL", "24h"))
+	failOnError(err, "failed to parse RETENTION_INTERVAL")
+
+	sweepInterval, err := time.ParseDuration(getEnv("RATE_LIMIT_SWEEP_INTERVAL", "1h"))
+	failOnError(err, "failed to parse RATE_LIMIT_SWEEP_INTERVAL")
+
+	grpcServer, err := NewGRPCServer(svc)
+	failOnError(err, "failed to create grpc server")
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", getEnv("GRPC_PORT", "3002")))
+	failOnError(err, "failed to listen for grpc")
+
+	errChan := make(chan error, 2)
 	go func() {
 		errChan <- e.Start(fmt.Sprintf(":%s", getEnv("PORT", "3001")))
 	}()
+	go func() {
+		errChan <- grpcServer.Serve(lis)
+	}()
 
 	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, os.Kill)
 	defer cancel()
 
+	go recurring.NewWorker(recurringSvc, recurringInterval, catchUp).Run(ctx)
+	go broker.Run(ctx, pq.NewListener(os.Getenv("DATABASE_URL"), time.Second, time.Minute, nil))
+	go webhook.NewDispatcher(db, svc, nil, webhook.Config{}).Run(ctx, webhookInterval)
+	if lim != nil {
+		go ratelimit.NewWorker(lim, sweepInterval).Run(ctx)
+	}
+	if policy.Enabled() {
+		retentionCtx := expense.WithActor(ctx, expense.Actor{ID: "retention"})
+		go retention.NewWorker(retentionSvc, policy, retentionInterval).Run(retentionCtx)
+	}
+
 	select {
 	case err := <-errChan:
 		if err != nil && err != http.ErrServerClosed {
@@ -80,6 +320,7 @@ func Execute() {
 		defer cancel()
 
 		zLog.Info("shutting down the server")
+		grpcServer.GracefulStop()
 		if err := e.Shutdown(ctx); err != nil {
 			zLog.Fatal("failed to shutdown the server", zap.Error(err))
 		}
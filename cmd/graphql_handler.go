@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/graph"
+)
+
+type graphqlHandler struct {
+	schema *graph.Schema
+}
+
+func NewGraphQLHandler(router *echo.Echo, schema *graph.Schema) error {
+	if router == nil || schema == nil {
+		return errors.New("invalid argument")
+	}
+	schema.SetAuthorizer(authorizeGraphQL)
+	h := graphqlHandler{
+		schema: schema,
+	}
+
+	router.POST("/graphql", h.Query, Auth)
+	return nil
+}
+
+func (h *graphqlHandler) Query(c echo.Context) error {
+	var req graph.Request
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid request body",
+		})
+	}
+
+	ctx := c.Request().Context()
+	return c.JSON(http.StatusOK, h.schema.Do(ctx, req))
+}
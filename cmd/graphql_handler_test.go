@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/graph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerGraphQLQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	schema, err := graph.NewSchema(expense.NewService(db), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := echo.New()
+	h := &graphqlHandler{schema}
+
+	t.Run("Query()", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(1, "").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}).
+				AddRow(1, 75, "Halo Kitty", "", pq.Array([]string{"drinks"}), "", nil, 0, nil, "draft", nil))
+
+		body := `{"query":"query ($id: ID!) { expense(id: $id) { title tags } }","variables":{"id":"1"}}`
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"data":{"expense":{"tags":["drinks"],"title":"Halo Kitty"}}}`
+
+		err := h.Query(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("Query() returns invalid request body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":1}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":400,"message":"invalid request body"}`
+
+		err := h.Query(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
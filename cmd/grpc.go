@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/phuangpheth/assessment/currency"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/expensepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type expenseServer struct {
+	expensepb.UnimplementedExpenseServiceServer
+	expenseSvc *expense.Service
+}
+
+// NewGRPCServer returns a gRPC server that serves the ExpenseService with the
+// same expense.Service and authentication as NewHandler.
+func NewGRPCServer(svc *expense.Service, opts ...grpc.ServerOption) (*grpc.Server, error) {
+	if svc == nil {
+		return nil, errors.New("invalid argument")
+	}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(unaryAuth),
+		grpc.ChainStreamInterceptor(streamAuth),
+	)
+	srv := grpc.NewServer(opts...)
+	expensepb.RegisterExpenseServiceServer(srv, &expenseServer{expenseSvc: svc})
+	return srv, nil
+}
+
+// authenticateMetadata applies authenticate to the "authorization",
+// "x-tenant-id" and "x-user-id" metadata of an incoming call, authorizes
+// method against policy and rate limits the call.
+func authenticateMetadata(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	ctx, err := authenticate(ctx, first("authorization"), first(strings.ToLower(HeaderTenantID)), first(strings.ToLower(HeaderUserID)))
+	if errors.Is(err, ErrInvalidTokenAuth) {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	if err := authorize(ctx, method); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err := rateLimitCall(ctx, method); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+func unaryAuth(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := authenticateMetadata(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func streamAuth(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := authenticateMetadata(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authServerStream{ServerStream: ss, ctx: ctx})
+}
+
+// grpcError maps errors of the expense package to gRPC status errors.
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, expense.ErrNotFound):
+		return status.Error(codes.NotFound, expense.ErrNotFound.Error())
+	case errors.Is(err, expense.ErrAmountInvalid), errors.Is(err, expense.ErrTitleEmpty),
+		errors.Is(err, expense.ErrCurrencyInvalid), errors.Is(err, expense.ErrSpentOnInvalid),
+		errors.Is(err, expense.ErrSplitPayerEmpty), errors.Is(err, expense.ErrSplitParticipantsEmpty),
+		errors.Is(err, expense.ErrSplitParticipantInvalid), errors.Is(err, expense.ErrSplitMethodInvalid),
+		errors.Is(err, expense.ErrSplitSharesInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, expense.ErrDuplicate):
+		return status.Error(codes.AlreadyExists, expense.ErrDuplicate.Error())
+	case errors.Is(err, expense.ErrNotEditable):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, currency.ErrRateNotFound):
+		return status.Error(codes.FailedPrecondition, currency.ErrRateNotFound.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Internal, "internal server error")
+}
+
+func toProto(e *expense.Expense) *expensepb.Expense {
+	return &expensepb.Expense{
+		Id:         e.ID,
+		Amount:     e.Amount,
+		Title:      e.Title,
+		Note:       e.Note,
+		Tags:       e.Tags,
+		Currency:   e.Currency,
+		SpentOn:    e.SpentOn,
+		BaseAmount: e.BaseAmount,
+		Split:      splitToProto(e.Split),
+		Status:     string(e.Status),
+	}
+}
+
+func splitToProto(s *expense.Split) *expensepb.Split {
+	if s == nil {
+		return nil
+	}
+	ps := make([]*expensepb.Participant, 0, len(s.Participants))
+	for _, p := range s.Participants {
+		ps = append(ps, &expensepb.Participant{Name: p.Name, Percent: p.Percent, Amount: p.Amount})
+	}
+	return &expensepb.Split{PaidBy: s.PaidBy, Method: string(s.Method), Participants: ps}
+}
+
+func splitFromProto(s *expensepb.Split) *expense.Split {
+	if s == nil {
+		return nil
+	}
+	ps := make([]expense.Participant, 0, len(s.GetParticipants()))
+	for _, p := range s.GetParticipants() {
+		ps = append(ps, expense.Participant{Name: p.GetName(), Percent: p.GetPercent(), Amount: p.GetAmount()})
+	}
+	return &expense.Split{PaidBy: s.GetPaidBy(), Method: expense.SplitMethod(s.GetMethod()), Participants: ps}
+}
+
+func fromProto(e *expensepb.Expense) *expense.Expense {
+	if e == nil {
+		return &expense.Expense{}
+	}
+	return &expense.Expense{
+		ID:       e.GetId(),
+		Amount:   e.GetAmount(),
+		Title:    e.GetTitle(),
+		Note:     e.GetNote(),
+		Tags:     e.GetTags(),
+		Currency: e.GetCurrency(),
+		SpentOn:  e.GetSpentOn(),
+		Split:    splitFromProto(e.GetSplit()),
+	}
+}
+
+func (s *expenseServer) CreateExpense(ctx context.Context, req *expensepb.CreateExpenseRequest) (*expensepb.Expense, error) {
+	exp := fromProto(req.GetExpense())
+	if err := exp.Validate(); err != nil {
+		return nil, grpcError(err)
+	}
+	if req.GetForce() {
+		ctx = expense.AllowDuplicates(ctx)
+	}
+	e, err := s.expenseSvc.Save(ctx, exp)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toProto(e), nil
+}
+
+func (s *expenseServer) GetExpense(ctx context.Context, req *expensepb.GetExpenseRequest) (*expensepb.Expense, error) {
+	e, err := s.expenseSvc.GetByID(expense.AllowStale(ctx), req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toProto(e), nil
+}
+
+func (s *expenseServer) UpdateExpense(ctx context.Context, req *expensepb.UpdateExpenseRequest) (*expensepb.Expense, error) {
+	exp := fromProto(req.GetExpense())
+	if err := exp.Validate(); err != nil {
+		return nil, grpcError(err)
+	}
+	e, err := s.expenseSvc.Update(ctx, exp)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toProto(e), nil
+}
+
+func (s *expenseServer) ListExpenses(ctx context.Context, req *expensepb.ListExpensesRequest) (*expensepb.ListExpensesResponse, error) {
+	exps, err := s.expenseSvc.List(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	res := &expensepb.ListExpensesResponse{
+		Expenses: make([]*expensepb.Expense, 0, len(exps)),
+	}
+	for i := range exps {
+		res.Expenses = append(res.Expenses, toProto(&exps[i]))
+	}
+	return res, nil
+}
+
+func (s *expenseServer) DeleteExpense(ctx context.Context, req *expensepb.DeleteExpenseRequest) (*expensepb.DeleteExpenseResponse, error) {
+	if err := s.expenseSvc.Delete(ctx, req.GetId()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &expensepb.DeleteExpenseResponse{}, nil
+}
+
+func (s *expenseServer) StreamExpenses(req *expensepb.ListExpensesRequest, stream expensepb.ExpenseService_StreamExpensesServer) error {
+	exps, err := s.expenseSvc.List(stream.Context())
+	if err != nil {
+		return grpcError(err)
+	}
+	for i := range exps {
+		if err := stream.Send(toProto(&exps[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/expensepb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newGRPCClient(t *testing.T, svc *expense.Service) expensepb.ExpenseServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	srv, err := NewGRPCServer(svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return expensepb.NewExpenseServiceClient(conn)
+}
+
+// authContext returns a context whose calls authenticate as user within
+// tenant.
+func authContext(t *testing.T, tenant, user string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", bearer(t, tenant, user))
+}
+
+func TestNewGRPCServer(t *testing.T) {
+	t.Run("NewGRPCServer() returns invalid argument", func(t *testing.T) {
+		_, err := NewGRPCServer(nil)
+		assert.EqualError(t, err, "invalid argument")
+	})
+}
+
+func TestGRPCExpenseServer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	columns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
+	client := newGRPCClient(t, expense.NewService(db))
+	useRoles(t, staticRoles{"ana": {access.RoleEditor}, "vic": {access.RoleViewer}})
+	useTokens(t)
+
+	t.Run("GetExpense() returns unauthenticated", func(t *testing.T) {
+		_, err := client.GetExpense(context.Background(), &expensepb.GetExpenseRequest{Id: 1})
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("DeleteExpense() returns permission denied", func(t *testing.T) {
+		ctx := authContext(t, "", "vic")
+		_, err := client.DeleteExpense(ctx, &expensepb.DeleteExpenseRequest{Id: 1})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("CreateExpense()", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(1, 75, "Halo Kitty", "buy tea", pq.Array([]string{"drinks"}), "", nil, 0, nil, "draft", nil)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).WillReturnRows(rows)
+		mock.ExpectExec(`INSERT INTO expense_events`).
+			WithArgs(expense.EventCreated, 1, "acme", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ctx := metadata.AppendToOutgoingContext(authContext(t, "acme", "ana"), "x-tenant-id", "acme")
+		got, err := client.CreateExpense(ctx, &expensepb.CreateExpenseRequest{
+			Expense: &expensepb.Expense{Amount: 75, Title: "Halo Kitty", Note: "buy tea", Tags: []string{"drinks"}},
+		})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), got.GetId())
+			assert.Equal(t, "Halo Kitty", got.GetTitle())
+			assert.Equal(t, []string{"drinks"}, got.GetTags())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("CreateExpense() saves likely duplicates with force", func(t *testing.T) {
+		svc := expense.NewService(db)
+		svc.SetDuplicateCheck(expense.DefaultDuplicateCheck)
+		client := newGRPCClient(t, svc)
+		rows := sqlmock.NewRows(columns).AddRow(2, 75, "Halo Kitty", "", pq.Array([]string{}), "", nil, 0, nil, "draft", nil)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).WillReturnRows(rows)
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		got, err := client.CreateExpense(authContext(t, "", "ana"), &expensepb.CreateExpenseRequest{
+			Expense: &expensepb.Expense{Amount: 75, Title: "Halo Kitty"},
+			Force:   true,
+		})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(2), got.GetId())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("CreateExpense() returns invalid argument", func(t *testing.T) {
+		_, err := client.CreateExpense(authContext(t, "", "ana"), &expensepb.CreateExpenseRequest{
+			Expense: &expensepb.Expense{Amount: 0, Title: "Halo Kitty"},
+		})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("GetExpense()", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(1, 75, "Halo Kitty", "buy tea", pq.Array([]string{"drinks"}), "", nil, 0, nil, "draft", nil)
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(1, "").WillReturnRows(rows)
+
+		got, err := client.GetExpense(authContext(t, "", "ana"), &expensepb.GetExpenseRequest{Id: 1})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, float64(75), got.GetAmount())
+			assert.Equal(t, "buy tea", got.GetNote())
+		}
+	})
+
+	t.Run("GetExpense() returns not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(2, "").WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := client.GetExpense(authContext(t, "", "ana"), &expensepb.GetExpenseRequest{Id: 2})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("DeleteExpense() returns not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(2, "").WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectRollback()
+
+		_, err := client.DeleteExpense(authContext(t, "", "ana"), &expensepb.DeleteExpenseRequest{Id: 2})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("StreamExpenses()", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(2, 30, "Milk", "", pq.Array([]string{}), "", nil, 0, nil, "draft", nil).
+			AddRow(1, 75, "Halo Kitty", "buy tea", pq.Array([]string{"drinks"}), "", nil, 0, nil, "draft", nil)
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WillReturnRows(rows)
+
+		stream, err := client.StreamExpenses(authContext(t, "", "ana"), &expensepb.ListExpensesRequest{})
+		if !assert.NoError(t, err) {
+			return
+		}
+		var titles []string
+		for {
+			e, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			titles = append(titles, e.GetTitle())
+		}
+		assert.Equal(t, []string{"Milk", "Halo Kitty"}, titles)
+	})
+
+	t.Run("StreamExpenses() returns unauthenticated", func(t *testing.T) {
+		stream, err := client.StreamExpenses(context.Background(), &expensepb.ListExpensesRequest{})
+		if assert.NoError(t, err) {
+			_, err = stream.Recv()
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		}
+	})
+}
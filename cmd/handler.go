@@ -4,8 +4,10 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/currency"
 	"github.com/phuangpheth/assessment/expense"
 )
 
@@ -22,9 +24,18 @@ func NewHandler(router *echo.Echo, svc *expense.Service) error {
 	}
 
 	router.GET("/expenses", h.ListExpenses, Auth)
+	router.GET("/expenses/search", h.SearchExpenses, Auth)
+	router.GET("/expenses/summary", h.SummarizeExpenses, Auth)
+	router.GET("/expenses/duplicates", h.ListDuplicates, Auth)
 	router.GET("/expenses/:id", h.GetExpenseByID, Auth)
 	router.POST("/expenses", h.SaveExpense, Auth)
 	router.PUT("/expenses/:id", h.UpdateExpense, Auth)
+	router.DELETE("/expenses/:id", h.DeleteExpense, Auth)
+	router.GET("/expenses/:id/transitions", h.ListTransitions, Auth)
+	router.POST("/expenses/:id/submit", h.transition(expense.ActionSubmit), Auth)
+	router.POST("/expenses/:id/approve", h.transition(expense.ActionApprove), Auth)
+	router.POST("/expenses/:id/reject", h.transition(expense.ActionReject), Auth)
+	router.POST("/expenses/:id/reimburse", h.transition(expense.ActionReimburse), Auth)
 	return nil
 }
 
@@ -44,14 +55,40 @@ func (h *handler) SaveExpense(c echo.Context) error {
 	}
 
 	ctx := c.Request().Context()
-	expense, err := h.expenseSvc.Save(ctx, &exp)
+	if v := c.QueryParam("force"); v != "" {
+		force, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"code":    http.StatusBadRequest,
+				"message": "invalid params",
+			})
+		}
+		if force {
+			ctx = expense.AllowDuplicates(ctx)
+		}
+	}
+	saved, err := h.expenseSvc.Save(ctx, &exp)
+	if errors.Is(err, currency.ErrRateNotFound) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": currency.ErrRateNotFound.Error(),
+		})
+	}
+	var dup *expense.DuplicateError
+	if errors.As(err, &dup) {
+		return c.JSON(http.StatusConflict, echo.Map{
+			"code":       http.StatusConflict,
+			"message":    dup.Error(),
+			"duplicates": dup.Matches,
+		})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{
 			"code":    http.StatusInternalServerError,
 			"message": "Internal Server Error: ",
 		})
 	}
-	return c.JSON(http.StatusCreated, expense)
+	return c.JSON(http.StatusCreated, saved)
 }
 
 func (h *handler) UpdateExpense(c echo.Context) error {
@@ -86,6 +123,18 @@ func (h *handler) UpdateExpense(c echo.Context) error {
 			"message": errors.Unwrap(err).Error(),
 		})
 	}
+	if errors.Is(err, expense.ErrNotEditable) {
+		return c.JSON(http.StatusConflict, echo.Map{
+			"code":    http.StatusConflict,
+			"message": err.Error(),
+		})
+	}
+	if errors.Is(err, currency.ErrRateNotFound) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": currency.ErrRateNotFound.Error(),
+		})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{
 			"code":    http.StatusInternalServerError,
@@ -95,6 +144,83 @@ func (h *handler) UpdateExpense(c echo.Context) error {
 	return c.JSON(http.StatusOK, ex)
 }
 
+// transition returns a handler that applies action to the expense. A
+// rejection takes its reason from the request body.
+func (h *handler) transition(action expense.Action) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"code":    http.StatusBadRequest,
+				"message": "invalid params",
+			})
+		}
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		if err := c.Bind(&body); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"code":    http.StatusBadRequest,
+				"message": "invalid request body",
+			})
+		}
+
+		ctx := c.Request().Context()
+		exp, err := h.expenseSvc.Transition(ctx, id, action, body.Reason)
+		switch {
+		case errors.Is(err, expense.ErrNotFound):
+			return c.JSON(http.StatusNotFound, echo.Map{
+				"code":    http.StatusNotFound,
+				"message": expense.ErrNotFound.Error(),
+			})
+		case errors.Is(err, expense.ErrReasonEmpty):
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"code":    http.StatusBadRequest,
+				"message": err.Error(),
+			})
+		case errors.Is(err, expense.ErrForbidden):
+			return c.JSON(http.StatusForbidden, echo.Map{
+				"code":    http.StatusForbidden,
+				"message": err.Error(),
+			})
+		case errors.Is(err, expense.ErrTransitionInvalid):
+			return c.JSON(http.StatusConflict, echo.Map{
+				"code":    http.StatusConflict,
+				"message": err.Error(),
+			})
+		case err != nil:
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"code":    http.StatusInternalServerError,
+				"message": "Internal Server Error",
+			})
+		}
+		return c.JSON(http.StatusOK, exp)
+	}
+}
+
+// ListTransitions returns the approval audit trail of the expense.
+func (h *handler) ListTransitions(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	ts, err := h.expenseSvc.Transitions(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, ts)
+}
+
+// ListExpenses responds with an ETag of the list. It has no Last-Modified,
+// as deleting an expense does not make the latest change of the rest newer.
 func (h *handler) ListExpenses(c echo.Context) error {
 	ctx := c.Request().Context()
 	exps, err := h.expenseSvc.List(ctx)
@@ -104,7 +230,115 @@ func (h *handler) ListExpenses(c echo.Context) error {
 			"message": "Internal Server Error",
 		})
 	}
-	return c.JSON(http.StatusOK, exps)
+	return conditionalJSON(c, exps, time.Time{})
+}
+
+// SearchExpenses returns the expenses matching the web-search query q, best
+// matches first.
+func (h *handler) SearchExpenses(c echo.Context) error {
+	limit := uint64(20)
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || n < 1 || n > 100 {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"code":    http.StatusBadRequest,
+				"message": "invalid params",
+			})
+		}
+		limit = n
+	}
+
+	ctx := c.Request().Context()
+	rs, err := h.expenseSvc.Search(ctx, c.QueryParam("q"), limit)
+	if errors.Is(err, expense.ErrQueryEmpty) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, rs)
+}
+
+// SummarizeExpenses returns the expense totals grouped by tag or month,
+// converted to the currency query parameter when it is given.
+func (h *handler) SummarizeExpenses(c echo.Context) error {
+	groupBy := c.QueryParam("group_by")
+	if groupBy == "" {
+		groupBy = expense.GroupByTag
+	}
+
+	ctx := c.Request().Context()
+	gs, err := h.expenseSvc.Summary(ctx, groupBy, c.QueryParam("currency"))
+	switch {
+	case errors.Is(err, expense.ErrGroupByInvalid),
+		errors.Is(err, expense.ErrCurrencyInvalid),
+		errors.Is(err, expense.ErrConverterMissing):
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	case errors.Is(err, currency.ErrRateNotFound):
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": currency.ErrRateNotFound.Error(),
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, gs)
+}
+
+// duplicatesDays is how many days back the duplicates report looks when
+// from is not given.
+const duplicatesDays = 90
+
+// ListDuplicates returns the pairs of expenses that are likely duplicates of
+// each other, whose older expense was spent from the from date to the to
+// date included. to defaults to today and from to duplicatesDays before to.
+func (h *handler) ListDuplicates(c echo.Context) error {
+	to, err := queryDate(c, "to", time.Now().UTC().Truncate(24*time.Hour))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+	from, err := queryDate(c, "from", to.AddDate(0, 0, -duplicatesDays))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	pairs, err := h.expenseSvc.Duplicates(ctx, from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, pairs)
+}
+
+// queryDate parses the query parameter name as expense.DateLayout, or
+// returns def when it is missing.
+func queryDate(c echo.Context, name string, def time.Time) (time.Time, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return def, nil
+	}
+	return time.Parse(expense.DateLayout, v)
 }
 
 func (h *handler) GetExpenseByID(c echo.Context) error {
@@ -116,7 +350,7 @@ func (h *handler) GetExpenseByID(c echo.Context) error {
 			"message": "invalid params",
 		})
 	}
-	exp, err := h.expenseSvc.GetByID(ctx, id)
+	exp, err := h.expenseSvc.GetByID(expense.AllowStale(ctx), id)
 	if errors.Is(err, expense.ErrNotFound) {
 		return c.JSON(http.StatusNotFound, echo.Map{
 			"code":    http.StatusNotFound,
@@ -129,5 +363,30 @@ func (h *handler) GetExpenseByID(c echo.Context) error {
 			"message": "Internal Server Error : ",
 		})
 	}
-	return c.JSON(http.StatusOK, exp)
+	return conditionalJSON(c, exp, exp.UpdatedAt)
+}
+
+func (h *handler) DeleteExpense(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+	err = h.expenseSvc.Delete(ctx, id)
+	if errors.Is(err, expense.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": errors.Unwrap(err).Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
 }
@@ -61,7 +61,9 @@ func TestGetExpenseByID(t *testing.T) {
 	assert.NoError(t, err)
 	resp.Body.Close()
 
-	want := `{"id":10,"amount":15,"title":"test-title","note":"test-note","tags":["test-tags"]}`
+	// The seeded expense was spent on the day the database was created.
+	today := time.Now().Format(expense.DateLayout)
+	want := fmt.Sprintf(`{"id":10,"amount":15,"title":"test-title","note":"test-note","tags":["test-tags"],"spent_on":%q,"base_amount":15,"status":"draft"}`, today)
 
 	if assert.NoError(t, err) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
@@ -112,7 +114,8 @@ func TestListExpenses(t *testing.T) {
 	assert.NoError(t, err)
 	resp.Body.Close()
 
-	want := `[{"id":10,"amount":15,"title":"test-title","note":"test-note","tags":["test-tags"]}]`
+	today := time.Now().Format(expense.DateLayout)
+	want := fmt.Sprintf(`[{"id":10,"amount":15,"title":"test-title","note":"test-note","tags":["test-tags"],"spent_on":%q,"base_amount":15,"status":"draft"}]`, today)
 
 	if assert.NoError(t, err) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
@@ -170,7 +173,8 @@ func TestSaveExpense(t *testing.T) {
 	assert.NoError(t, err)
 	resp.Body.Close()
 
-	want := `{"id":1,"amount":30,"title":"add-title","note":"add-note","tags":["add-tags"]}`
+	today := time.Now().Format(expense.DateLayout)
+	want := fmt.Sprintf(`{"id":1,"amount":30,"title":"add-title","note":"add-note","tags":["add-tags"],"spent_on":%q,"base_amount":30,"status":"draft"}`, today)
 
 	if assert.NoError(t, err) {
 		assert.Equal(t, http.StatusCreated, resp.StatusCode)
@@ -229,7 +233,8 @@ func TestUpdateExpense(t *testing.T) {
 	assert.NoError(t, err)
 	resp.Body.Close()
 
-	want := `{"id":1,"amount":30,"title":"update-title","note":"update-note","tags":["update-tags"]}`
+	today := time.Now().Format(expense.DateLayout)
+	want := fmt.Sprintf(`{"id":1,"amount":30,"title":"update-title","note":"update-note","tags":["update-tags"],"spent_on":%q,"base_amount":30,"status":"draft"}`, today)
 
 	if assert.NoError(t, err) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
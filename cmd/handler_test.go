@@ -7,10 +7,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/labstack/echo/v4"
 	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/currency"
 	"github.com/phuangpheth/assessment/expense"
 	"github.com/stretchr/testify/assert"
 )
@@ -57,7 +59,7 @@ func TestHandlerSaveExpense(t *testing.T) {
 	}
 	defer db.Close()
 
-	columns := []string{"id", "amount", "title", "note", "tags"}
+	columns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
 	e := echo.New()
 	svc := expense.NewService(db)
 	h := &handler{svc}
@@ -70,15 +72,20 @@ func TestHandlerSaveExpense(t *testing.T) {
 			Tags:   []string{"drinks", "juices"},
 		}
 
-		rows := sqlmock.NewRows(columns).AddRow(exp.ID, exp.Amount, exp.Title, exp.Note, pq.Array(exp.Tags))
+		rows := sqlmock.NewRows(columns).AddRow(exp.ID, exp.Amount, exp.Title, exp.Note, pq.Array(exp.Tags), "", nil, 0, nil, "draft", nil)
+		mock.ExpectBegin()
 		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).WillReturnRows(rows)
+		mock.ExpectExec(`INSERT INTO expense_events`).
+			WithArgs(expense.EventCreated, exp.ID, "", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		byt, _ := json.Marshal(exp)
 		req := httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader(string(byt)))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
-		want := `{"id":1,"amount":75,"title":"Halo Kitty","note":"buy tea and coffee","tags":["drinks","juices"]}`
+		want := `{"id":1,"amount":75,"title":"Halo Kitty","note":"buy tea and coffee","tags":["drinks","juices"],"status":"draft"}`
 
 		err = h.SaveExpense(c)
 
@@ -88,6 +95,53 @@ func TestHandlerSaveExpense(t *testing.T) {
 		}
 	})
 
+	t.Run("SaveExpense() returns conflict for a likely duplicate", func(t *testing.T) {
+		svc := expense.NewService(db)
+		svc.SetDuplicateCheck(expense.DefaultDuplicateCheck)
+		h := &handler{svc}
+		mock.ExpectQuery(`SELECT (.+) FROM expenses WHERE`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 75, "Halo Kitty", "", pq.Array([]string{}), "THB", nil, 75, nil, "draft", nil))
+
+		body := `{"amount":75,"title":"halo kitty","currency":"THB","spent_on":"2026-03-10"}`
+		req := httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":409,"duplicates":[{"expense":{"id":1,"amount":75,"title":"Halo Kitty","note":"","tags":[],"currency":"THB","base_amount":75,"status":"draft"},"similarity":1}],"message":"likely duplicate of an existing expense"}`
+
+		err = h.SaveExpense(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusConflict, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SaveExpense() with force saves a likely duplicate", func(t *testing.T) {
+		svc := expense.NewService(db)
+		svc.SetDuplicateCheck(expense.DefaultDuplicateCheck)
+		h := &handler{svc}
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(2, 75, "halo kitty", "", pq.Array([]string{}), "THB", nil, 75, nil, "draft", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		body := `{"amount":75,"title":"halo kitty","currency":"THB","spent_on":"2026-03-10"}`
+		req := httptest.NewRequest(http.MethodPost, "/expenses?force=true", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err = h.SaveExpense(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("SaveExpense() returns invalid request body", func(t *testing.T) {
 		body := `
 			{
@@ -119,7 +173,7 @@ func TestHandlerUpdateExpense(t *testing.T) {
 	}
 	defer db.Close()
 
-	columns := []string{"id", "amount", "title", "note", "tags"}
+	columns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
 	e := echo.New()
 	svc := expense.NewService(db)
 	h := &handler{svc}
@@ -133,12 +187,18 @@ func TestHandlerUpdateExpense(t *testing.T) {
 			Tags:   []string{"drinks", "juices"},
 		}
 
-		rows := sqlmock.NewRows(columns).AddRow(exp.ID, exp.Amount, exp.Title, exp.Note, pq.Array(exp.Tags))
-		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(exp.ID).WillReturnRows(rows)
+		spentOn := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+		rows := sqlmock.NewRows(columns).AddRow(exp.ID, 60, exp.Title, exp.Note, pq.Array(exp.Tags), "THB", spentOn, 60, nil, "draft", nil)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(exp.ID, "").WillReturnRows(rows)
 
 		mock.ExpectExec(`UPDATE expenses`).
-			WithArgs(exp.Amount, exp.Title, exp.Note, pq.Array(exp.Tags), exp.ID).
+			WithArgs(exp.Amount, exp.Title, exp.Note, pq.Array(exp.Tags), "THB", "2026-10-18", exp.Amount, nil, exp.ID, "").
 			WillReturnResult(sqlmock.NewResult(exp.ID, 1))
+		mock.ExpectExec(`INSERT INTO expense_events`).
+			WithArgs(expense.EventUpdated, exp.ID, "", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		byt, _ := json.Marshal(exp)
 		req := httptest.NewRequest(http.MethodPost, "/expenses/:id", strings.NewReader(string(byt)))
@@ -147,7 +207,7 @@ func TestHandlerUpdateExpense(t *testing.T) {
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
 		c.SetParamValues("1")
-		want := `{"id":1,"amount":75,"title":"Halo Kitty","note":"buy tea","tags":["drinks","juices"]}`
+		want := `{"id":1,"amount":75,"title":"Halo Kitty","note":"buy tea","tags":["drinks","juices"],"currency":"THB","spent_on":"2026-10-18","base_amount":75,"status":"draft"}`
 
 		err = h.UpdateExpense(c)
 
@@ -216,7 +276,9 @@ func TestHandlerUpdateExpense(t *testing.T) {
 			Tags:   []string{"drinks", "juices"},
 		}
 
-		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(exp.ID).WillReturnError(expense.ErrNotFound)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(exp.ID, "").WillReturnError(expense.ErrNotFound)
+		mock.ExpectRollback()
 
 		byt, _ := json.Marshal(exp)
 		req := httptest.NewRequest(http.MethodPost, "/expenses/:id", strings.NewReader(string(byt)))
@@ -243,7 +305,7 @@ func TestHandlerGetExpenseByID(t *testing.T) {
 	}
 	defer db.Close()
 
-	columns := []string{"id", "amount", "title", "note", "tags"}
+	columns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
 	e := echo.New()
 	svc := expense.NewService(db)
 	h := &handler{svc}
@@ -257,8 +319,8 @@ func TestHandlerGetExpenseByID(t *testing.T) {
 			Tags:   []string{"food", "beverage"},
 		}
 
-		rows := sqlmock.NewRows(columns).AddRow(exp.ID, exp.Amount, exp.Title, exp.Note, pq.Array(exp.Tags))
-		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(exp.ID).WillReturnRows(rows)
+		rows := sqlmock.NewRows(columns).AddRow(exp.ID, exp.Amount, exp.Title, exp.Note, pq.Array(exp.Tags), "", nil, 0, nil, "draft", nil)
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(exp.ID, "").WillReturnRows(rows)
 
 		req := httptest.NewRequest(http.MethodGet, "/expenses/:id", nil)
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -266,7 +328,7 @@ func TestHandlerGetExpenseByID(t *testing.T) {
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
 		c.SetParamValues(fmt.Sprintf("%d", exp.ID))
-		want := `{"id":2,"amount":105,"title":"strawberry","note":"night","tags":["food","beverage"]}`
+		want := `{"id":2,"amount":105,"title":"strawberry","note":"night","tags":["food","beverage"],"status":"draft"}`
 
 		err = h.GetExpenseByID(c)
 
@@ -276,6 +338,44 @@ func TestHandlerGetExpenseByID(t *testing.T) {
 		}
 	})
 
+	t.Run("GetExpenseByID() returns not modified", func(t *testing.T) {
+		updatedAt := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+		get := func(header, value string) *httptest.ResponseRecorder {
+			rows := sqlmock.NewRows(columns).AddRow(3, 20, "tea", "", pq.Array([]string{}), "", nil, 0, nil, "draft", updatedAt)
+			mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(3, "").WillReturnRows(rows)
+
+			req := httptest.NewRequest(http.MethodGet, "/expenses/3", nil)
+			if header != "" {
+				req.Header.Set(header, value)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("3")
+			assert.NoError(t, h.GetExpenseByID(c))
+			return rec
+		}
+
+		rec := get("", "")
+		etag := rec.Header().Get("ETag")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEmpty(t, etag)
+		assert.Equal(t, "Sun, 18 Oct 2026 09:30:00 GMT", rec.Header().Get(echo.HeaderLastModified))
+
+		rec = get("If-None-Match", `"other", `+etag)
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.String())
+
+		rec = get("If-None-Match", `"other"`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = get(echo.HeaderIfModifiedSince, "Sun, 18 Oct 2026 09:30:00 GMT")
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+
+		rec = get(echo.HeaderIfModifiedSince, "Sun, 18 Oct 2026 09:29:59 GMT")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
 	t.Run("GetExpenseByID() returns invalid params", func(t *testing.T) {
 		mock.ExpectQuery("SELECT (.+) FROM expenses").WillReturnError(expense.ErrNotFound)
 
@@ -320,7 +420,7 @@ func TestHandlerListExpenses(t *testing.T) {
 	}
 	defer db.Close()
 
-	columns := []string{"id", "amount", "title", "note", "tags"}
+	columns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
 	e := echo.New()
 	svc := expense.NewService(db)
 	h := &handler{svc}
@@ -345,7 +445,7 @@ func TestHandlerListExpenses(t *testing.T) {
 
 		rows := sqlmock.NewRows(columns)
 		for _, v := range exps {
-			rows = rows.AddRow(v.ID, v.Amount, v.Title, v.Note, pq.Array(v.Tags))
+			rows = rows.AddRow(v.ID, v.Amount, v.Title, v.Note, pq.Array(v.Tags), "", nil, 0, nil, "draft", nil)
 		}
 		mock.ExpectQuery("SELECT (.+) FROM expenses").WillReturnRows(rows)
 
@@ -353,7 +453,7 @@ func TestHandlerListExpenses(t *testing.T) {
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
-		want := `[{"id":2,"amount":65,"title":"Ice Milk","note":"","tags":["drinks","juices"]},{"id":3,"amount":100,"title":"Ice Chocolate","note":"","tags":["drinks","juices"]}]`
+		want := `[{"id":2,"amount":65,"title":"Ice Milk","note":"","tags":["drinks","juices"],"status":"draft"},{"id":3,"amount":100,"title":"Ice Chocolate","note":"","tags":["drinks","juices"],"status":"draft"}]`
 
 		err = h.ListExpenses(c)
 
@@ -363,3 +463,320 @@ func TestHandlerListExpenses(t *testing.T) {
 		}
 	})
 }
+
+func TestHandlerDeleteExpense(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	columns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
+	e := echo.New()
+	svc := expense.NewService(db)
+	h := &handler{svc}
+
+	t.Run("DeleteExpense()", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(1, 75, "Halo Kitty", "", pq.Array([]string{}), "", nil, 0, nil, "draft", nil)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(1, "").WillReturnRows(rows)
+		mock.ExpectExec("UPDATE expenses SET deleted_at").WithArgs(1, "").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO expense_events`).
+			WithArgs(expense.EventDeleted, 1, "", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		req := httptest.NewRequest(http.MethodDelete, "/expenses/:id", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		err = h.DeleteExpense(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("DeleteExpense() returns not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(2, "").WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectRollback()
+
+		req := httptest.NewRequest(http.MethodDelete, "/expenses/:id", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("2")
+		want := `{"code":404,"message":"not found"}`
+
+		err = h.DeleteExpense(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
+
+func TestHandlerSearchExpenses(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	columns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at", "rank", "snippet"}
+	e := echo.New()
+	svc := expense.NewService(db)
+	h := &handler{svc}
+
+	t.Run("SearchExpenses()", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(3, 250, "Taxi", "to Bangkok", pq.Array([]string{"travel"}), "", nil, 0, nil, "draft", nil, 0.5, "<b>Taxi</b> to <b>Bangkok</b>")
+		mock.ExpectQuery("SELECT (.+) FROM expenses CROSS JOIN websearch_to_tsquery").
+			WithArgs("taxi bangkok", "").
+			WillReturnRows(rows)
+
+		req := httptest.NewRequest(http.MethodGet, "/expenses/search?q=taxi+bangkok&limit=5", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `[{"id":3,"amount":250,"title":"Taxi","note":"to Bangkok","tags":["travel"],"status":"draft","rank":0.5,"snippet":"\u003cb\u003eTaxi\u003c/b\u003e to \u003cb\u003eBangkok\u003c/b\u003e"}]`
+
+		err := h.SearchExpenses(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("SearchExpenses() returns empty search query", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/expenses/search?q=", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":400,"message":"empty search query"}`
+
+		err := h.SearchExpenses(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("SearchExpenses() returns invalid params", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/expenses/search?q=taxi&limit=1000", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":400,"message":"invalid params"}`
+
+		err := h.SearchExpenses(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
+
+func TestHandlerListDuplicates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := echo.New()
+	h := &handler{expense.NewService(db)}
+
+	t.Run("ListDuplicates() looks back from to", func(t *testing.T) {
+		mock.ExpectQuery("SELECT a.id, b.id, a.title, b.title FROM expenses a").
+			WithArgs(sqlmock.AnyArg(), 3, "", "2026-07-02", "2026-09-30", 1000).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "id", "title", "title"}))
+
+		req := httptest.NewRequest(http.MethodGet, "/expenses/duplicates?to=2026-09-30", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := h.ListDuplicates(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "[]", strings.TrimSpace(rec.Body.String()))
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListDuplicates() returns invalid params", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/expenses/duplicates?from=last+week", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := h.ListDuplicates(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestHandlerSummarizeExpenses(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := echo.New()
+	svc := expense.NewService(db)
+	svc.SetConverter("THB", currency.NewService(db))
+	h := &handler{svc}
+
+	t.Run("SummarizeExpenses() converts totals to currency", func(t *testing.T) {
+		mock.ExpectQuery("SELECT to_char\\(spent_on, 'YYYY-MM'\\) AS key").
+			WillReturnRows(sqlmock.NewRows([]string{"key", "count", "sum"}).AddRow("2026-10", 3, 730))
+		mock.ExpectQuery("SELECT rate FROM exchange_rates").
+			WithArgs("THB", "USD", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"rate"}))
+		mock.ExpectQuery("SELECT rate FROM exchange_rates").
+			WithArgs("USD", "THB", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"rate"}).AddRow(36.5))
+
+		req := httptest.NewRequest(http.MethodGet, "/expenses/summary?group_by=month&currency=USD", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `[{"key":"2026-10","count":3,"total":20,"currency":"USD"}]`
+
+		err := h.SummarizeExpenses(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("SummarizeExpenses() returns unknown exchange rate", func(t *testing.T) {
+		mock.ExpectQuery("SELECT unnest\\(tags\\) AS key").
+			WillReturnRows(sqlmock.NewRows([]string{"key", "count", "sum"}).AddRow("food", 1, 100))
+		mock.ExpectQuery("SELECT rate FROM exchange_rates").WillReturnRows(sqlmock.NewRows([]string{"rate"}))
+		mock.ExpectQuery("SELECT rate FROM exchange_rates").WillReturnRows(sqlmock.NewRows([]string{"rate"}))
+
+		req := httptest.NewRequest(http.MethodGet, "/expenses/summary?currency=EUR", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":400,"message":"exchange rate not found"}`
+
+		err := h.SummarizeExpenses(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandlerTransition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	columns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
+	e := echo.New()
+	svc := expense.NewService(db)
+	h := &handler{svc}
+
+	t.Run("transition() submits the expense", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(1, "").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 75, "Hotel", "", pq.Array([]string{}), "", nil, 0, nil, "draft", nil))
+		mock.ExpectQuery("INSERT INTO expense_transitions").
+			WithArgs(1, expense.ActionSubmit, expense.StatusDraft, expense.StatusSubmitted, "ana", "").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+		mock.ExpectExec("UPDATE expenses SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO expense_events").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		req := httptest.NewRequest(http.MethodPost, "/expenses/1/submit", nil)
+		req = req.WithContext(expense.WithActor(req.Context(), expense.Actor{ID: "ana"}))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		want := `{"id":1,"amount":75,"title":"Hotel","note":"","tags":[],"status":"submitted"}`
+
+		err := h.transition(expense.ActionSubmit)(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("transition() returns forbidden", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(1, "").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 75, "Hotel", "", pq.Array([]string{}), "", nil, 0, nil, "submitted", nil))
+		mock.ExpectRollback()
+
+		req := httptest.NewRequest(http.MethodPost, "/expenses/1/approve", nil)
+		req = req.WithContext(expense.WithActor(req.Context(), expense.Actor{ID: "ana"}))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		want := `{"code":403,"message":"not allowed to perform this action"}`
+
+		err := h.transition(expense.ActionApprove)(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("transition() returns conflict", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM expenses").WithArgs(1, "").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 75, "Hotel", "", pq.Array([]string{}), "", nil, 0, nil, "submitted", nil))
+		mock.ExpectRollback()
+
+		req := httptest.NewRequest(http.MethodPost, "/expenses/1/submit", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		want := `{"code":409,"message":"action is not allowed in the current status"}`
+
+		err := h.transition(expense.ActionSubmit)(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusConflict, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("transition() returns empty rejection reason", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/expenses/1/reject", strings.NewReader(`{}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		want := `{"code":400,"message":"rejection reason must not be empty"}`
+
+		err := h.transition(expense.ActionReject)(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
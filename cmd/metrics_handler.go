@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/expense"
+)
+
+type metricsHandler struct {
+	cache *expense.Cache
+}
+
+func NewMetricsHandler(router *echo.Echo, cache *expense.Cache) error {
+	if router == nil || cache == nil {
+		return errors.New("invalid argument")
+	}
+	h := metricsHandler{
+		cache: cache,
+	}
+
+	router.GET("/metrics", h.Metrics, Auth)
+	return nil
+}
+
+// Metrics serves the expense cache statistics in the Prometheus text format.
+func (h *metricsHandler) Metrics(c echo.Context) error {
+	s := h.cache.Stats()
+	var b strings.Builder
+	metric := func(name, typ, help string, v any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, typ, name, v)
+	}
+	metric("expense_cache_hits_total", "counter", "Expense lookups served from the cache.", s.Hits)
+	metric("expense_cache_misses_total", "counter", "Expense lookups that read the database.", s.Misses)
+	metric("expense_cache_entries", "gauge", "Expenses held in the cache.", s.Entries)
+	metric("expense_cache_hit_ratio", "gauge", "Share of expense lookups served from the cache.", s.HitRatio())
+	return c.Blob(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerMetrics(t *testing.T) {
+	e := echo.New()
+	cache := expense.NewCache(10)
+	cache.ExpenseEvent(expense.Event{ExpenseID: 1})
+	h := &metricsHandler{cache}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := h.Metrics(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "# TYPE expense_cache_hits_total counter\nexpense_cache_hits_total 0\n")
+		assert.Contains(t, rec.Body.String(), "expense_cache_hit_ratio 0\n")
+	}
+}
@@ -1,25 +1,220 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/apikey"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/openapi"
+	"github.com/phuangpheth/assessment/token"
 )
 
+// HeaderTenantID selects the tenant a request acts on behalf of. It must
+// match the tenant of the credential, or be empty.
+const HeaderTenantID = "X-Tenant-ID"
+
+// HeaderUserID names the user a request acts on behalf of. It must match the
+// user of the credential, or be empty.
+const HeaderUserID = "X-User-ID"
+
+// SchemeAPIKey prefixes API keys in the Authorization header.
+const SchemeAPIKey = "ApiKey "
+
+// SchemeBearer prefixes signed tokens in the Authorization header.
+const SchemeBearer = "Bearer "
+
 // ErrInvalidTokenAuth is returned when token authentication was invalid.
 var ErrInvalidTokenAuth = errors.New("missing or invalid token authentication")
 
+// KeyStore authenticates API keys.
+type KeyStore interface {
+	Authenticate(ctx context.Context, key string) (*apikey.Key, error)
+}
+
+// keys authenticates API keys. Without a store API keys are rejected.
+var keys KeyStore
+
+// setKeyStore replaces the store that authenticates API keys.
+func setKeyStore(s KeyStore) {
+	keys = s
+}
+
+// TokenVerifier verifies signed bearer tokens.
+type TokenVerifier interface {
+	Verify(token string) (*token.Claims, error)
+}
+
+// tokens verifies bearer tokens. Without a verifier bearer tokens are
+// rejected.
+var tokens TokenVerifier
+
+// setTokenVerifier replaces the verifier of bearer tokens.
+func setTokenVerifier(v TokenVerifier) {
+	tokens = v
+}
+
+type signInKey struct{}
+
+// withSignIn returns a copy of ctx that carries when its user signed in.
+func withSignIn(ctx context.Context, at time.Time) context.Context {
+	return context.WithValue(ctx, signInKey{}, at)
+}
+
+// signIn returns when the user of ctx signed in, and false unless ctx was
+// authenticated with the signed token of a user rather than an API key.
+func signIn(ctx context.Context) (time.Time, bool) {
+	at, ok := ctx.Value(signInKey{}).(time.Time)
+	return at, ok
+}
+
+type credentialKey struct{}
+
+// withCredential returns a copy of ctx that carries who its verified
+// credential was issued to: the subject within the tenant.
+func withCredential(ctx context.Context, tenant, subject string) context.Context {
+	return context.WithValue(ctx, credentialKey{}, tenant+"/"+subject)
+}
+
+// credential returns who the credential of ctx was issued to, and false
+// unless ctx was authenticated.
+func credential(ctx context.Context) (string, bool) {
+	c, ok := ctx.Value(credentialKey{}).(string)
+	return c, ok
+}
+
+// authenticate checks the Authorization credential and returns a copy of ctx
+// that carries the tenant and the actor of the credential, and the roles of
+// the actor within the tenant. The tenant and user given with the request
+// only select what the credential already names; any other value is
+// rejected. It is shared by the REST and gRPC APIs.
+func authenticate(ctx context.Context, authorization, tenant, user string) (context.Context, error) {
+	switch {
+	case strings.HasPrefix(authorization, SchemeAPIKey):
+		return authenticateKey(ctx, strings.TrimPrefix(authorization, SchemeAPIKey), tenant, user)
+	case strings.HasPrefix(authorization, SchemeBearer):
+		return authenticateToken(ctx, strings.TrimPrefix(authorization, SchemeBearer), tenant, user)
+	}
+	return nil, ErrInvalidTokenAuth
+}
+
+// authenticateToken authenticates a signed token, which acts on behalf of
+// the user and tenant it was issued to with the roles of the user, and
+// access.RoleOperator for operators.
+func authenticateToken(ctx context.Context, tk, tenant, user string) (context.Context, error) {
+	if tokens == nil {
+		return nil, ErrInvalidTokenAuth
+	}
+	c, err := tokens.Verify(tk)
+	if err != nil {
+		return nil, ErrInvalidTokenAuth
+	}
+	if (tenant != "" && tenant != c.Tenant) || (user != "" && user != c.Subject) {
+		return nil, ErrInvalidTokenAuth
+	}
+	var rs []access.Role
+	if roles != nil {
+		if rs, err = roles.Roles(ctx, c.Tenant, c.Subject); err != nil {
+			return nil, err
+		}
+	}
+	rs = withOperator(rs, c.Tenant, c.Subject)
+	ctx = expense.WithTenant(ctx, c.Tenant)
+	ctx = access.WithRoles(ctx, rs)
+	ctx = withSignIn(ctx, c.Issued())
+	ctx = withCredential(ctx, c.Tenant, c.Subject)
+	return expense.WithActor(ctx, expense.Actor{
+		ID:       c.Subject,
+		Approver: access.Allowed(rs, access.PermApprove),
+	}), nil
+}
+
+// authenticateKey authenticates an API key, which acts on behalf of its own
+// tenant with the roles of its scopes. A tenant or user other than those of
+// the key is rejected.
+func authenticateKey(ctx context.Context, key, tenant, user string) (context.Context, error) {
+	if keys == nil {
+		return nil, ErrInvalidTokenAuth
+	}
+	k, err := keys.Authenticate(ctx, key)
+	if errors.Is(err, apikey.ErrKeyInvalid) {
+		return nil, ErrInvalidTokenAuth
+	}
+	if err != nil {
+		return nil, err
+	}
+	if (tenant != "" && tenant != k.Tenant) || (user != "" && user != k.Actor()) {
+		return nil, ErrInvalidTokenAuth
+	}
+	rs := k.Roles()
+	ctx = expense.WithTenant(ctx, k.Tenant)
+	ctx = access.WithRoles(ctx, rs)
+	ctx = withCredential(ctx, k.Tenant, k.Actor())
+	return expense.WithActor(ctx, expense.Actor{
+		ID:       k.Actor(),
+		Approver: access.Allowed(rs, access.PermApprove),
+	}), nil
+}
+
+// Auth authenticates the request, checks that the roles of the user grant
+// the permission that policy requires for the matched route and counts the
+// request against the rate limit of the user.
 func Auth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		tk := c.Request().Header.Get("Authorization")
-		if _, err := time.Parse("January 02, 2006", tk); err != nil {
+		req := c.Request()
+		ctx, err := authenticate(req.Context(), req.Header.Get("Authorization"), req.Header.Get(HeaderTenantID), req.Header.Get(HeaderUserID))
+		if errors.Is(err, ErrInvalidTokenAuth) {
 			return c.JSON(http.StatusUnauthorized, echo.Map{
 				"code":    http.StatusUnauthorized,
-				"message": ErrInvalidTokenAuth.Error(),
+				"message": err.Error(),
+			})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"code":    http.StatusInternalServerError,
+				"message": "Internal Server Error",
+			})
+		}
+		route := req.Method + " " + c.Path()
+		if err := authorize(ctx, route); err != nil {
+			return c.JSON(http.StatusForbidden, echo.Map{
+				"code":    http.StatusForbidden,
+				"message": err.Error(),
 			})
 		}
-		return next(c)
+		c.SetRequest(req.WithContext(ctx))
+		return rateLimit(c, route, next)
+	}
+}
+
+// ValidateRequest rejects requests whose parameters or JSON body do not
+// match the operation of the matched route in doc. Routes without an
+// operation are passed through.
+func ValidateRequest(doc *openapi.Document) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			op := doc.Operation(req.Method, openapi.PathTemplate(c.Path()))
+			if op == nil {
+				return next(c)
+			}
+
+			params := make(map[string]string, len(c.ParamNames()))
+			for i, name := range c.ParamNames() {
+				params[name] = c.ParamValues()[i]
+			}
+			if err := doc.ValidateRequest(op, req, params); err != nil {
+				return c.JSON(http.StatusBadRequest, echo.Map{
+					"code":    http.StatusBadRequest,
+					"message": err.Error(),
+				})
+			}
+			return next(c)
+		}
 	}
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -8,45 +9,247 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/apikey"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/openapi"
+	"github.com/phuangpheth/assessment/token"
 	"github.com/stretchr/testify/assert"
 )
 
+// useTokens verifies bearer tokens with a test signer until the end of the
+// test.
+func useTokens(t *testing.T) {
+	t.Helper()
+	s, err := token.NewSigner([]byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := tokens
+	setTokenVerifier(s)
+	t.Cleanup(func() { setTokenVerifier(prev) })
+}
+
+// bearer returns the Authorization of a token of user within tenant, signed
+// by the signer of useTokens.
+func bearer(t *testing.T, tenant, user string) string {
+	t.Helper()
+	s, ok := tokens.(*token.Signer)
+	if !ok {
+		t.Fatal("bearer() requires useTokens()")
+	}
+	tk, err := s.Issue(tenant, user, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return SchemeBearer + tk
+}
+
 func TestMiddleware(t *testing.T) {
 	e := echo.New()
-	req := httptest.NewRequest(echo.GET, "/expenses", nil)
-
-	t.Run("Auth()", func(t *testing.T) {
-		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-		req.Header.Set(echo.HeaderAuthorization, time.Now().Format("January 02, 2006"))
+	useRoles(t, staticRoles{"ana": {access.RoleViewer}})
+	useTokens(t)
 
+	serve := func(method string, header http.Header) (*httptest.ResponseRecorder, context.Context) {
+		req := httptest.NewRequest(method, "/expenses", nil)
+		for k, v := range header {
+			req.Header.Set(k, v[0])
+		}
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
+		c.SetPath("/expenses")
+		var ctx context.Context
 		h := Auth(func(c echo.Context) error {
+			ctx = c.Request().Context()
 			return c.String(http.StatusOK, "test")
 		})
-
-		err := h(c)
-		if assert.NoError(t, err) {
-			assert.Equal(t, http.StatusOK, rec.Code)
+		if err := h(c); err != nil {
+			t.Fatal(err)
 		}
+		return rec, ctx
+	}
+
+	t.Run("Auth()", func(t *testing.T) {
+		rec, ctx := serve(http.MethodGet, http.Header{
+			echo.HeaderAuthorization: {bearer(t, "acme", "ana")},
+		})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "acme", expense.TenantFromContext(ctx))
+		assert.Equal(t, "ana", expense.ActorFromContext(ctx).ID)
+	})
+
+	t.Run("Auth() accepts the tenant and user of the token", func(t *testing.T) {
+		rec, _ := serve(http.MethodGet, http.Header{
+			echo.HeaderAuthorization: {bearer(t, "acme", "ana")},
+			HeaderTenantID:           {"acme"},
+			HeaderUserID:             {"ana"},
+		})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
 	})
 
 	t.Run("Auth() returns unauthorized", func(t *testing.T) {
-		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-		req.Header.Set(echo.HeaderAuthorization, "January 02, 2006 55555")
+		want := `{"code":401,"message":"missing or invalid token authentication"}`
+		tests := []struct {
+			name   string
+			header http.Header
+		}{
+			{"without token", http.Header{}},
+			{"for a date", http.Header{echo.HeaderAuthorization: {time.Now().Format("January 02, 2006")}}},
+			{"for a forged token", http.Header{echo.HeaderAuthorization: {SchemeBearer + "e30.e30.e30"}}},
+			{"for another user", http.Header{echo.HeaderAuthorization: {bearer(t, "acme", "bob")}, HeaderUserID: {"ana"}}},
+			{"for another tenant", http.Header{echo.HeaderAuthorization: {bearer(t, "acme", "ana")}, HeaderTenantID: {"globex"}}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec, _ := serve(http.MethodGet, tt.header)
+
+				assert.Equal(t, http.StatusUnauthorized, rec.Code)
+				assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+			})
+		}
+	})
+
+	t.Run("Auth() rejects tokens without a verifier", func(t *testing.T) {
+		header := http.Header{echo.HeaderAuthorization: {bearer(t, "acme", "ana")}}
+		prev := tokens
+		setTokenVerifier(nil)
+		defer setTokenVerifier(prev)
+
+		rec, _ := serve(http.MethodGet, header)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Auth() returns forbidden", func(t *testing.T) {
+		rec, _ := serve(http.MethodPost, http.Header{
+			echo.HeaderAuthorization: {bearer(t, "acme", "ana")},
+		})
+
+		want := `{"code":403,"message":"missing permission for this action"}`
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+	})
+}
+
+// staticKeys authenticates the keys it holds.
+type staticKeys map[string]*apikey.Key
 
+func (s staticKeys) Authenticate(_ context.Context, key string) (*apikey.Key, error) {
+	if k, ok := s[key]; ok {
+		return k, nil
+	}
+	return nil, apikey.ErrKeyInvalid
+}
+
+func TestAuthAPIKey(t *testing.T) {
+	e := echo.New()
+	prev := keys
+	setKeyStore(staticKeys{
+		"exp_writer": {Tenant: "acme", Prefix: "writer", Scopes: []apikey.Scope{apikey.ScopeWrite}},
+		"exp_reader": {Tenant: "acme", Prefix: "reader", Scopes: []apikey.Scope{apikey.ScopeRead}},
+	})
+	t.Cleanup(func() { setKeyStore(prev) })
+
+	serve := func(method, key, tenant string) (*httptest.ResponseRecorder, context.Context) {
+		req := httptest.NewRequest(method, "/expenses", nil)
+		req.Header.Set(echo.HeaderAuthorization, "ApiKey "+key)
+		req.Header.Set(HeaderTenantID, tenant)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
+		c.SetPath("/expenses")
+		var ctx context.Context
 		h := Auth(func(c echo.Context) error {
-			return c.String(http.StatusOK, "test")
+			ctx = c.Request().Context()
+			return c.NoContent(http.StatusOK)
 		})
+		if err := h(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec, ctx
+	}
 
-		want := `{"code":401,"message":"missing or invalid token authentication"}`
+	t.Run("Auth() accepts an API key", func(t *testing.T) {
+		rec, ctx := serve(http.MethodPost, "exp_writer", "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "acme", expense.TenantFromContext(ctx))
+		assert.Equal(t, "apikey:writer", expense.ActorFromContext(ctx).ID)
+	})
+
+	t.Run("Auth() limits an API key to its scopes", func(t *testing.T) {
+		rec, _ := serve(http.MethodPost, "exp_reader", "")
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Auth() rejects an API key of another tenant", func(t *testing.T) {
+		rec, _ := serve(http.MethodGet, "exp_reader", "globex")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Auth() rejects a user with an API key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/expenses", nil)
+		req.Header.Set(echo.HeaderAuthorization, "ApiKey exp_reader")
+		req.Header.Set(HeaderUserID, "ana")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/expenses")
+
+		err := Auth(func(c echo.Context) error { return c.NoContent(http.StatusOK) })(c)
 
-		err := h(c)
 		if assert.NoError(t, err) {
 			assert.Equal(t, http.StatusUnauthorized, rec.Code)
-			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
 		}
 	})
+
+	t.Run("Auth() rejects an unknown API key", func(t *testing.T) {
+		rec, _ := serve(http.MethodGet, "exp_unknown", "")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestValidateRequest(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := echo.New()
+	e.Use(ValidateRequest(doc))
+	e.PUT("/expenses/:id", func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	})
+	e.GET("/unknown", func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		want   string
+	}{
+		{"ValidateRequest()", http.MethodPut, "/expenses/1", `{"amount":79,"title":"Tea","tags":["drinks"]}`, http.StatusOK, "test"},
+		{"ValidateRequest() returns invalid path param", http.MethodPut, "/expenses/A", `{"amount":79,"title":"Tea"}`, http.StatusBadRequest, `{"code":400,"message":"id: must be an integer"}`},
+		{"ValidateRequest() returns invalid body", http.MethodPut, "/expenses/1", `{"amount":"79","title":"Tea"}`, http.StatusBadRequest, `{"code":400,"message":"body.amount: must be of type number"}`},
+		{"ValidateRequest() returns missing field", http.MethodPut, "/expenses/1", `{"amount":79}`, http.StatusBadRequest, `{"code":400,"message":"body.title: is required"}`},
+		{"ValidateRequest() skips routes without operation", http.MethodGet, "/unknown", "", http.StatusOK, "test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.code, rec.Code)
+			assert.Equal(t, tt.want, strings.TrimSpace(rec.Body.String()))
+		})
+	}
 }
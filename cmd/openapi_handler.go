@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/openapi"
+)
+
+// NewOpenAPIHandler serves the OpenAPI document at /openapi.json and a page
+// that renders it at /docs. Both are public.
+func NewOpenAPIHandler(router *echo.Echo) error {
+	if router == nil {
+		return errors.New("invalid argument")
+	}
+	router.GET("/openapi.json", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, openapi.JSON())
+	})
+	router.GET("/docs", func(c echo.Context) error {
+		return c.HTMLBlob(http.StatusOK, openapi.DocsHTML())
+	})
+	return nil
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/retention"
+)
+
+// writeExpenses writes exps as a table, JSON or CSV. CSV tags are joined
+// with semicolons.
+func writeExpenses(w io.Writer, format string, exps []expense.Expense) error {
+	switch format {
+	case "json":
+		return writeJSON(w, exps)
+	case "csv":
+		rows := make([][]string, 0, len(exps))
+		for _, e := range exps {
+			rows = append(rows, []string{
+				strconv.FormatInt(e.ID, 10),
+				formatAmount(e.Amount),
+				e.Currency,
+				e.SpentOn,
+				e.Title,
+				e.Note,
+				strings.Join(e.Tags, ";"),
+			})
+		}
+		return writeCSV(w, []string{"id", "amount", "currency", "spent_on", "title", "note", "tags"}, rows)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tAMOUNT\tTITLE\tNOTE\tTAGS")
+		for _, e := range exps {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", e.ID, formatAmount(e.Amount), e.Title, e.Note, strings.Join(e.Tags, ", "))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("%w: unknown output format %q", ErrUsage, format)
+	}
+}
+
+func writeGroups(w io.Writer, format string, gs []expense.Group) error {
+	switch format {
+	case "json":
+		return writeJSON(w, gs)
+	case "csv":
+		rows := make([][]string, 0, len(gs))
+		for _, g := range gs {
+			rows = append(rows, []string{g.Key, strconv.FormatInt(g.Count, 10), formatAmount(g.Total)})
+		}
+		return writeCSV(w, []string{"tag", "count", "total"}, rows)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TAG\tCOUNT\tTOTAL")
+		for _, g := range gs {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", g.Key, g.Count, formatAmount(g.Total))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("%w: unknown output format %q", ErrUsage, format)
+	}
+}
+
+func writeAssignments(w io.Writer, format string, as []access.Assignment) error {
+	switch format {
+	case "json":
+		return writeJSON(w, as)
+	case "csv":
+		rows := make([][]string, 0, len(as))
+		for _, a := range as {
+			rows = append(rows, []string{a.User, string(a.Role)})
+		}
+		return writeCSV(w, []string{"user", "role"}, rows)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "USER\tROLE")
+		for _, a := range as {
+			fmt.Fprintf(tw, "%s\t%s\n", a.User, a.Role)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("%w: unknown output format %q", ErrUsage, format)
+	}
+}
+
+func writeRetentionReport(w io.Writer, format string, r *retention.Report) error {
+	purgeBefore := ""
+	if r.PurgeBefore != nil {
+		purgeBefore = r.PurgeBefore.Format(time.RFC3339)
+	}
+	switch format {
+	case "json":
+		return writeJSON(w, r)
+	case "csv":
+		return writeCSV(w, []string{"dry_run", "archive_before", "archived", "purge_before", "purged"}, [][]string{{
+			strconv.FormatBool(r.DryRun),
+			r.ArchiveBefore,
+			strconv.FormatInt(r.Archived, 10),
+			purgeBefore,
+			strconv.FormatInt(r.Purged, 10),
+		}})
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "DRY RUN\tARCHIVE BEFORE\tARCHIVED\tPURGE BEFORE\tPURGED")
+		fmt.Fprintf(tw, "%t\t%s\t%d\t%s\t%d\n", r.DryRun, r.ArchiveBefore, r.Archived, purgeBefore, r.Purged)
+		return tw.Flush()
+	default:
+		return fmt.Errorf("%w: unknown output format %q", ErrUsage, format)
+	}
+}
+
+func writeRetentionLog(w io.Writer, format string, es []retention.Entry) error {
+	switch format {
+	case "json":
+		return writeJSON(w, es)
+	case "csv":
+		rows := make([][]string, 0, len(es))
+		for _, e := range es {
+			rows = append(rows, []string{
+				strconv.FormatInt(e.ID, 10),
+				e.CreatedAt.Format(time.RFC3339),
+				string(e.Action),
+				e.Cutoff.Format(time.RFC3339),
+				e.Actor,
+				joinIDs(e.ExpenseIDs, ";"),
+			})
+		}
+		return writeCSV(w, []string{"id", "created_at", "action", "cutoff", "actor", "expense_ids"}, rows)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tCREATED AT\tACTION\tCUTOFF\tACTOR\tEXPENSES")
+		for _, e := range es {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%d\n", e.ID, e.CreatedAt.Format(time.RFC3339), e.Action, e.Cutoff.Format(time.RFC3339), e.Actor, len(e.ExpenseIDs))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("%w: unknown output format %q", ErrUsage, format)
+	}
+}
+
+func joinIDs(ids []int64, sep string) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(s, sep)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func formatAmount(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
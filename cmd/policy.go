@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/expensepb"
+)
+
+// policy maps each authenticated REST route, as "METHOD path", and each gRPC
+// method to the permission it requires. Routes and methods missing from the
+// policy are forbidden.
+var policy = map[string]access.Permission{
+	"GET /expenses":                 access.PermRead,
+	"GET /expenses/search":          access.PermRead,
+	"GET /expenses/summary":         access.PermRead,
+	"GET /expenses/duplicates":      access.PermRead,
+	"GET /expenses/stream":          access.PermRead,
+	"GET /expenses/:id":             access.PermRead,
+	"POST /expenses":                access.PermWrite,
+	"PUT /expenses/:id":             access.PermWrite,
+	"DELETE /expenses/:id":          access.PermWrite,
+	"GET /expenses/:id/transitions": access.PermRead,
+	"POST /expenses/:id/submit":     access.PermWrite,
+	"POST /expenses/:id/approve":    access.PermApprove,
+	"POST /expenses/:id/reject":     access.PermApprove,
+	"POST /expenses/:id/reimburse":  access.PermApprove,
+
+	"GET /expenses/:id/attachments":                  access.PermRead,
+	"POST /expenses/:id/attachments":                 access.PermWrite,
+	"GET /expenses/:id/attachments/:attachmentID":    access.PermRead,
+	"DELETE /expenses/:id/attachments/:attachmentID": access.PermWrite,
+
+	"GET /budgets":            access.PermRead,
+	"GET /budgets/:id":        access.PermRead,
+	"GET /budgets/:id/status": access.PermRead,
+	"POST /budgets":           access.PermWrite,
+	"DELETE /budgets/:id":     access.PermWrite,
+
+	"GET /recurring-expenses":        access.PermRead,
+	"GET /recurring-expenses/:id":    access.PermRead,
+	"POST /recurring-expenses":       access.PermWrite,
+	"DELETE /recurring-expenses/:id": access.PermWrite,
+
+	"GET /balances":     access.PermRead,
+	"GET /settlements":  access.PermRead,
+	"POST /settlements": access.PermWrite,
+
+	// Mutations are checked against access.PermWrite by the schema.
+	"POST /graphql": access.PermRead,
+
+	// Exchange rates are shared by every tenant, so only operators change
+	// them.
+	"GET /exchange-rates":  access.PermRead,
+	"POST /exchange-rates": access.PermOperate,
+
+	"GET /webhooks":                        access.PermAdmin,
+	"POST /webhooks":                       access.PermAdmin,
+	"DELETE /webhooks/:id":                 access.PermAdmin,
+	"GET /webhooks/:id/deliveries":         access.PermAdmin,
+	"POST /webhooks/deliveries/:id/replay": access.PermAdmin,
+
+	"GET /roles":                      access.PermAdmin,
+	"PUT /users/:user/roles/:role":    access.PermAdmin,
+	"DELETE /users/:user/roles/:role": access.PermAdmin,
+
+	"GET /api-keys":             access.PermAdmin,
+	"POST /api-keys":            access.PermAdmin,
+	"DELETE /api-keys/:id":      access.PermAdmin,
+	"POST /api-keys/:id/rotate": access.PermAdmin,
+
+	"GET /metrics": access.PermAdmin,
+
+	"POST /statements/preview": access.PermWrite,
+	"POST /statements/import":  access.PermWrite,
+
+	"GET /rules":            access.PermRead,
+	"GET /rules/:id":        access.PermRead,
+	"POST /rules":           access.PermWrite,
+	"PUT /rules/:id":        access.PermWrite,
+	"DELETE /rules/:id":     access.PermWrite,
+	"POST /rules/:id/apply": access.PermAdmin,
+
+	// Every user may export their own data. Erasing it also requires a
+	// recent sign-in, which the handler checks.
+	"GET /me/export": access.PermRead,
+	"DELETE /me":     access.PermWrite,
+
+	expensepb.ExpenseService_CreateExpense_FullMethodName:  access.PermWrite,
+	expensepb.ExpenseService_GetExpense_FullMethodName:     access.PermRead,
+	expensepb.ExpenseService_UpdateExpense_FullMethodName:  access.PermWrite,
+	expensepb.ExpenseService_ListExpenses_FullMethodName:   access.PermRead,
+	expensepb.ExpenseService_DeleteExpense_FullMethodName:  access.PermWrite,
+	expensepb.ExpenseService_StreamExpenses_FullMethodName: access.PermRead,
+}
+
+// RoleStore returns the roles of a user within a tenant.
+type RoleStore interface {
+	Roles(ctx context.Context, tenant, user string) ([]access.Role, error)
+}
+
+// roles resolves the roles of authenticated users. Without a store every
+// user is without roles.
+var roles RoleStore
+
+// setRoleStore replaces the store that resolves the roles of users.
+func setRoleStore(s RoleStore) {
+	roles = s
+}
+
+// operators are the users, as "tenant/user", granted access.RoleOperator on
+// top of their roles within their tenant.
+var operators map[string]bool
+
+// setOperators replaces the users granted access.RoleOperator.
+func setOperators(users []string) {
+	operators = make(map[string]bool, len(users))
+	for _, u := range users {
+		operators[u] = true
+	}
+}
+
+// withOperator adds access.RoleOperator to rs when user of tenant is an
+// operator.
+func withOperator(rs []access.Role, tenant, user string) []access.Role {
+	if operators[tenant+"/"+user] {
+		return append(rs, access.RoleOperator)
+	}
+	return rs
+}
+
+// authorize returns access.ErrForbidden unless the roles in ctx grant the
+// permission that policy requires for route.
+func authorize(ctx context.Context, route string) error {
+	perm, ok := policy[route]
+	if !ok {
+		return access.ErrForbidden
+	}
+	return access.Require(ctx, perm)
+}
+
+// authorizeGraphQL requires access.PermWrite for mutations. Queries need no
+// more than the policy of POST /graphql.
+func authorizeGraphQL(ctx context.Context, operation string) error {
+	if operation == "mutation" {
+		return access.Require(ctx, access.PermWrite)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/stretchr/testify/assert"
+)
+
+// staticRoles assigns roles to users in every tenant.
+type staticRoles map[string][]access.Role
+
+func (s staticRoles) Roles(_ context.Context, _, user string) ([]access.Role, error) {
+	return s[user], nil
+}
+
+// useRoles resolves roles with s until the end of the test.
+func useRoles(t *testing.T, s RoleStore) {
+	t.Helper()
+	prev := roles
+	setRoleStore(s)
+	t.Cleanup(func() { setRoleStore(prev) })
+}
+
+// publicRoutes are served without authentication.
+var publicRoutes = map[string]bool{
+	"GET /openapi.json": true,
+	"GET /docs":         true,
+}
+
+func TestPolicyCoversRoutes(t *testing.T) {
+	e := newTestRouter(t)
+
+	registered := make(map[string]bool)
+	for _, r := range e.Routes() {
+		key := r.Method + " " + r.Path
+		registered[key] = true
+		if _, ok := policy[key]; !ok && !publicRoutes[key] {
+			t.Errorf("route %s has no policy", key)
+		}
+	}
+	for key := range policy {
+		if key[0] != '/' && !registered[key] {
+			t.Errorf("policy %s has no route", key)
+		}
+	}
+}
+
+func TestPolicyMatrix(t *testing.T) {
+	useRoles(t, staticRoles{
+		"viewer":   {access.RoleViewer},
+		"editor":   {access.RoleEditor},
+		"approver": {access.RoleApprover},
+		"admin":    {access.RoleAdmin},
+	})
+	useTokens(t)
+	type allowed struct{ viewer, editor, approver, admin bool }
+	var (
+		everyone  = allowed{true, true, true, true}
+		editors   = allowed{false, true, true, true}
+		approvers = allowed{false, false, true, true}
+		admins    = allowed{false, false, false, true}
+	)
+	tests := []struct {
+		method string
+		path   string
+		want   allowed
+	}{
+		{http.MethodGet, "/expenses", everyone},
+		{http.MethodGet, "/expenses/search", everyone},
+		{http.MethodGet, "/expenses/summary", everyone},
+		{http.MethodGet, "/expenses/:id", everyone},
+		{http.MethodPost, "/expenses", editors},
+		{http.MethodPut, "/expenses/:id", editors},
+		{http.MethodDelete, "/expenses/:id", editors},
+		{http.MethodGet, "/expenses/:id/transitions", everyone},
+		{http.MethodPost, "/expenses/:id/submit", editors},
+		{http.MethodPost, "/expenses/:id/approve", approvers},
+		{http.MethodPost, "/expenses/:id/reject", approvers},
+		{http.MethodPost, "/expenses/:id/reimburse", approvers},
+		{http.MethodPost, "/settlements", editors},
+		{http.MethodPost, "/exchange-rates", allowed{}},
+		{http.MethodPost, "/webhooks", admins},
+		{http.MethodGet, "/roles", admins},
+		{http.MethodPut, "/users/:user/roles/:role", admins},
+		{http.MethodPost, "/api-keys", admins},
+		{http.MethodPost, "/unknown", allowed{}},
+	}
+	param := regexp.MustCompile(`:\w+`)
+	for _, tt := range tests {
+		e := echo.New()
+		e.Add(tt.method, tt.path, func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		}, Auth)
+
+		for _, r := range []struct {
+			role string
+			want bool
+		}{
+			{"viewer", tt.want.viewer},
+			{"editor", tt.want.editor},
+			{"approver", tt.want.approver},
+			{"admin", tt.want.admin},
+		} {
+			role, want := r.role, r.want
+			t.Run(role+" "+tt.method+" "+tt.path, func(t *testing.T) {
+				req := httptest.NewRequest(tt.method, param.ReplaceAllString(tt.path, "1"), nil)
+				req.Header.Set(echo.HeaderAuthorization, bearer(t, "", role))
+				rec := httptest.NewRecorder()
+
+				e.ServeHTTP(rec, req)
+
+				if want {
+					assert.Equal(t, http.StatusOK, rec.Code)
+				} else {
+					assert.Equal(t, http.StatusForbidden, rec.Code)
+				}
+			})
+		}
+	}
+}
+
+func TestPolicyOperators(t *testing.T) {
+	useRoles(t, staticRoles{"admin": {access.RoleAdmin}})
+	useTokens(t)
+	setOperators([]string{"ops/root"})
+	t.Cleanup(func() { setOperators(nil) })
+
+	e := echo.New()
+	e.POST("/exchange-rates", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, Auth)
+
+	for _, tt := range []struct {
+		tenant, user string
+		want         int
+	}{
+		{"ops", "root", http.StatusOK},
+		{"acme", "root", http.StatusForbidden},
+		{"ops", "admin", http.StatusForbidden},
+	} {
+		t.Run(tt.tenant+"/"+tt.user, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/exchange-rates", nil)
+			req.Header.Set(echo.HeaderAuthorization, bearer(t, tt.tenant, tt.user))
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestAuthorizeGraphQL(t *testing.T) {
+	viewer := access.WithRoles(context.Background(), []access.Role{access.RoleViewer})
+	editor := access.WithRoles(context.Background(), []access.Role{access.RoleEditor})
+
+	assert.NoError(t, authorizeGraphQL(viewer, "query"))
+	assert.ErrorIs(t, authorizeGraphQL(viewer, "mutation"), access.ErrForbidden)
+	assert.NoError(t, authorizeGraphQL(editor, "mutation"))
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/privacy"
+)
+
+// ErrSignInRequired is returned when the data of a user is asked for with a
+// credential other than the signed token of the user, such as an API key.
+var ErrSignInRequired = errors.New("sign in as the user whose data this is")
+
+// ErrReauthRequired is returned when a user signed in too long ago to erase
+// their data.
+var ErrReauthRequired = errors.New("sign in again to erase your data")
+
+// reauthWindow is how recently a user must have signed in to erase their
+// data.
+const reauthWindow = 5 * time.Minute
+
+type privacyHandler struct {
+	privacySvc *privacy.Service
+}
+
+func NewPrivacyHandler(router *echo.Echo, svc *privacy.Service) error {
+	if router == nil || svc == nil {
+		return errors.New("invalid argument")
+	}
+	h := privacyHandler{
+		privacySvc: svc,
+	}
+
+	router.GET("/me/export", h.ExportMe, Auth)
+	router.DELETE("/me", h.EraseMe, Auth)
+	return nil
+}
+
+// ExportMe returns a zip archive of the expenses of the caller, with their
+// attachments and history. The caller is the user of the signed token.
+func (h *privacyHandler) ExportMe(c echo.Context) error {
+	ctx := c.Request().Context()
+	if _, ok := signIn(ctx); !ok {
+		return c.JSON(http.StatusForbidden, echo.Map{
+			"code":    http.StatusForbidden,
+			"message": ErrSignInRequired.Error(),
+		})
+	}
+	ex, err := h.privacySvc.Export(ctx)
+	if errors.Is(err, expense.ErrOwnerEmpty) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/zip")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="export.zip"`)
+	res.WriteHeader(http.StatusOK)
+	// The status is sent: an error from here on can only abort the response.
+	return ex.Write(ctx, res)
+}
+
+// EraseMe erases the data of the caller and returns the tombstone of the
+// erasure. The caller is the user of the signed token, who must have signed
+// in within reauthWindow.
+func (h *privacyHandler) EraseMe(c echo.Context) error {
+	ctx := c.Request().Context()
+	at, ok := signIn(ctx)
+	if !ok {
+		return c.JSON(http.StatusForbidden, echo.Map{
+			"code":    http.StatusForbidden,
+			"message": ErrSignInRequired.Error(),
+		})
+	}
+	if time.Since(at) > reauthWindow {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"code":    http.StatusUnauthorized,
+			"message": ErrReauthRequired.Error(),
+		})
+	}
+	er, err := h.privacySvc.Erase(ctx)
+	if errors.Is(err, expense.ErrOwnerEmpty) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, er)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/attachment"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/privacy"
+	"github.com/phuangpheth/assessment/retention"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerPrivacy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store, err := attachment.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	h := &privacyHandler{privacy.NewService(db, expense.NewService(db), attachment.NewService(db, store, attachment.Limits{}), access.NewService(db), retention.NewService(db, expense.NewService(db), attachment.NewService(db, store, attachment.Limits{})))}
+
+	t.Run("EraseMe() requires the signed token of a user", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/me", nil)
+		req = req.WithContext(expense.WithActor(req.Context(), expense.Actor{ID: "apikey:abc"}))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":403,"message":"sign in as the user whose data this is"}`
+
+		err := h.EraseMe(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("EraseMe() requires a recent sign-in", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/me", nil)
+		ctx := withSignIn(req.Context(), time.Now().Add(-time.Hour))
+		req = req.WithContext(expense.WithActor(ctx, expense.Actor{ID: "ana"}))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":401,"message":"sign in again to erase your data"}`
+
+		err := h.EraseMe(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("ExportMe() requires the signed token of a user", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/me/export", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := h.ExportMe(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("ExportMe() returns a zip archive", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).
+			WithArgs("ana", "").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}))
+		mock.ExpectQuery(`SELECT (.+) FROM expense_events`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "expense_id", "tenant_id", "data", "created_at"}))
+		mock.ExpectQuery(`SELECT (.+) FROM expense_transitions`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "expense_id", "action", "from_status", "to_status", "actor", "reason", "created_at"}))
+		mock.ExpectQuery(`SELECT (.+) FROM expenses_archive`).
+			WithArgs("ana", "").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "data", "archived_at"}))
+
+		req := httptest.NewRequest(http.MethodGet, "/me/export", nil)
+		req = req.WithContext(expense.WithActor(withSignIn(req.Context(), time.Now()), expense.Actor{ID: "ana"}))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := h.ExportMe(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "application/zip", rec.Header().Get(echo.HeaderContentType))
+			assert.True(t, strings.HasPrefix(rec.Body.String(), "PK"))
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
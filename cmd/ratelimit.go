@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/ratelimit"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// limiter limits the requests of each principal. Without a limiter requests
+// are not limited.
+var limiter *ratelimit.Limiter
+
+// setLimiter replaces the limiter of authenticated requests.
+func setLimiter(l *ratelimit.Limiter) {
+	limiter = l
+}
+
+// newLimiter returns the limiter configured by RATE_LIMIT, RATE_LIMIT_ROUTES,
+// RATE_LIMIT_DAILY_QUOTA and RATE_LIMIT_STORE, or nil when RATE_LIMIT is
+// "off".
+func newLimiter(db *sql.DB) (*ratelimit.Limiter, error) {
+	def := getEnv("RATE_LIMIT", "600/m")
+	if def == "off" {
+		return nil, nil
+	}
+	cfg := ratelimit.Config{Routes: make(map[string]ratelimit.Limit)}
+	var err error
+	if cfg.Default, err = ratelimit.ParseLimit(def); err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT: %w", err)
+	}
+	for _, v := range splitEnv("RATE_LIMIT_ROUTES") {
+		route, limit, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("RATE_LIMIT_ROUTES: %q must look like \"GET /expenses=60/m\"", v)
+		}
+		if cfg.Routes[strings.TrimSpace(route)], err = ratelimit.ParseLimit(limit); err != nil {
+			return nil, fmt.Errorf("RATE_LIMIT_ROUTES: %w", err)
+		}
+	}
+	if cfg.DailyQuota, err = strconv.ParseInt(getEnv("RATE_LIMIT_DAILY_QUOTA", "0"), 10, 64); err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_DAILY_QUOTA: %w", err)
+	}
+
+	switch driver := getEnv("RATE_LIMIT_STORE", "memory"); driver {
+	case "memory":
+		return ratelimit.NewLimiter(ratelimit.NewMemoryStore(), cfg), nil
+	case "postgres":
+		return ratelimit.NewLimiter(ratelimit.NewPostgresStore(db), cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", driver)
+	}
+}
+
+// principal identifies who a request is counted against: the user or API
+// key its verified credential was issued to, within its tenant, or else the
+// client IP.
+func principal(ctx context.Context, ip string) string {
+	if c, ok := credential(ctx); ok {
+		return "user:" + c
+	}
+	return "ip:" + ip
+}
+
+// allow counts a request to route against the limiter. Requests are allowed
+// when the limiter fails, so that an unavailable store does not take the API
+// down with it.
+func allow(ctx context.Context, ip, route string) (ratelimit.Decision, bool) {
+	if limiter == nil {
+		return ratelimit.Decision{}, false
+	}
+	d, err := limiter.Allow(ctx, principal(ctx, ip), route)
+	if err != nil {
+		zap.L().Error("failed to rate limit request", zap.String("route", route), zap.Error(err))
+		return ratelimit.Decision{}, false
+	}
+	return d, true
+}
+
+// ceilSeconds rounds d up to whole seconds, as the rate limit headers count
+// seconds.
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// rateLimit sets the RateLimit headers of the authenticated request and
+// responds 429 with Retry-After when the request is over its limit instead
+// of calling next.
+func rateLimit(c echo.Context, route string, next echo.HandlerFunc) error {
+	d, ok := allow(c.Request().Context(), c.RealIP(), route)
+	if !ok {
+		return next(c)
+	}
+	h := c.Response().Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(d.Limit.Requests))
+	h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	h.Set("RateLimit-Reset", ceilSeconds(d.Reset))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", d.Limit.Requests, ceilSeconds(d.Limit.Per)))
+	if d.Allowed {
+		return next(c)
+	}
+
+	message := "rate limit exceeded"
+	if d.QuotaExceeded {
+		message = "daily quota exceeded"
+	}
+	h.Set("Retry-After", ceilSeconds(d.RetryAfter))
+	return c.JSON(http.StatusTooManyRequests, echo.Map{
+		"code":    http.StatusTooManyRequests,
+		"message": message,
+	})
+}
+
+// rateLimitCall is rateLimit for gRPC calls, which are rejected with
+// ResourceExhausted and a retry-after header.
+func rateLimitCall(ctx context.Context, method string) error {
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip, _, _ = net.SplitHostPort(p.Addr.String())
+	}
+	d, ok := allow(ctx, ip, method)
+	if !ok || d.Allowed {
+		return nil
+	}
+
+	message := "rate limit exceeded"
+	if d.QuotaExceeded {
+		message = "daily quota exceeded"
+	}
+	grpc.SetHeader(ctx, metadata.Pairs("retry-after", ceilSeconds(d.RetryAfter)))
+	return status.Error(codes.ResourceExhausted, message)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+// useLimiter sets the limiter for the duration of the test.
+func useLimiter(t *testing.T, l *ratelimit.Limiter) {
+	t.Helper()
+	prev := limiter
+	setLimiter(l)
+	t.Cleanup(func() { setLimiter(prev) })
+}
+
+func TestAuthRateLimit(t *testing.T) {
+	e := echo.New()
+	useRoles(t, staticRoles{"ana": {access.RoleViewer}, "bob": {access.RoleViewer}})
+	useTokens(t)
+	useLimiter(t, ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Config{
+		Default: ratelimit.Limit{Requests: 1, Per: time.Minute},
+	}))
+	get := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/expenses", nil)
+		req.Header.Set(echo.HeaderAuthorization, bearer(t, "", user))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/expenses")
+		h := Auth(func(c echo.Context) error {
+			return c.String(http.StatusOK, "test")
+		})
+		assert.NoError(t, h(c))
+		return rec
+	}
+
+	rec := get("ana")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1;w=60", rec.Header().Get("RateLimit-Policy"))
+
+	rec = get("ana")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	assert.Equal(t, `{"code":429,"message":"rate limit exceeded"}`, strings.TrimSpace(rec.Body.String()))
+
+	rec = get("bob")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestPrincipal(t *testing.T) {
+	ctx := expense.WithTenant(context.Background(), "acme")
+
+	assert.Equal(t, "ip:192.0.2.1", principal(ctx, "192.0.2.1"))
+	assert.Equal(t, "ip:192.0.2.1", principal(expense.WithActor(ctx, expense.Actor{ID: "ana"}), "192.0.2.1"), "an actor without a credential")
+	assert.Equal(t, "user:acme/apikey:0a1b2c3d", principal(withCredential(ctx, "acme", "apikey:0a1b2c3d"), "192.0.2.1"))
+}
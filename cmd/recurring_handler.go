@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/recurring"
+)
+
+type recurringHandler struct {
+	recurringSvc *recurring.Service
+}
+
+func NewRecurringHandler(router *echo.Echo, svc *recurring.Service) error {
+	if router == nil || svc == nil {
+		return errors.New("invalid argument")
+	}
+	h := recurringHandler{
+		recurringSvc: svc,
+	}
+
+	router.GET("/recurring-expenses", h.ListRecurringExpenses, Auth)
+	router.GET("/recurring-expenses/:id", h.GetRecurringExpenseByID, Auth)
+	router.POST("/recurring-expenses", h.SaveRecurringExpense, Auth)
+	router.DELETE("/recurring-expenses/:id", h.DeleteRecurringExpense, Auth)
+	return nil
+}
+
+func (h *recurringHandler) SaveRecurringExpense(c echo.Context) error {
+	var r recurring.RecurringExpense
+	if err := c.Bind(&r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid request body",
+		})
+	}
+	if err := r.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+
+	ctx := c.Request().Context()
+	rec, err := h.recurringSvc.Save(ctx, &r)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusCreated, rec)
+}
+
+func (h *recurringHandler) ListRecurringExpenses(c echo.Context) error {
+	ctx := c.Request().Context()
+	rs, err := h.recurringSvc.List(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, rs)
+}
+
+func (h *recurringHandler) GetRecurringExpenseByID(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	r, err := h.recurringSvc.GetByID(ctx, id)
+	if errors.Is(err, recurring.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": errors.Unwrap(err).Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, r)
+}
+
+func (h *recurringHandler) DeleteRecurringExpense(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	err = h.recurringSvc.Delete(ctx, id)
+	if errors.Is(err, recurring.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": errors.Unwrap(err).Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/recurring"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerSaveRecurringExpense(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := echo.New()
+	h := &recurringHandler{recurring.NewService(db, expense.NewService(db))}
+
+	t.Run("SaveRecurringExpense()", func(t *testing.T) {
+		body := `{
+			"template": {"amount": 500, "title": "rent", "tags": ["home"]},
+			"schedule": {"frequency": "monthly", "start_date": "2026-01-31T00:00:00Z"}
+		}`
+		mock.ExpectQuery(`INSERT INTO recurring_expenses (.+) RETURNING id`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		req := httptest.NewRequest(http.MethodPost, "/recurring-expenses", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"id":1,"template":{"id":0,"amount":500,"title":"rent","note":"","tags":["home"]},"schedule":{"frequency":"monthly","interval":1,"start_date":"2026-01-31T00:00:00Z"},"next_date":"2026-01-31T00:00:00Z"}`
+
+		err := h.SaveRecurringExpense(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("SaveRecurringExpense() returns invalid frequency", func(t *testing.T) {
+		body := `{
+			"template": {"amount": 500, "title": "rent"},
+			"schedule": {"frequency": "hourly", "start_date": "2026-01-31T00:00:00Z"}
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/recurring-expenses", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":400,"message":"frequency must be one of daily, weekly, monthly or yearly"}`
+
+		err := h.SaveRecurringExpense(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/apikey"
+	"github.com/phuangpheth/assessment/attachment"
+	"github.com/phuangpheth/assessment/budget"
+	"github.com/phuangpheth/assessment/currency"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/graph"
+	"github.com/phuangpheth/assessment/privacy"
+	"github.com/phuangpheth/assessment/recurring"
+	"github.com/phuangpheth/assessment/rule"
+	"github.com/phuangpheth/assessment/split"
+	"github.com/phuangpheth/assessment/statement"
+	"github.com/phuangpheth/assessment/stream"
+	"github.com/phuangpheth/assessment/webhook"
+)
+
+// services are the dependencies of the REST routes.
+type services struct {
+	expense    *expense.Service
+	attachment *attachment.Service
+	budget     *budget.Service
+	recurring  *recurring.Service
+	webhook    *webhook.Service
+	currency   *currency.Service
+	split      *split.Service
+	access     *access.Service
+	apiKey     *apikey.Service
+	statement  *statement.Service
+	rule       *rule.Service
+	privacy    *privacy.Service
+	schema     *graph.Schema
+	broker     *stream.Broker
+	cache      *expense.Cache
+}
+
+// registerRoutes registers every REST route on router. Each route must have
+// an operation in openapi/openapi.json.
+func registerRoutes(router *echo.Echo, s services) error {
+	if err := NewOpenAPIHandler(router); err != nil {
+		return err
+	}
+	if err := NewHandler(router, s.expense); err != nil {
+		return err
+	}
+	if err := NewAttachmentHandler(router, s.expense, s.attachment); err != nil {
+		return err
+	}
+	if err := NewBudgetHandler(router, s.budget); err != nil {
+		return err
+	}
+	if err := NewRecurringHandler(router, s.recurring); err != nil {
+		return err
+	}
+	if err := NewGraphQLHandler(router, s.schema); err != nil {
+		return err
+	}
+	if err := NewWebhookHandler(router, s.webhook); err != nil {
+		return err
+	}
+	if err := NewCurrencyHandler(router, s.currency); err != nil {
+		return err
+	}
+	if err := NewSplitHandler(router, s.split); err != nil {
+		return err
+	}
+	if err := NewAccessHandler(router, s.access); err != nil {
+		return err
+	}
+	if err := NewAPIKeyHandler(router, s.apiKey); err != nil {
+		return err
+	}
+	if err := NewMetricsHandler(router, s.cache); err != nil {
+		return err
+	}
+	if err := NewStatementHandler(router, s.statement); err != nil {
+		return err
+	}
+	if err := NewRuleHandler(router, s.rule); err != nil {
+		return err
+	}
+	if err := NewPrivacyHandler(router, s.privacy); err != nil {
+		return err
+	}
+	return NewStreamHandler(router, s.expense, s.broker)
+}
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/apikey"
+	"github.com/phuangpheth/assessment/attachment"
+	"github.com/phuangpheth/assessment/budget"
+	"github.com/phuangpheth/assessment/currency"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/graph"
+	"github.com/phuangpheth/assessment/openapi"
+	"github.com/phuangpheth/assessment/privacy"
+	"github.com/phuangpheth/assessment/recurring"
+	"github.com/phuangpheth/assessment/rule"
+	"github.com/phuangpheth/assessment/split"
+	"github.com/phuangpheth/assessment/statement"
+	"github.com/phuangpheth/assessment/stream"
+	"github.com/phuangpheth/assessment/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRouter(t *testing.T) *echo.Echo {
+	t.Helper()
+	e := echo.New()
+	err := registerRoutes(e, services{
+		expense:    &expense.Service{},
+		attachment: &attachment.Service{},
+		budget:     &budget.Service{},
+		recurring:  &recurring.Service{},
+		webhook:    &webhook.Service{},
+		currency:   &currency.Service{},
+		split:      &split.Service{},
+		access:     &access.Service{},
+		apiKey:     &apikey.Service{},
+		schema:     &graph.Schema{},
+		broker:     stream.NewBroker(nil),
+		cache:      &expense.Cache{},
+		statement:  &statement.Service{},
+		rule:       &rule.Service{},
+		privacy:    &privacy.Service{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// TestRoutesOpenAPI keeps the hand-maintained openapi.json in step with the
+// routes of the router.
+func TestRoutesOpenAPI(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newTestRouter(t)
+
+	registered := make(map[string]bool)
+	for _, r := range e.Routes() {
+		path := openapi.PathTemplate(r.Path)
+		registered[r.Method+" "+path] = true
+		if doc.Operation(r.Method, path) == nil {
+			t.Errorf("route %s %s has no operation in openapi.json", r.Method, path)
+		}
+	}
+	for path, item := range doc.Paths {
+		for method := range item {
+			if !registered[strings.ToUpper(method)+" "+path] {
+				t.Errorf("operation %s %s in openapi.json has no route", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+// TestOpenAPISchemas checks the component schemas of openapi.json against
+// the JSON of the types the handlers bind and respond with, filled so that
+// every field is marshaled: a response schema must declare exactly the
+// properties of its type, and an input schema only properties its type
+// binds.
+func TestOpenAPISchemas(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses := map[string]any{
+		"Expense":               expense.Expense{},
+		"Attachment":            attachment.Attachment{},
+		"Schedule":              recurring.Schedule{},
+		"RecurringExpense":      recurring.RecurringExpense{},
+		"Budget":                budget.Budget{},
+		"BudgetStatus":          budget.Status{},
+		"Webhook":               webhook.Subscription{},
+		"WebhookDelivery":       webhook.Delivery{},
+		"SearchResult":          expense.SearchResult{},
+		"ExchangeRate":          currency.Rate{},
+		"SummaryGroup":          expense.Group{},
+		"DuplicateMatch":        expense.Match{},
+		"DuplicatePair":         expense.DuplicatePair{},
+		"Split":                 expense.Split{},
+		"Participant":           expense.Participant{},
+		"Balance":               split.Balance{},
+		"Transfer":              split.Transfer{},
+		"Balances":              split.Balances{},
+		"Settlement":            split.Settlement{},
+		"Transition":            expense.Transition{},
+		"RoleAssignment":        access.Assignment{},
+		"APIKey":                apikey.Key{},
+		"StatementTransaction":  statement.Transaction{},
+		"StatementRow":          statement.Row{},
+		"StatementImportResult": statement.Result{},
+		"RuleCondition":         rule.Condition{},
+		"Rule":                  rule.Rule{},
+		"RuleChange":            rule.Change{},
+		"Erasure":               privacy.Erasure{},
+	}
+	inputs := map[string]any{
+		"ExpenseInput":          expense.Expense{},
+		"RecurringExpenseInput": recurring.RecurringExpense{},
+		"BudgetInput":           budget.Budget{},
+		"WebhookInput":          webhook.Subscription{},
+		"SettlementInput":       split.Settlement{},
+		"APIKeyInput":           apikey.Key{},
+		"StatementDecision":     statement.Decision{},
+		"RuleInput":             rule.Rule{},
+		"GraphQLRequest":        graph.Request{},
+	}
+	// builtByHandlers are the schemas of JSON that handlers build
+	// themselves, and of strings.
+	builtByHandlers := map[string]bool{
+		"Error":          true,
+		"DuplicateError": true,
+		"GraphQLResult":  true,
+		"RuleBackfill":   true,
+		"EventType":      true,
+		"ExpenseStatus":  true,
+		"Role":           true,
+		"APIKeyScope":    true,
+	}
+
+	for name, s := range doc.Components.Schemas {
+		v, response := responses[name]
+		if !response {
+			v = inputs[name]
+		}
+		if v == nil {
+			if !builtByHandlers[name] {
+				t.Errorf("schema %s is not checked against a type", name)
+			}
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			ptr := reflect.New(reflect.TypeOf(v))
+			fill(ptr.Elem())
+			b, err := json.Marshal(ptr.Interface())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got any
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			for _, msg := range conform(doc, s, got, name, response) {
+				t.Error(msg)
+			}
+		})
+	}
+}
+
+// fill sets every exported field reachable from v to a value that is
+// marshaled, so that fields with omitempty are too.
+func fill(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		fill(v.Elem())
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(`{}`))
+			return
+		}
+		e := reflect.New(v.Type().Elem()).Elem()
+		fill(e)
+		v.Set(reflect.Append(reflect.MakeSlice(v.Type(), 0, 1), e))
+	case reflect.Map:
+		k := reflect.New(v.Type().Key()).Elem()
+		fill(k)
+		e := reflect.New(v.Type().Elem()).Elem()
+		fill(e)
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(k, e)
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	}
+}
+
+// conform returns how v, decoded JSON at path, differs from s. Properties of
+// v missing from s are only reported when strict.
+func conform(doc *openapi.Document, s *openapi.Schema, v any, path string, strict bool) []string {
+	for s != nil && s.Ref != "" {
+		s = doc.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	if s == nil {
+		return nil
+	}
+	var msgs []string
+	switch v := v.(type) {
+	case map[string]any:
+		if s.Properties == nil {
+			return nil
+		}
+		keys := make([]string, 0, len(s.Properties))
+		for k := range s.Properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			got, ok := v[k]
+			if !ok {
+				msgs = append(msgs, path+"."+k+" is in openapi.json but not in the JSON")
+				continue
+			}
+			msgs = append(msgs, conform(doc, s.Properties[k], got, path+"."+k, strict)...)
+		}
+		if strict {
+			for k := range v {
+				if _, ok := s.Properties[k]; !ok {
+					msgs = append(msgs, path+"."+k+" is in the JSON but not in openapi.json")
+				}
+			}
+		}
+	case []any:
+		if s.Items != nil && len(v) > 0 {
+			msgs = append(msgs, conform(doc, s.Items, v[0], path+"[]", strict)...)
+		}
+	}
+	return msgs
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	e := newTestRouter(t)
+
+	t.Run("GET /openapi.json", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"openapi": "3.1.0"`)
+	})
+
+	t.Run("GET /docs", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/html")
+	})
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/rule"
+)
+
+type ruleHandler struct {
+	ruleSvc *rule.Service
+}
+
+func NewRuleHandler(router *echo.Echo, svc *rule.Service) error {
+	if router == nil || svc == nil {
+		return errors.New("invalid argument")
+	}
+	h := ruleHandler{
+		ruleSvc: svc,
+	}
+
+	router.GET("/rules", h.ListRules, Auth)
+	router.GET("/rules/:id", h.GetRuleByID, Auth)
+	router.POST("/rules", h.SaveRule, Auth)
+	router.PUT("/rules/:id", h.UpdateRule, Auth)
+	router.DELETE("/rules/:id", h.DeleteRule, Auth)
+	router.POST("/rules/:id/apply", h.ApplyRule, Auth)
+	return nil
+}
+
+func (h *ruleHandler) SaveRule(c echo.Context) error {
+	var r rule.Rule
+	if err := c.Bind(&r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid request body",
+		})
+	}
+	if err := r.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+
+	ctx := c.Request().Context()
+	saved, err := h.ruleSvc.Save(ctx, &r)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusCreated, saved)
+}
+
+func (h *ruleHandler) UpdateRule(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+	var r rule.Rule
+	if err := c.Bind(&r); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid request body",
+		})
+	}
+	r.ID = id
+	if err := r.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+
+	ctx := c.Request().Context()
+	updated, err := h.ruleSvc.Update(ctx, &r)
+	if errors.Is(err, rule.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": errors.Unwrap(err).Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+func (h *ruleHandler) ListRules(c echo.Context) error {
+	ctx := c.Request().Context()
+	rs, err := h.ruleSvc.List(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, rs)
+}
+
+func (h *ruleHandler) GetRuleByID(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	r, err := h.ruleSvc.GetByID(ctx, id)
+	if errors.Is(err, rule.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": errors.Unwrap(err).Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, r)
+}
+
+func (h *ruleHandler) DeleteRule(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	err = h.ruleSvc.Delete(ctx, id)
+	if errors.Is(err, rule.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": errors.Unwrap(err).Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ApplyRule backfills the rule on existing expenses and returns the tags it
+// added to each. With dry_run=true nothing is saved.
+func (h *ruleHandler) ApplyRule(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+	dryRun := false
+	if v := c.QueryParam("dry_run"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"code":    http.StatusBadRequest,
+				"message": "invalid params",
+			})
+		}
+	}
+
+	ctx := c.Request().Context()
+	changes, err := h.ruleSvc.Backfill(ctx, id, dryRun)
+	if errors.Is(err, rule.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": errors.Unwrap(err).Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"dry_run": dryRun,
+		"changes": changes,
+	})
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/rule"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerRules(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := echo.New()
+	h := &ruleHandler{rule.NewService(db, expense.NewService(db))}
+
+	t.Run("SaveRule() creates the rule", func(t *testing.T) {
+		body := `{"name":"Large","conditions":[{"field":"amount","op":"gt","value":"1000"}],"tags":["large"]}`
+		mock.ExpectQuery(`INSERT INTO rules`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		req := httptest.NewRequest(http.MethodPost, "/rules", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"id":1,"name":"Large","priority":0,"conditions":[{"field":"amount","op":"gt","value":"1000"}],"tags":["large"],"stop":false}`
+
+		err := h.SaveRule(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("SaveRule() returns invalid condition", func(t *testing.T) {
+		body := `{"name":"Taxi","conditions":[{"field":"title","op":"matches","value":"("}],"tags":["transport"]}`
+		req := httptest.NewRequest(http.MethodPost, "/rules", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := h.SaveRule(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), "invalid condition")
+		}
+	})
+
+	t.Run("ApplyRule() returns not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM rules`).WithArgs(7, "").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectRollback()
+
+		req := httptest.NewRequest(http.MethodPost, "/rules/7/apply?dry_run=true", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+		want := `{"code":404,"message":"not found"}`
+
+		err := h.ApplyRule(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+)
+
+// schema is applied in order on startup. Every statement must be idempotent.
+// It must create the same objects as migrations/, which TestSchemaMigrations
+// checks.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS expenses (
+	  id SERIAL PRIMARY KEY,
+	  title TEXT,
+	  amount FLOAT,
+	  note TEXT,
+	  tags TEXT[]
+	);`,
+	`CREATE TABLE IF NOT EXISTS attachments (
+	  id SERIAL PRIMARY KEY,
+	  expense_id INT NOT NULL REFERENCES expenses (id) ON DELETE CASCADE,
+	  filename TEXT NOT NULL,
+	  content_type TEXT NOT NULL,
+	  size BIGINT NOT NULL,
+	  sha256 TEXT NOT NULL,
+	  storage_key TEXT NOT NULL,
+	  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);`,
+	`CREATE INDEX IF NOT EXISTS attachments_expense_id_idx ON attachments (expense_id);`,
+	`CREATE TABLE IF NOT EXISTS recurring_expenses (
+	  id SERIAL PRIMARY KEY,
+	  title TEXT NOT NULL,
+	  amount FLOAT NOT NULL,
+	  note TEXT NOT NULL DEFAULT '',
+	  tags TEXT[],
+	  frequency TEXT NOT NULL,
+	  interval_count INT NOT NULL DEFAULT 1,
+	  start_date DATE NOT NULL,
+	  end_date DATE,
+	  next_index INT NOT NULL DEFAULT 0,
+	  next_date DATE NOT NULL
+	);`,
+	`CREATE INDEX IF NOT EXISTS recurring_expenses_next_date_idx ON recurring_expenses (next_date);`,
+	`CREATE TABLE IF NOT EXISTS recurring_occurrences (
+	  recurring_id INT NOT NULL REFERENCES recurring_expenses (id) ON DELETE CASCADE,
+	  occurs_on DATE NOT NULL,
+	  expense_id INT NOT NULL REFERENCES expenses (id) ON DELETE CASCADE,
+	  PRIMARY KEY (recurring_id, occurs_on)
+	);`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();`,
+	`CREATE INDEX IF NOT EXISTS expenses_created_at_idx ON expenses (created_at);`,
+	`CREATE TABLE IF NOT EXISTS budgets (
+	  id SERIAL PRIMARY KEY,
+	  name TEXT NOT NULL DEFAULT '',
+	  tag TEXT NOT NULL,
+	  amount_limit FLOAT NOT NULL,
+	  period TEXT NOT NULL
+	);`,
+	`CREATE INDEX IF NOT EXISTS budgets_tag_idx ON budgets (tag);`,
+	`CREATE TABLE IF NOT EXISTS expense_events (
+	  id BIGSERIAL PRIMARY KEY,
+	  type TEXT NOT NULL,
+	  expense_id INT NOT NULL,
+	  data JSONB NOT NULL,
+	  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);`,
+	`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+	  id SERIAL PRIMARY KEY,
+	  url TEXT NOT NULL,
+	  secret TEXT NOT NULL,
+	  event_types TEXT[] NOT NULL,
+	  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);`,
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	  id BIGSERIAL PRIMARY KEY,
+	  subscription_id INT NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+	  event_id BIGINT NOT NULL REFERENCES expense_events (id) ON DELETE CASCADE,
+	  status TEXT NOT NULL DEFAULT 'pending',
+	  attempts INT NOT NULL DEFAULT 0,
+	  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	  last_error TEXT NOT NULL DEFAULT '',
+	  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);`,
+	`CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';`,
+	`CREATE TABLE IF NOT EXISTS webhook_cursor (
+	  id INT PRIMARY KEY CHECK (id = 1),
+	  last_event_id BIGINT NOT NULL
+	);`,
+	`INSERT INTO webhook_cursor (id, last_event_id) VALUES (1, 0) ON CONFLICT DO NOTHING;`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;`,
+	`ALTER TABLE expense_events ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';`,
+	`CREATE OR REPLACE FUNCTION notify_expense_event() RETURNS trigger AS $$
+	BEGIN
+	  PERFORM pg_notify('expense_events', NEW.id::text);
+	  RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;`,
+	`DROP TRIGGER IF EXISTS expense_events_notify ON expense_events;`,
+	`CREATE TRIGGER expense_events_notify AFTER INSERT ON expense_events
+	  FOR EACH ROW EXECUTE PROCEDURE notify_expense_event();`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS search tsvector
+	  GENERATED ALWAYS AS (to_tsvector('english', coalesce(title, '') || ' ' || coalesce(note, ''))) STORED;`,
+	`CREATE INDEX IF NOT EXISTS expenses_search_idx ON expenses USING GIN (search);`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT '';`,
+	`DO $$
+	BEGIN
+	  IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'expenses' AND column_name = 'spent_on') THEN
+	    ALTER TABLE expenses ADD COLUMN spent_on DATE;
+	    UPDATE expenses SET spent_on = created_at::date;
+	    ALTER TABLE expenses ALTER COLUMN spent_on SET DEFAULT CURRENT_DATE;
+	    ALTER TABLE expenses ALTER COLUMN spent_on SET NOT NULL;
+	  END IF;
+	  IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'expenses' AND column_name = 'base_amount') THEN
+	    ALTER TABLE expenses ADD COLUMN base_amount FLOAT;
+	    UPDATE expenses SET base_amount = amount;
+	  END IF;
+	END;
+	$$;`,
+	`ALTER TABLE recurring_expenses ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT '';`,
+	`CREATE TABLE IF NOT EXISTS exchange_rates (
+	  date DATE NOT NULL,
+	  base TEXT NOT NULL,
+	  quote TEXT NOT NULL,
+	  rate FLOAT NOT NULL CHECK (rate > 0),
+	  PRIMARY KEY (date, base, quote)
+	);`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS split JSONB;`,
+	`CREATE TABLE IF NOT EXISTS settlements (
+	  id SERIAL PRIMARY KEY,
+	  from_name TEXT NOT NULL,
+	  to_name TEXT NOT NULL,
+	  amount FLOAT NOT NULL CHECK (amount > 0),
+	  note TEXT NOT NULL DEFAULT '',
+	  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'draft';`,
+	`CREATE TABLE IF NOT EXISTS expense_transitions (
+	  id BIGSERIAL PRIMARY KEY,
+	  expense_id INT NOT NULL REFERENCES expenses (id) ON DELETE CASCADE,
+	  action TEXT NOT NULL,
+	  from_status TEXT NOT NULL,
+	  to_status TEXT NOT NULL,
+	  actor TEXT NOT NULL DEFAULT '',
+	  reason TEXT NOT NULL DEFAULT '',
+	  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);`,
+	`CREATE INDEX IF NOT EXISTS expense_transitions_expense_id_idx ON expense_transitions (expense_id);`,
+	`CREATE TABLE IF NOT EXISTS role_assignments (
+	  tenant_id TEXT NOT NULL DEFAULT '',
+	  user_id TEXT NOT NULL,
+	  role TEXT NOT NULL CHECK (role IN ('viewer', 'editor', 'approver', 'admin')),
+	  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	  PRIMARY KEY (tenant_id, user_id, role)
+	);`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';`,
+	`CREATE INDEX IF NOT EXISTS expenses_tenant_id_idx ON expenses (tenant_id, id);`,
+	`ALTER TABLE recurring_expenses ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE budgets ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';`,
+	`CREATE INDEX IF NOT EXISTS budgets_tenant_tag_idx ON budgets (tenant_id, tag);`,
+	`ALTER TABLE settlements ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';`,
+	`CREATE INDEX IF NOT EXISTS settlements_tenant_id_idx ON settlements (tenant_id, id);`,
+	`CREATE TABLE IF NOT EXISTS api_keys (
+	  id BIGSERIAL PRIMARY KEY,
+	  tenant_id TEXT NOT NULL DEFAULT '',
+	  name TEXT NOT NULL,
+	  prefix TEXT NOT NULL UNIQUE,
+	  hash TEXT NOT NULL,
+	  scopes TEXT[] NOT NULL,
+	  expires_at TIMESTAMPTZ,
+	  last_used_at TIMESTAMPTZ,
+	  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	  revoked_at TIMESTAMPTZ
+	);`,
+	`CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+	  key TEXT PRIMARY KEY,
+	  tokens DOUBLE PRECISION NOT NULL,
+	  updated_at TIMESTAMPTZ NOT NULL
+	);`,
+	`CREATE TABLE IF NOT EXISTS rate_limit_quotas (
+	  key TEXT NOT NULL,
+	  day DATE NOT NULL,
+	  count BIGINT NOT NULL,
+	  PRIMARY KEY (key, day)
+	);`,
+	`CREATE INDEX IF NOT EXISTS rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);`,
+	`CREATE INDEX IF NOT EXISTS rate_limit_quotas_day_idx ON rate_limit_quotas (day);`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();`,
+	`CREATE TABLE IF NOT EXISTS statement_transactions (
+	  tenant_id TEXT NOT NULL DEFAULT '',
+	  fingerprint TEXT NOT NULL,
+	  action TEXT NOT NULL CHECK (action IN ('confirm', 'skip')),
+	  expense_id INT REFERENCES expenses (id) ON DELETE SET NULL,
+	  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	  PRIMARY KEY (tenant_id, fingerprint)
+	);`,
+	`CREATE TABLE IF NOT EXISTS rules (
+	  id SERIAL PRIMARY KEY,
+	  tenant_id TEXT NOT NULL DEFAULT '',
+	  name TEXT NOT NULL,
+	  priority INT NOT NULL DEFAULT 0,
+	  conditions JSONB NOT NULL,
+	  tags TEXT[] NOT NULL,
+	  stop BOOLEAN NOT NULL DEFAULT false,
+	  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);`,
+	`ALTER TABLE rules ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';`,
+	`DROP INDEX IF EXISTS rules_priority_idx;`,
+	`CREATE INDEX IF NOT EXISTS rules_tenant_priority_idx ON rules (tenant_id, priority, id);`,
+	`CREATE INDEX IF NOT EXISTS expenses_duplicate_idx ON expenses (currency, amount, spent_on);`,
+	`CREATE TABLE IF NOT EXISTS expenses_archive (
+	  id INT PRIMARY KEY,
+	  spent_on DATE NOT NULL,
+	  data JSONB NOT NULL,
+	  archived_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);`,
+	`CREATE TABLE IF NOT EXISTS retention_log (
+	  id BIGSERIAL PRIMARY KEY,
+	  action TEXT NOT NULL CHECK (action IN ('archive', 'purge')),
+	  cutoff TIMESTAMPTZ NOT NULL,
+	  expense_ids INT[] NOT NULL,
+	  actor TEXT NOT NULL DEFAULT '',
+	  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);`,
+	`CREATE INDEX IF NOT EXISTS expenses_deleted_at_idx ON expenses (deleted_at) WHERE deleted_at IS NOT NULL;`,
+	`CREATE INDEX IF NOT EXISTS expenses_spent_on_idx ON expenses (spent_on);`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS owner_id TEXT NOT NULL DEFAULT '';`,
+	`CREATE INDEX IF NOT EXISTS expenses_owner_idx ON expenses (tenant_id, owner_id);`,
+	`CREATE TABLE IF NOT EXISTS erasures (
+	  id BIGSERIAL PRIMARY KEY,
+	  tenant_id TEXT NOT NULL,
+	  subject TEXT NOT NULL,
+	  deleted INT NOT NULL,
+	  anonymized INT NOT NULL,
+	  attachments INT NOT NULL,
+	  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);`,
+	`CREATE INDEX IF NOT EXISTS expenses_archive_owner_idx ON expenses_archive ((data->'expense'->>'tenant_id'), (data->'expense'->>'owner_id'));`,
+	`DO $$
+	BEGIN
+	  IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'expense_events' AND column_name = 'tx_id') THEN
+	    ALTER TABLE expense_events ADD COLUMN tx_id BIGINT NOT NULL DEFAULT txid_current();
+	    ALTER TABLE webhook_cursor ADD COLUMN IF NOT EXISTS last_tx_id BIGINT NOT NULL DEFAULT 0;
+	    UPDATE webhook_cursor SET last_tx_id = txid_current() WHERE last_event_id > 0;
+	  END IF;
+	END;
+	$$;`,
+	`CREATE INDEX IF NOT EXISTS expense_events_position_idx ON expense_events (tx_id, id);`,
+	`ALTER TABLE recurring_expenses ADD COLUMN IF NOT EXISTS owner_id TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE recurring_expenses ADD COLUMN IF NOT EXISTS retry_at TIMESTAMPTZ;`,
+	`ALTER TABLE recurring_expenses ADD COLUMN IF NOT EXISTS last_error TEXT NOT NULL DEFAULT '';`,
+}
+
+func createSchema(ctx context.Context, db *sql.DB) error {
+	for _, stmt := range schema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	createTableRe = regexp.MustCompile(`(?s)CREATE TABLE IF NOT EXISTS (\w+) \((.*?)\n\s*\);`)
+	addColumnRe   = regexp.MustCompile(`ALTER TABLE (\w+) ADD COLUMN (?:IF NOT EXISTS )?(\w+)`)
+	indexRe       = regexp.MustCompile(`(CREATE (?:UNIQUE )?INDEX (?:IF NOT EXISTS )?|DROP INDEX (?:IF EXISTS )?)(\w+)`)
+	routineRe     = regexp.MustCompile(`CREATE (?:OR REPLACE )?(?:FUNCTION|TRIGGER) (\w+)`)
+)
+
+// objects lists the tables, columns, indexes, functions and triggers the DDL
+// leaves behind.
+func objects(ddl string) []string {
+	set := map[string]bool{}
+	for _, m := range createTableRe.FindAllStringSubmatch(ddl, -1) {
+		set["table "+m[1]] = true
+		for _, line := range strings.Split(m[2], "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			switch strings.ToUpper(fields[0]) {
+			case "PRIMARY", "UNIQUE", "CONSTRAINT", "FOREIGN", "CHECK":
+				continue
+			}
+			set["column "+m[1]+"."+fields[0]] = true
+		}
+	}
+	for _, m := range addColumnRe.FindAllStringSubmatch(ddl, -1) {
+		set["column "+m[1]+"."+m[2]] = true
+	}
+	for _, m := range indexRe.FindAllStringSubmatch(ddl, -1) {
+		set["index "+m[2]] = strings.HasPrefix(m[1], "CREATE")
+	}
+	for _, m := range routineRe.FindAllStringSubmatch(ddl, -1) {
+		set["routine "+m[1]] = true
+	}
+	var out []string
+	for o, ok := range set {
+		if ok {
+			out = append(out, o)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestSchemaMigrations(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "migrations", "*.up.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	sort.Strings(files)
+
+	var migrations strings.Builder
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		require.NoError(t, err)
+		migrations.Write(b)
+		migrations.WriteString("\n")
+	}
+
+	assert.Equal(t, objects(migrations.String()), objects(strings.Join(schema, "\n")),
+		"cmd/schema.go and migrations/ must create the same objects")
+}
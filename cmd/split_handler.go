@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/split"
+)
+
+type splitHandler struct {
+	splitSvc *split.Service
+}
+
+func NewSplitHandler(router *echo.Echo, svc *split.Service) error {
+	if router == nil || svc == nil {
+		return errors.New("invalid argument")
+	}
+	h := splitHandler{
+		splitSvc: svc,
+	}
+
+	router.GET("/balances", h.GetBalances, Auth)
+	router.GET("/settlements", h.ListSettlements, Auth)
+	router.POST("/settlements", h.SaveSettlement, Auth)
+	return nil
+}
+
+// GetBalances returns who owes whom and the fewest transfers that settle up.
+func (h *splitHandler) GetBalances(c echo.Context) error {
+	ctx := c.Request().Context()
+	b, err := h.splitSvc.Balances(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, b)
+}
+
+func (h *splitHandler) SaveSettlement(c echo.Context) error {
+	var st split.Settlement
+	if err := c.Bind(&st); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid request body",
+		})
+	}
+	if err := st.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+
+	ctx := c.Request().Context()
+	s, err := h.splitSvc.SaveSettlement(ctx, &st)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusCreated, s)
+}
+
+func (h *splitHandler) ListSettlements(c echo.Context) error {
+	ctx := c.Request().Context()
+	sts, err := h.splitSvc.ListSettlements(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, sts)
+}
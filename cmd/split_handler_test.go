@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/split"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerSaveSettlement(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := echo.New()
+	h := &splitHandler{split.NewService(db, expense.NewService(db))}
+
+	t.Run("SaveSettlement()", func(t *testing.T) {
+		body := `{"from":"Bo","to":"Ana","amount":170}`
+		mock.ExpectQuery("INSERT INTO settlements (.+) RETURNING id, created_at").
+			WithArgs("", "Bo", "Ana", 170.0, "").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)))
+
+		req := httptest.NewRequest(http.MethodPost, "/settlements", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"id":1,"from":"Bo","to":"Ana","amount":170,"note":"","created_at":"2026-10-18T00:00:00Z"}`
+
+		err := h.SaveSettlement(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("SaveSettlement() returns same person", func(t *testing.T) {
+		body := `{"from":"Ana","to":"Ana","amount":10}`
+		req := httptest.NewRequest(http.MethodPost, "/settlements", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":400,"message":"from and to must be different people"}`
+
+		err := h.SaveSettlement(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
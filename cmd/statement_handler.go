@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/statement"
+)
+
+// maxStatementSize is the largest statement accepted for preview.
+const maxStatementSize = 5 << 20
+
+type statementHandler struct {
+	statementSvc *statement.Service
+}
+
+func NewStatementHandler(router *echo.Echo, svc *statement.Service) error {
+	if router == nil || svc == nil {
+		return errors.New("invalid argument")
+	}
+	h := statementHandler{
+		statementSvc: svc,
+	}
+
+	router.POST("/statements/preview", h.PreviewStatement, Auth)
+	router.POST("/statements/import", h.ImportStatement, Auth)
+	return nil
+}
+
+// PreviewStatement parses the statement in the body, or in the file field of
+// a multipart form, and returns its debits as expense drafts. The format
+// query parameter is detected from the content when empty.
+func (h *statementHandler) PreviewStatement(c echo.Context) error {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxStatementSize)
+	var r io.Reader = req.Body
+	if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"code":    http.StatusBadRequest,
+				"message": "invalid request body",
+			})
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"code":    http.StatusBadRequest,
+				"message": "invalid request body",
+			})
+		}
+		defer f.Close()
+		r = f
+	}
+
+	txs, err := statement.Parse(r, c.QueryParam("format"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+
+	ctx := req.Context()
+	rows, err := h.statementSvc.Preview(ctx, txs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, rows)
+}
+
+// ImportStatement saves the previewed rows the user confirmed and records
+// the skipped ones, so that later statements do not offer them again.
+func (h *statementHandler) ImportStatement(c echo.Context) error {
+	var ds []statement.Decision
+	if err := c.Bind(&ds); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid request body",
+		})
+	}
+	for i := range ds {
+		if err := ds[i].Validate(); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"code":    http.StatusBadRequest,
+				"message": err.Error(),
+			})
+		}
+	}
+
+	ctx := c.Request().Context()
+	res, err := h.statementSvc.Import(ctx, ds)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/statement"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerPreviewStatement(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := echo.New()
+	h := &statementHandler{statement.NewService(db, expense.NewService(db))}
+
+	t.Run("PreviewStatement() returns the debits as drafts", func(t *testing.T) {
+		body := "<OFX><CURDEF>THB<STMTTRN><DTPOSTED>20261001<TRNAMT>-45.00<FITID>1<NAME>Noodles</STMTTRN></OFX>"
+		mock.ExpectQuery(`SELECT fingerprint FROM statement_transactions`).
+			WillReturnRows(sqlmock.NewRows([]string{"fingerprint"}))
+
+		req := httptest.NewRequest(http.MethodPost, "/statements/preview", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, "application/x-ofx")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := h.PreviewStatement(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			var rows []statement.Row
+			if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rows)) && assert.Len(t, rows, 1) {
+				assert.Equal(t, expense.Expense{Amount: 45, Title: "Noodles", Currency: "THB", SpentOn: "2026-10-01", BaseAmount: 45}, rows[0].Expense)
+				assert.False(t, rows[0].Duplicate)
+			}
+		}
+	})
+
+	t.Run("PreviewStatement() returns unknown format", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/statements/preview", strings.NewReader("date,amount\n"))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":400,"message":"statement format must be ofx, qfx or camt053"}`
+
+		err := h.PreviewStatement(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/stream"
+)
+
+type streamHandler struct {
+	expenseSvc *expense.Service
+	broker     *stream.Broker
+	heartbeat  time.Duration
+}
+
+func NewStreamHandler(router *echo.Echo, svc *expense.Service, broker *stream.Broker) error {
+	if router == nil || svc == nil || broker == nil {
+		return errors.New("invalid argument")
+	}
+	h := streamHandler{
+		expenseSvc: svc,
+		broker:     broker,
+		heartbeat:  15 * time.Second,
+	}
+
+	router.GET("/expenses/stream", h.StreamExpenses, Auth)
+	return nil
+}
+
+// StreamExpenses pushes expense events of the caller's tenant as
+// Server-Sent Events, with the position of the event as its ID. A client
+// that reconnects with Last-Event-ID first receives the events it missed
+// from the expense_events table.
+func (h *streamHandler) StreamExpenses(c echo.Context) error {
+	var last expense.Position
+	if v := c.Request().Header.Get("Last-Event-ID"); v != "" {
+		p, err := expense.ParsePosition(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"code":    http.StatusBadRequest,
+				"message": "invalid Last-Event-ID",
+			})
+		}
+		last = p
+	}
+
+	ctx := c.Request().Context()
+	tenant := expense.TenantFromContext(ctx)
+	sub := h.broker.Subscribe(tenant)
+	defer h.broker.Unsubscribe(sub)
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	// Events held back by running transactions are published by the broker
+	// once they end, as the subscription was taken before.
+	if last != (expense.Position{}) {
+		for {
+			evs, held, err := h.expenseSvc.Events(ctx, last, 100)
+			if err != nil {
+				return nil
+			}
+			for _, ev := range evs {
+				last = ev.Position()
+				if ev.TenantID != tenant {
+					continue
+				}
+				if err := writeEvent(res, ev); err != nil {
+					return nil
+				}
+			}
+			if held || len(evs) < 100 {
+				break
+			}
+		}
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case ev, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+			if !last.Less(ev.Position()) {
+				continue
+			}
+			if err := writeEvent(res, ev); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func writeEvent(res *echo.Response, ev expense.Event) error {
+	if _, err := fmt.Fprintf(res, "id: %s\nevent: %s\ndata: %s\n\n", ev.Position(), ev.Type, ev.Data); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerStreamExpenses(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := echo.New()
+	svc := expense.NewService(db)
+	h := &streamHandler{expenseSvc: svc, broker: stream.NewBroker(svc), heartbeat: 10 * time.Millisecond}
+
+	t.Run("StreamExpenses() resumes from Last-Event-ID", func(t *testing.T) {
+		columns := []string{"id", "type", "expense_id", "tenant_id", "data", "created_at", "tx_id", "ended"}
+		mock.ExpectQuery("SELECT (.+) FROM expense_events").WithArgs(100, 3).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(4, expense.EventCreated, 1, "acme", []byte(`{"id":1}`), time.Now(), 100, true).
+				AddRow(5, expense.EventCreated, 2, "other", []byte(`{"id":2}`), time.Now(), 101, true))
+
+		ctx, cancel := context.WithTimeout(expense.WithTenant(context.Background(), "acme"), 50*time.Millisecond)
+		defer cancel()
+		req := httptest.NewRequest(http.MethodGet, "/expenses/stream", nil).WithContext(ctx)
+		req.Header.Set("Last-Event-ID", "100-3")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := h.StreamExpenses(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "text/event-stream", rec.Header().Get(echo.HeaderContentType))
+			body := rec.Body.String()
+			assert.Contains(t, body, "id: 100-4\nevent: expense.created\ndata: {\"id\":1}\n\n")
+			assert.NotContains(t, body, "id: 101-5")
+			assert.Contains(t, body, ": heartbeat\n\n")
+		}
+	})
+
+	t.Run("StreamExpenses() returns invalid Last-Event-ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/expenses/stream", nil)
+		req.Header.Set("Last-Event-ID", "A")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := h.StreamExpenses(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+}
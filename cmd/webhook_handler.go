@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/webhook"
+)
+
+type webhookHandler struct {
+	webhookSvc *webhook.Service
+}
+
+func NewWebhookHandler(router *echo.Echo, svc *webhook.Service) error {
+	if router == nil || svc == nil {
+		return errors.New("invalid argument")
+	}
+	h := webhookHandler{
+		webhookSvc: svc,
+	}
+
+	router.GET("/webhooks", h.ListWebhooks, Auth)
+	router.POST("/webhooks", h.SaveWebhook, Auth)
+	router.DELETE("/webhooks/:id", h.DeleteWebhook, Auth)
+	router.GET("/webhooks/:id/deliveries", h.ListDeliveries, Auth)
+	router.POST("/webhooks/deliveries/:id/replay", h.ReplayDelivery, Auth)
+	return nil
+}
+
+func (h *webhookHandler) SaveWebhook(c echo.Context) error {
+	var sub webhook.Subscription
+	if err := c.Bind(&sub); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid request body",
+		})
+	}
+	if err := sub.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+	}
+
+	ctx := c.Request().Context()
+	s, err := h.webhookSvc.Save(ctx, &sub)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusCreated, s)
+}
+
+func (h *webhookHandler) ListWebhooks(c echo.Context) error {
+	ctx := c.Request().Context()
+	subs, err := h.webhookSvc.List(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, subs)
+}
+
+func (h *webhookHandler) DeleteWebhook(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	err = h.webhookSvc.Delete(ctx, id)
+	if errors.Is(err, webhook.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": errors.Unwrap(err).Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *webhookHandler) ListDeliveries(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	ds, err := h.webhookSvc.Deliveries(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.JSON(http.StatusOK, ds)
+}
+
+func (h *webhookHandler) ReplayDelivery(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"code":    http.StatusBadRequest,
+			"message": "invalid params",
+		})
+	}
+
+	ctx := c.Request().Context()
+	err = h.webhookSvc.Replay(ctx, id)
+	if errors.Is(err, webhook.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"code":    http.StatusNotFound,
+			"message": errors.Unwrap(err).Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"code":    http.StatusInternalServerError,
+			"message": "Internal Server Error",
+		})
+	}
+	return c.NoContent(http.StatusAccepted)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/phuangpheth/assessment/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerSaveWebhook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := echo.New()
+	h := &webhookHandler{webhook.NewService(db)}
+
+	t.Run("SaveWebhook()", func(t *testing.T) {
+		body := `{"url":"https://example.com/hooks","secret":"s3cr3t","event_types":["expense.created"]}`
+		mock.ExpectQuery("INSERT INTO webhook_subscriptions (.+) RETURNING").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)))
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"id":1,"url":"https://example.com/hooks","secret":"s3cr3t","event_types":["expense.created"],"created_at":"2026-10-18T00:00:00Z"}`
+
+		err := h.SaveWebhook(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("SaveWebhook() returns invalid url", func(t *testing.T) {
+		body := `{"url":"ftp://example.com","event_types":["expense.created"]}`
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		want := `{"code":400,"message":"url must be an absolute http or https URL"}`
+
+		err := h.SaveWebhook(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
+
+func TestHandlerReplayDelivery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := echo.New()
+	h := &webhookHandler{webhook.NewService(db)}
+
+	t.Run("ReplayDelivery() returns not found", func(t *testing.T) {
+		mock.ExpectExec("UPDATE webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/deliveries/9/replay", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("9")
+		want := `{"code":404,"message":"not found"}`
+
+		err := h.ReplayDelivery(c)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, want, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
@@ -0,0 +1,247 @@
+// Package currency stores exchange rates and converts amounts between
+// currencies.
+package currency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// DateLayout is the layout of rate dates.
+const DateLayout = "2006-01-02"
+
+// ErrRateNotFound is returned when no rate is known on or before the date.
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// ErrCodeInvalid is returned when a currency is not a three-letter code.
+var ErrCodeInvalid = errors.New("currency must be a three-letter ISO 4217 code")
+
+// ErrRateInvalid is returned when the rate is less than or equal to zero.
+var ErrRateInvalid = errors.New("rate must be greater than zero")
+
+// ErrDateEmpty is returned when the rate has no date.
+var ErrDateEmpty = errors.New("empty date")
+
+var code = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidCode reports whether c is a three-letter upper-case currency code.
+func ValidCode(c string) bool {
+	return code.MatchString(c)
+}
+
+// Rate is the price of one unit of Base in Quote on Date.
+type Rate struct {
+	Date  time.Time `json:"date"`
+	Base  string    `json:"base"`
+	Quote string    `json:"quote"`
+	Rate  float64   `json:"rate"`
+}
+
+func (r *Rate) Validate() error {
+	if r.Date.IsZero() {
+		return ErrDateEmpty
+	}
+	if !ValidCode(r.Base) || !ValidCode(r.Quote) {
+		return ErrCodeInvalid
+	}
+	if r.Rate <= 0 {
+		return ErrRateInvalid
+	}
+	return nil
+}
+
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// Import stores rates, replacing existing rates for the same date and
+// currency pair. All rates are stored in one transaction.
+func (s *Service) Import(ctx context.Context, rates []Rate) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range rates {
+		if err := upsertRate(ctx, tx, &rates[i]); err != nil {
+			return fmt.Errorf("upsertRate(%s/%s): %w", rates[i].Base, rates[i].Quote, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Rate returns the most recent rate from base to quote on or before on. An
+// inverse rate is used when only quote to base is known.
+func (s *Service) Rate(ctx context.Context, base, quote string, on time.Time) (float64, error) {
+	if base == quote {
+		return 1, nil
+	}
+	rate, err := getRate(ctx, s.db, base, quote, on)
+	if errors.Is(err, ErrRateNotFound) {
+		var inverse float64
+		if inverse, err = getRate(ctx, s.db, quote, base, on); err == nil {
+			rate = 1 / inverse
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("getRate(%s/%s, %s): %w", base, quote, on.Format(DateLayout), err)
+	}
+	return rate, nil
+}
+
+// Convert converts amount from one currency to another at the rate of on.
+func (s *Service) Convert(ctx context.Context, amount float64, from, to string, on time.Time) (float64, error) {
+	rate, err := s.Rate(ctx, from, to, on)
+	if err != nil {
+		return 0, err
+	}
+	return amount * rate, nil
+}
+
+// List returns the rates of the pair base/quote, newest first. Empty
+// arguments match every currency.
+func (s *Service) List(ctx context.Context, base, quote string) ([]Rate, error) {
+	rs, err := listRates(ctx, s.db, base, quote)
+	if err != nil {
+		return nil, fmt.Errorf("listRates(): %w", err)
+	}
+	return rs, nil
+}
+
+// ReadCSV reads rates from CSV with a date,base,quote,rate header. Dates use
+// DateLayout.
+func ReadCSV(r io.Reader) ([]Rate, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, h := range []string{"date", "base", "quote", "rate"} {
+		if _, ok := cols[h]; !ok {
+			return nil, fmt.Errorf("missing %s column", h)
+		}
+	}
+
+	var rates []Rate
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		date, err := time.Parse(DateLayout, rec[cols["date"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date", line)
+		}
+		rate, err := strconv.ParseFloat(rec[cols["rate"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rate", line)
+		}
+		r := Rate{
+			Date:  date,
+			Base:  strings.ToUpper(rec[cols["base"]]),
+			Quote: strings.ToUpper(rec[cols["quote"]]),
+			Rate:  rate,
+		}
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, r)
+	}
+}
+
+func upsertRate(ctx context.Context, tx *sql.Tx, r *Rate) error {
+	query, args, err := sq.Insert("exchange_rates").
+		Columns("date", "base", "quote", "rate").
+		Values(r.Date, r.Base, r.Quote, r.Rate).
+		Suffix("ON CONFLICT (date, base, quote) DO UPDATE SET rate = EXCLUDED.rate").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func getRate(ctx context.Context, db *sql.DB, base, quote string, on time.Time) (float64, error) {
+	query, args, err := sq.Select("rate").
+		From("exchange_rates").
+		Where(sq.Eq{"base": base, "quote": quote}).
+		Where(sq.LtOrEq{"date": on}).
+		OrderBy("date DESC").
+		Limit(1).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var rate float64
+	err = db.QueryRowContext(ctx, query, args...).Scan(&rate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrRateNotFound
+	}
+	return rate, err
+}
+
+func listRates(ctx context.Context, db *sql.DB, base, quote string) ([]Rate, error) {
+	qb := sq.Select("date", "base", "quote", "rate").
+		From("exchange_rates").
+		OrderBy("date DESC", "base", "quote").
+		PlaceholderFormat(sq.Dollar)
+	if base != "" {
+		qb = qb.Where(sq.Eq{"base": base})
+	}
+	if quote != "" {
+		qb = qb.Where(sq.Eq{"quote": quote})
+	}
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rs := make([]Rate, 0)
+	for rows.Next() {
+		var r Rate
+		if err := rows.Scan(&r.Date, &r.Base, &r.Quote, &r.Rate); err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
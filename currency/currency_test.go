@@ -0,0 +1,155 @@
+package currency
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRateValidate(t *testing.T) {
+	t.Run("ErrDateEmpty", func(t *testing.T) {
+		r := &Rate{Base: "USD", Quote: "THB", Rate: 36}
+
+		assert.ErrorIs(t, r.Validate(), ErrDateEmpty)
+	})
+
+	t.Run("ErrCodeInvalid", func(t *testing.T) {
+		r := &Rate{Date: date(2026, 10, 1), Base: "usd", Quote: "THB", Rate: 36}
+
+		assert.ErrorIs(t, r.Validate(), ErrCodeInvalid)
+	})
+
+	t.Run("ErrRateInvalid", func(t *testing.T) {
+		r := &Rate{Date: date(2026, 10, 1), Base: "USD", Quote: "THB"}
+
+		assert.ErrorIs(t, r.Validate(), ErrRateInvalid)
+	})
+}
+
+func TestReadCSV(t *testing.T) {
+	t.Run("ReadCSV() reads rates", func(t *testing.T) {
+		in := "date,base,quote,rate\n2026-10-01,usd,THB,36.5\n2026-10-01,THB,LAK,620\n"
+
+		rates, err := ReadCSV(strings.NewReader(in))
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []Rate{
+				{Date: date(2026, 10, 1), Base: "USD", Quote: "THB", Rate: 36.5},
+				{Date: date(2026, 10, 1), Base: "THB", Quote: "LAK", Rate: 620},
+			}, rates)
+		}
+	})
+
+	t.Run("ReadCSV() returns the line of an invalid rate", func(t *testing.T) {
+		in := "date,base,quote,rate\n2026-10-01,USD,THB,36.5\n2026-10-02,USD,THB,-1\n"
+
+		_, err := ReadCSV(strings.NewReader(in))
+
+		assert.EqualError(t, err, "line 3: "+ErrRateInvalid.Error())
+	})
+
+	t.Run("ReadCSV() requires every column", func(t *testing.T) {
+		_, err := ReadCSV(strings.NewReader("date,base,rate\n"))
+
+		assert.EqualError(t, err, "missing quote column")
+	})
+}
+
+func TestServiceConvert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	ctx := context.Background()
+	on := date(2026, 10, 18)
+
+	t.Run("Convert() uses the latest rate on or before the date", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT rate FROM exchange_rates WHERE (.+) ORDER BY date DESC LIMIT 1`).
+			WithArgs("USD", "THB", on).
+			WillReturnRows(sqlmock.NewRows([]string{"rate"}).AddRow(36.5))
+
+		got, err := svc.Convert(ctx, 10, "USD", "THB", on)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 365.0, got)
+		}
+	})
+
+	t.Run("Convert() falls back to the inverse rate", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT rate FROM exchange_rates`).
+			WithArgs("LAK", "THB", on).
+			WillReturnRows(sqlmock.NewRows([]string{"rate"}))
+		mock.ExpectQuery(`SELECT rate FROM exchange_rates`).
+			WithArgs("THB", "LAK", on).
+			WillReturnRows(sqlmock.NewRows([]string{"rate"}).AddRow(625))
+
+		got, err := svc.Convert(ctx, 50000, "LAK", "THB", on)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 80.0, got)
+		}
+	})
+
+	t.Run("Convert() returns ErrRateNotFound", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT rate FROM exchange_rates`).
+			WithArgs("EUR", "THB", on).
+			WillReturnRows(sqlmock.NewRows([]string{"rate"}))
+		mock.ExpectQuery(`SELECT rate FROM exchange_rates`).
+			WithArgs("THB", "EUR", on).
+			WillReturnRows(sqlmock.NewRows([]string{"rate"}))
+
+		_, err := svc.Convert(ctx, 10, "EUR", "THB", on)
+
+		assert.ErrorIs(t, err, ErrRateNotFound)
+	})
+
+	t.Run("Convert() does not query the same currency", func(t *testing.T) {
+		got, err := svc.Convert(ctx, 10, "THB", "THB", on)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 10.0, got)
+		}
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceImport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+
+	t.Run("Import() upserts every rate in one transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO exchange_rates (.+) ON CONFLICT \(date, base, quote\) DO UPDATE`).
+			WithArgs(date(2026, 10, 1), "USD", "THB", 36.5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO exchange_rates`).
+			WithArgs(date(2026, 10, 1), "THB", "LAK", 620.0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := svc.Import(context.Background(), []Rate{
+			{Date: date(2026, 10, 1), Base: "USD", Quote: "THB", Rate: 36.5},
+			{Date: date(2026, 10, 1), Base: "THB", Quote: "LAK", Rate: 620},
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
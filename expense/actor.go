@@ -0,0 +1,25 @@
+package expense
+
+import "context"
+
+// Actor is the authenticated user a request acts on behalf of.
+type Actor struct {
+	ID string
+	// Approver reports whether the actor may approve, reject and reimburse
+	// expenses.
+	Approver bool
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx that carries the actor of the caller.
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// ActorFromContext returns the actor stored in ctx by WithActor, or the zero
+// Actor for anonymous callers.
+func ActorFromContext(ctx context.Context) Actor {
+	a, _ := ctx.Value(actorKey{}).(Actor)
+	return a
+}
@@ -0,0 +1,247 @@
+package expense
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/phuangpheth/assessment/txn"
+)
+
+// ErrTransitionInvalid is returned when an action is not allowed from the
+// current status of the expense.
+var ErrTransitionInvalid = errors.New("action is not allowed in the current status")
+
+// ErrActionInvalid is returned when the action is unknown.
+var ErrActionInvalid = errors.New("action must be one of submit, approve, reject or reimburse")
+
+// ErrForbidden is returned when the actor may not perform the action.
+var ErrForbidden = errors.New("not allowed to perform this action")
+
+// ErrReasonEmpty is returned when an expense is rejected without a reason.
+var ErrReasonEmpty = errors.New("rejection reason must not be empty")
+
+// ErrNotEditable is returned when an expense is updated after it has been
+// submitted.
+var ErrNotEditable = errors.New("expense can only be updated while it is a draft")
+
+// Status is the state of an expense in the approval workflow.
+type Status string
+
+const (
+	StatusDraft      Status = "draft"
+	StatusSubmitted  Status = "submitted"
+	StatusApproved   Status = "approved"
+	StatusRejected   Status = "rejected"
+	StatusReimbursed Status = "reimbursed"
+)
+
+// Action moves an expense from one Status to another.
+type Action string
+
+const (
+	ActionSubmit    Action = "submit"
+	ActionApprove   Action = "approve"
+	ActionReject    Action = "reject"
+	ActionReimburse Action = "reimburse"
+)
+
+// transitions is the approval state machine: the status each action leads
+// to, per status it is allowed from.
+var transitions = map[Status]map[Action]Status{
+	StatusDraft: {
+		ActionSubmit: StatusSubmitted,
+	},
+	StatusSubmitted: {
+		ActionApprove: StatusApproved,
+		ActionReject:  StatusRejected,
+	},
+	StatusApproved: {
+		ActionReimburse: StatusReimbursed,
+	},
+}
+
+// Next returns the status that action leads to from s, or
+// ErrTransitionInvalid.
+func (s Status) Next(action Action) (Status, error) {
+	switch action {
+	case ActionSubmit, ActionApprove, ActionReject, ActionReimburse:
+	default:
+		return "", ErrActionInvalid
+	}
+	next, ok := transitions[s][action]
+	if !ok {
+		return "", ErrTransitionInvalid
+	}
+	return next, nil
+}
+
+// Transition is the audit entry of one status change.
+type Transition struct {
+	ID        int64     `json:"id"`
+	ExpenseID int64     `json:"expense_id"`
+	Action    Action    `json:"action"`
+	From      Status    `json:"from"`
+	To        Status    `json:"to"`
+	Actor     string    `json:"actor"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Transition applies action to the expense on behalf of the actor in ctx.
+// Anyone may submit a draft; only approvers may approve, reject or
+// reimburse, and never an expense they submitted themselves. Each change is
+// recorded as a Transition and an EventStatusChanged.
+func (s *Service) Transition(ctx context.Context, id int64, action Action, reason string) (*Expense, error) {
+	actor := ActorFromContext(ctx)
+	if action == ActionReject && reason == "" {
+		return nil, ErrReasonEmpty
+	}
+
+	var exp *Expense
+	err := s.inTx(ctx, func(db querier) error {
+		var err error
+		exp, err = lockExpenseByID(ctx, db, id)
+		if err != nil {
+			return fmt.Errorf("lockExpenseByID(%d): %w", id, err)
+		}
+		next, err := exp.Status.Next(action)
+		if err != nil {
+			return err
+		}
+		if action != ActionSubmit {
+			if !actor.Approver {
+				return ErrForbidden
+			}
+			submitter, err := getSubmitter(ctx, db, id)
+			if err != nil {
+				return fmt.Errorf("getSubmitter(%d): %w", id, err)
+			}
+			if submitter == actor.ID {
+				return ErrForbidden
+			}
+		}
+
+		t := &Transition{
+			ExpenseID: id,
+			Action:    action,
+			From:      exp.Status,
+			To:        next,
+			Actor:     actor.ID,
+			Reason:    reason,
+		}
+		if err := createTransition(ctx, db, t); err != nil {
+			return fmt.Errorf("createTransition(): %w", err)
+		}
+		exp.Status = next
+		if err := updateStatus(ctx, db, id, next); err != nil {
+			return fmt.Errorf("updateStatus(): %w", err)
+		}
+		if err := createEvent(ctx, db, EventStatusChanged, exp); err != nil {
+			return fmt.Errorf("createEvent(): %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	txn.AfterCommit(ctx, func() { s.invalidate(id) })
+	return exp, nil
+}
+
+// Transitions returns the audit trail of the expense, oldest first.
+func (s *Service) Transitions(ctx context.Context, id int64) ([]Transition, error) {
+	var ts []Transition
+	err := s.read(ctx, func(ctx context.Context, db querier) (err error) {
+		ts, err = listTransitions(ctx, db, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listTransitions(%d): %w", id, err)
+	}
+	return ts, nil
+}
+
+func updateStatus(ctx context.Context, db querier, id int64, status Status) error {
+	query, args, err := sq.Update("expenses").
+		Set("status", status).
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"id": id}).
+		Where(inTenant(ctx)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func createTransition(ctx context.Context, db querier, t *Transition) error {
+	query, args, err := sq.Insert("expense_transitions").
+		Columns("expense_id", "action", "from_status", "to_status", "actor", "reason").
+		Values(t.ExpenseID, t.Action, t.From, t.To, t.Actor, t.Reason).
+		Suffix("RETURNING id, created_at").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	return db.QueryRowContext(ctx, query, args...).Scan(&t.ID, &t.CreatedAt)
+}
+
+// getSubmitter returns the actor of the latest submission of the expense.
+func getSubmitter(ctx context.Context, db querier, id int64) (string, error) {
+	query, args, err := sq.Select("actor").
+		From("expense_transitions").
+		Where(sq.Eq{"expense_id": id, "action": ActionSubmit}).
+		OrderBy("id DESC").
+		Limit(1).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return "", err
+	}
+
+	var actor string
+	err = db.QueryRowContext(ctx, query, args...).Scan(&actor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return actor, err
+}
+
+func listTransitions(ctx context.Context, db querier, id int64) ([]Transition, error) {
+	query, args, err := sq.Select("id", "expense_id", "action", "from_status", "to_status", "actor", "reason", "created_at").
+		From("expense_transitions").
+		Where(sq.Eq{"expense_id": id}).
+		Where(ofTenant(ctx)).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts := make([]Transition, 0)
+	for rows.Next() {
+		var t Transition
+		if err := rows.Scan(&t.ID, &t.ExpenseID, &t.Action, &t.From, &t.To, &t.Actor, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
@@ -0,0 +1,157 @@
+package expense
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusNext(t *testing.T) {
+	tests := []struct {
+		from   Status
+		action Action
+		want   Status
+		err    error
+	}{
+		{StatusDraft, ActionSubmit, StatusSubmitted, nil},
+		{StatusSubmitted, ActionApprove, StatusApproved, nil},
+		{StatusSubmitted, ActionReject, StatusRejected, nil},
+		{StatusApproved, ActionReimburse, StatusReimbursed, nil},
+		{StatusDraft, ActionApprove, "", ErrTransitionInvalid},
+		{StatusSubmitted, ActionSubmit, "", ErrTransitionInvalid},
+		{StatusRejected, ActionReimburse, "", ErrTransitionInvalid},
+		{StatusReimbursed, ActionReject, "", ErrTransitionInvalid},
+		{StatusDraft, "cancel", "", ErrActionInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" "+string(tt.action), func(t *testing.T) {
+			got, err := tt.from.Next(tt.action)
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestServiceTransition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	row := func(status Status) *sqlmock.Rows {
+		return sqlmock.NewRows(expenseColumns).
+			AddRow(1, 75, "Hotel", "", pq.Array([]string{"travel"}), "THB", time.Now(), 75, nil, status, nil)
+	}
+	approver := WithActor(context.Background(), Actor{ID: "bo", Approver: true})
+
+	t.Run("Transition() records the submitter", func(t *testing.T) {
+		ctx := WithActor(context.Background(), Actor{ID: "ana"})
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM expenses WHERE (.+) FOR UPDATE`).WithArgs(1, "").WillReturnRows(row(StatusDraft))
+		mock.ExpectQuery(`INSERT INTO expense_transitions (.+) RETURNING id, created_at`).
+			WithArgs(1, ActionSubmit, StatusDraft, StatusSubmitted, "ana", "").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+		mock.ExpectExec(`UPDATE expenses SET status`).WithArgs(StatusSubmitted, 1, "").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO expense_events`).
+			WithArgs(EventStatusChanged, 1, "", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		e, err := svc.Transition(ctx, 1, ActionSubmit, "")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, StatusSubmitted, e.Status)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Transition() forbids approvers from approving their own expense", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnRows(row(StatusSubmitted))
+		mock.ExpectQuery(`SELECT actor FROM expense_transitions`).
+			WithArgs(ActionSubmit, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"actor"}).AddRow("bo"))
+		mock.ExpectRollback()
+
+		_, err := svc.Transition(approver, 1, ActionApprove, "")
+
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Transition() forbids submitters from approving", func(t *testing.T) {
+		ctx := WithActor(context.Background(), Actor{ID: "chai"})
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnRows(row(StatusSubmitted))
+		mock.ExpectRollback()
+
+		_, err := svc.Transition(ctx, 1, ActionApprove, "")
+
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Transition() rejects with a reason", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnRows(row(StatusSubmitted))
+		mock.ExpectQuery(`SELECT actor FROM expense_transitions`).
+			WillReturnRows(sqlmock.NewRows([]string{"actor"}).AddRow("ana"))
+		mock.ExpectQuery(`INSERT INTO expense_transitions`).
+			WithArgs(1, ActionReject, StatusSubmitted, StatusRejected, "bo", "no receipt").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, time.Now()))
+		mock.ExpectExec(`UPDATE expenses SET status`).WithArgs(StatusRejected, 1, "").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		e, err := svc.Transition(approver, 1, ActionReject, "no receipt")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, StatusRejected, e.Status)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Transition() returns ErrReasonEmpty", func(t *testing.T) {
+		_, err := svc.Transition(approver, 1, ActionReject, "")
+
+		assert.ErrorIs(t, err, ErrReasonEmpty)
+	})
+
+	t.Run("Transition() returns ErrTransitionInvalid", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnRows(row(StatusRejected))
+		mock.ExpectRollback()
+
+		_, err := svc.Transition(approver, 1, ActionReimburse, "")
+
+		assert.ErrorIs(t, err, ErrTransitionInvalid)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestServiceUpdateNotEditable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM expenses WHERE (.+) FOR UPDATE`).WithArgs(1, "").
+		WillReturnRows(sqlmock.NewRows(expenseColumns).
+			AddRow(1, 75, "Hotel", "", pq.Array([]string{}), "THB", time.Now(), 75, nil, StatusSubmitted, nil))
+	mock.ExpectRollback()
+
+	_, err = svc.Update(context.Background(), &Expense{ID: 1, Amount: 80, Title: "Hotel"})
+
+	assert.ErrorIs(t, err, ErrNotEditable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,168 @@
+package expense
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is an LRU cache of expenses by tenant and ID in front of
+// Service.GetByID. The zero value is an empty cache that never stores
+// anything.
+type Cache struct {
+	mu     sync.Mutex
+	size   int
+	ll     list.List
+	items  map[int64]*list.Element
+	fills  map[int64]*fill
+	hits   uint64
+	misses uint64
+}
+
+// CacheStats counts the lookups of a Cache.
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// HitRatio returns the share of lookups served from the cache.
+func (s CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewCache returns a cache that holds up to size expenses.
+func NewCache(size int) *Cache {
+	return &Cache{
+		size:  size,
+		items: make(map[int64]*list.Element),
+		fills: make(map[int64]*fill),
+	}
+}
+
+// fill tracks the reads of an expense that missed the cache. Invalidate
+// bumps its generation, so that a read that started before a change does
+// not store the expense as it was.
+type fill struct {
+	gen     uint64
+	readers int
+}
+
+// entry is a cached expense of a tenant. IDs are unique across tenants, so
+// entries are indexed by ID alone and the tenant is checked on lookup.
+type entry struct {
+	tenant  string
+	expense *Expense
+}
+
+// get returns a copy of the cached expense of tenant. On a miss it starts a
+// fill of the expense and returns its generation, to be passed to done.
+func (c *Cache) get(tenant string, id int64) (*Expense, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[id]
+	if !ok || el.Value.(*entry).tenant != tenant {
+		c.misses++
+		if c.fills == nil {
+			return nil, 0, false
+		}
+		f, ok := c.fills[id]
+		if !ok {
+			f = &fill{}
+			c.fills[id] = f
+		}
+		f.readers++
+		return nil, f.gen, false
+	}
+	c.hits++
+	c.ll.MoveToFront(el)
+	return clone(el.Value.(*entry).expense), 0, true
+}
+
+// done ends a fill of the expense id started by get at generation gen. It
+// stores e of tenant unless e is nil or the expense was invalidated since.
+func (c *Cache) done(tenant string, id int64, e *Expense, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, ok := c.fills[id]
+	if !ok {
+		return
+	}
+	if f.readers--; f.readers == 0 {
+		delete(c.fills, id)
+	}
+	if f.gen == gen && e != nil {
+		c.put(tenant, e)
+	}
+}
+
+// put stores a copy of e of tenant, evicting the least recently used expense
+// when the cache is full. c.mu must be held.
+func (c *Cache) put(tenant string, e *Expense) {
+	if c.size <= 0 {
+		return
+	}
+	en := &entry{tenant: tenant, expense: clone(e)}
+	if el, ok := c.items[e.ID]; ok {
+		el.Value = en
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[e.ID] = c.ll.PushFront(en)
+	if c.ll.Len() > c.size {
+		last := c.ll.Back()
+		c.ll.Remove(last)
+		delete(c.items, last.Value.(*entry).expense.ID)
+	}
+}
+
+// clone copies e, so that callers cannot change the cached expense.
+func clone(e *Expense) *Expense {
+	cp := *e
+	if e.Tags != nil {
+		cp.Tags = append(make([]string, 0, len(e.Tags)), e.Tags...)
+	}
+	if e.Split != nil {
+		split := *e.Split
+		if e.Split.Participants != nil {
+			split.Participants = append(make([]Participant, 0, len(e.Split.Participants)), e.Split.Participants...)
+		}
+		cp.Split = &split
+	}
+	return &cp
+}
+
+// Invalidate drops the expense from the cache.
+func (c *Cache) Invalidate(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[id]; ok {
+		c.ll.Remove(el)
+		delete(c.items, id)
+	}
+	if f, ok := c.fills[id]; ok {
+		f.gen++
+	}
+}
+
+// ExpenseEvent invalidates the expense the event is about, so that changes
+// made by other replicas are seen once their event is published.
+func (c *Cache) ExpenseEvent(ev Event) {
+	c.Invalidate(ev.ExpenseID)
+}
+
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: c.ll.Len(),
+	}
+}
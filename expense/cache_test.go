@@ -0,0 +1,182 @@
+package expense
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	c := NewCache(2)
+	svc := NewService(db)
+	svc.SetCache(c)
+	ctx := context.Background()
+	read := func(id int64, tenant string, tags ...string) {
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(id, tenant).
+			WillReturnRows(sqlmock.NewRows(expenseColumns).
+				AddRow(id, 75, "Tea", "", pq.Array(tags), "THB", time.Now(), 75, nil, "draft", time.Now()))
+	}
+
+	read(1, "", "food")
+	read(2, "")
+	read(3, "")
+	_, _ = svc.GetByID(ctx, 1)
+	_, _ = svc.GetByID(ctx, 2)
+	_, _ = svc.GetByID(ctx, 1)
+	_, _ = svc.GetByID(ctx, 3)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	e, err := svc.GetByID(ctx, 1)
+	if assert.NoError(t, err) {
+		e.Tags[0] = "changed"
+		e, _ = svc.GetByID(ctx, 1)
+		assert.Equal(t, []string{"food"}, e.Tags, "callers get a copy")
+	}
+
+	mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "acme").WillReturnRows(sqlmock.NewRows(expenseColumns))
+	_, err = svc.GetByID(WithTenant(ctx, "acme"), 1)
+	assert.ErrorIs(t, err, ErrNotFound, "expenses of another tenant are not served")
+
+	read(2, "")
+	_, err = svc.GetByID(ctx, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "least recently used expense is evicted")
+
+	c.ExpenseEvent(Event{ExpenseID: 1})
+	read(1, "", "food")
+	_, err = svc.GetByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "a changed expense is read again")
+
+	s := c.Stats()
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 6, Entries: 2}, s)
+	assert.Equal(t, 1.0/3, s.HitRatio())
+}
+
+func TestCacheFill(t *testing.T) {
+	c := NewCache(2)
+
+	_, gen, _ := c.get("", 1)
+	c.done("", 1, &Expense{ID: 1}, gen)
+	_, _, ok := c.get("", 1)
+	assert.True(t, ok, "a fill is stored")
+
+	_, gen, _ = c.get("", 2)
+	c.Invalidate(2)
+	c.done("", 2, &Expense{ID: 2}, gen)
+	_, gen, ok = c.get("", 2)
+	assert.False(t, ok, "a fill started before Invalidate is dropped")
+
+	c.done("", 2, nil, gen)
+	assert.Empty(t, c.fills, "done ends the fill")
+}
+
+func TestServiceGetByIDCache(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	svc.SetCache(NewCache(10))
+	ctx := context.Background()
+	row := func(title string) *sqlmock.Rows {
+		return sqlmock.NewRows(expenseColumns).
+			AddRow(1, 75, title, "", pq.Array([]string{}), "THB", time.Now(), 75, nil, "draft", time.Now())
+	}
+
+	t.Run("GetByID() reads through the cache", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnRows(row("Tea"))
+
+		first, err := svc.GetByID(ctx, 1)
+		assert.NoError(t, err)
+		second, err := svc.GetByID(ctx, 1)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, first, second)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update() invalidates the cached expense", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnRows(row("Tea"))
+		mock.ExpectExec(`UPDATE expenses`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnRows(row("Green tea"))
+
+		_, err := svc.Update(ctx, &Expense{ID: 1, Amount: 75, Title: "Green tea"})
+		assert.NoError(t, err)
+		e, err := svc.GetByID(ctx, 1)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Green tea", e.Title)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByID() does not cache what it read before an Update()", func(t *testing.T) {
+		svc.invalidate(1)
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnRows(row("Green tea"))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnRows(row("Green tea"))
+		mock.ExpectExec(`UPDATE expenses`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnRows(row("Black tea"))
+
+		// The miss of GetByID reads the expense, then Update commits before
+		// the read fills the cache.
+		_, gen, _ := svc.cache.get("", 1)
+		stale, err := getExpenseByID(ctx, db, 1)
+		assert.NoError(t, err)
+		_, err = svc.Update(ctx, &Expense{ID: 1, Amount: 75, Title: "Black tea"})
+		assert.NoError(t, err)
+		svc.cache.done("", 1, stale, gen)
+		e, err := svc.GetByID(ctx, 1)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Black tea", e.Title)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByID() misses after Erase()", func(t *testing.T) {
+		acme := WithTenant(ctx, "acme")
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "acme").WillReturnRows(row("Tea"))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, status FROM expenses WHERE (.+) FOR UPDATE`).
+			WithArgs("ana", "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, "draft"))
+		mock.ExpectExec(`DELETE FROM expense_events`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE expense_transitions`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE expenses`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DELETE FROM expenses`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO expense_events`).
+			WithArgs(EventDeleted, 1, "acme", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "acme").WillReturnRows(sqlmock.NewRows(expenseColumns))
+
+		_, err := svc.GetByID(acme, 1)
+		assert.NoError(t, err)
+		_, err = svc.Erase(acme, Owner{Tenant: "acme", ID: "ana"})
+		assert.NoError(t, err)
+		_, err = svc.GetByID(acme, 1)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,295 @@
+package expense
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ErrDuplicate is returned by Save when the expense is likely a duplicate of
+// an existing one. The error is a *DuplicateError.
+var ErrDuplicate = errors.New("likely duplicate of an existing expense")
+
+// DuplicateError lists the existing expenses a new expense likely
+// duplicates.
+type DuplicateError struct {
+	Matches []Match
+}
+
+func (e *DuplicateError) Error() string {
+	return ErrDuplicate.Error()
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicate
+}
+
+// Match is an existing expense that is likely a duplicate.
+type Match struct {
+	Expense    Expense `json:"expense"`
+	Similarity float64 `json:"similarity"`
+}
+
+// DuplicatePair is two expenses that are likely duplicates of each other.
+type DuplicatePair struct {
+	Expense    Expense `json:"expense"`
+	Duplicate  Expense `json:"duplicate"`
+	Similarity float64 `json:"similarity"`
+}
+
+// DuplicateCheck decides which expenses are likely duplicates: those with
+// the same amount and currency, spent within WindowDays of each other, whose
+// titles have a Similarity of at least MinSimilarity.
+type DuplicateCheck struct {
+	WindowDays    int
+	MinSimilarity float64
+}
+
+// DefaultDuplicateCheck is the check of the duplicates report when the
+// service has none.
+var DefaultDuplicateCheck = DuplicateCheck{WindowDays: 3, MinSimilarity: 0.75}
+
+// maxDuplicatePairs bounds the candidate pairs a duplicates report compares.
+const maxDuplicatePairs = 1000
+
+type duplicatesKey struct{}
+
+// AllowDuplicates returns a copy of ctx in which Save does not check for
+// duplicates.
+func AllowDuplicates(ctx context.Context) context.Context {
+	return context.WithValue(ctx, duplicatesKey{}, true)
+}
+
+// DuplicatesAllowed reports whether ctx comes from AllowDuplicates.
+func DuplicatesAllowed(ctx context.Context) bool {
+	ok, _ := ctx.Value(duplicatesKey{}).(bool)
+	return ok
+}
+
+// SetDuplicateCheck makes Save reject likely duplicates with a
+// *DuplicateError, unless ctx comes from AllowDuplicates.
+func (s *Service) SetDuplicateCheck(c DuplicateCheck) {
+	s.dupCheck = &c
+}
+
+// FindDuplicates returns the existing expenses e is likely a duplicate of,
+// most similar first. e must have its currency and spend date set. Without a
+// duplicate check it returns nothing.
+func (s *Service) FindDuplicates(ctx context.Context, e *Expense) ([]Match, error) {
+	if s.dupCheck == nil {
+		return nil, nil
+	}
+	c := *s.dupCheck
+	var candidates []Expense
+	err := s.read(ctx, func(ctx context.Context, db querier) (err error) {
+		candidates, err = listDuplicateCandidates(ctx, db, e, c.WindowDays)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listDuplicateCandidates(): %w", err)
+	}
+
+	var ms []Match
+	for i := range candidates {
+		if sim := Similarity(e.Title, candidates[i].Title); sim >= c.MinSimilarity {
+			ms = append(ms, Match{Expense: candidates[i], Similarity: sim})
+		}
+	}
+	for i := 1; i < len(ms); i++ {
+		for j := i; j > 0 && ms[j].Similarity > ms[j-1].Similarity; j-- {
+			ms[j], ms[j-1] = ms[j-1], ms[j]
+		}
+	}
+	return ms, nil
+}
+
+// checkDuplicates returns a *DuplicateError when e is a likely duplicate.
+func (s *Service) checkDuplicates(ctx context.Context, e *Expense) error {
+	if s.dupCheck == nil || DuplicatesAllowed(ctx) {
+		return nil
+	}
+	ms, err := s.FindDuplicates(ctx, e)
+	if err != nil {
+		return err
+	}
+	if len(ms) > 0 {
+		return &DuplicateError{Matches: ms}
+	}
+	return nil
+}
+
+// Duplicates returns the pairs of existing expenses that are likely
+// duplicates, oldest first, whose older expense was spent from from to to
+// included. Only the first maxDuplicatePairs candidate pairs are compared.
+func (s *Service) Duplicates(ctx context.Context, from, to time.Time) ([]DuplicatePair, error) {
+	c := DefaultDuplicateCheck
+	if s.dupCheck != nil {
+		c = *s.dupCheck
+	}
+
+	pairs := make([]DuplicatePair, 0)
+	err := s.readReplica(ctx, func(ctx context.Context, db querier) error {
+		candidates, err := listDuplicatePairs(ctx, db, c.WindowDays, from, to, maxDuplicatePairs)
+		if err != nil {
+			return fmt.Errorf("listDuplicatePairs(): %w", err)
+		}
+		var ids []int64
+		var kept []duplicatePair
+		for _, p := range candidates {
+			if p.similarity = Similarity(p.titles[0], p.titles[1]); p.similarity >= c.MinSimilarity {
+				kept = append(kept, p)
+				ids = append(ids, p.ids[0], p.ids[1])
+			}
+		}
+		if len(kept) == 0 {
+			return nil
+		}
+		exps, err := getExpensesByIDs(ctx, db, ids)
+		if err != nil {
+			return fmt.Errorf("getExpensesByIDs(): %w", err)
+		}
+		byID := make(map[int64]Expense, len(exps))
+		for _, e := range exps {
+			byID[e.ID] = e
+		}
+		pairs = pairs[:0]
+		for _, p := range kept {
+			pairs = append(pairs, DuplicatePair{
+				Expense:    byID[p.ids[0]],
+				Duplicate:  byID[p.ids[1]],
+				Similarity: p.similarity,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// Similarity returns how alike two titles are, from 0 to 1: one minus the
+// Levenshtein distance of the normalized titles divided by the length of
+// the longer one. Normalizing ignores case, punctuation and repeated
+// spaces.
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(normalizeTitle(a)), []rune(normalizeTitle(b))
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func normalizeTitle(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		space = true
+	}
+	return b.String()
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// amountTolerance absorbs the rounding of amounts stored as floats.
+const amountTolerance = 0.005
+
+func listDuplicateCandidates(ctx context.Context, db querier, e *Expense, window int) ([]Expense, error) {
+	on, err := time.Parse(DateLayout, e.SpentOn)
+	if err != nil {
+		return nil, err
+	}
+	qb := sq.Select(expenseColumns...).
+		From("expenses").
+		Where(sq.Eq{"deleted_at": nil, "currency": e.Currency}).
+		Where(inTenant(ctx)).
+		Where("abs(amount - ?) < ?", e.Amount, amountTolerance).
+		Where(sq.GtOrEq{"spent_on": on.AddDate(0, 0, -window).Format(DateLayout)}).
+		Where(sq.LtOrEq{"spent_on": on.AddDate(0, 0, window).Format(DateLayout)}).
+		Where(sq.NotEq{"id": e.ID}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar)
+	return queryExpenses(ctx, db, qb)
+}
+
+type duplicatePair struct {
+	ids        [2]int64
+	titles     [2]string
+	similarity float64
+}
+
+// listDuplicatePairs returns up to limit pairs of expenses with the same
+// amount and currency spent within window days of each other, whatever
+// their titles, whose first expense was spent from from to to included.
+func listDuplicatePairs(ctx context.Context, db querier, window int, from, to time.Time, limit uint64) ([]duplicatePair, error) {
+	rows, err := db.QueryContext(ctx, `SELECT a.id, b.id, a.title, b.title
+		FROM expenses a
+		JOIN expenses b ON b.id > a.id
+		  AND b.tenant_id = a.tenant_id
+		  AND b.currency = a.currency
+		  AND b.amount > a.amount - $1 AND b.amount < a.amount + $1
+		  AND b.spent_on BETWEEN a.spent_on - $2::int AND a.spent_on + $2::int
+		WHERE a.tenant_id = $3 AND a.spent_on BETWEEN $4 AND $5
+		  AND a.deleted_at IS NULL AND b.deleted_at IS NULL
+		ORDER BY a.id, b.id
+		LIMIT $6`, amountTolerance, window, TenantFromContext(ctx), from.Format(DateLayout), to.Format(DateLayout), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ps []duplicatePair
+	for rows.Next() {
+		var p duplicatePair
+		if err := rows.Scan(&p.ids[0], &p.ids[1], &p.titles[0], &p.titles[1]); err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	return ps, rows.Err()
+}
@@ -0,0 +1,103 @@
+package expense
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{"same", "Starbucks", "Starbucks", 1},
+		{"case and punctuation", "STARBUCKS #123", "starbucks  123", 1},
+		{"one edit", "Starbucks", "Starbuck", 1 - 1.0/9},
+		{"different", "Taxi", "Books", 0},
+		{"empty", "", "!", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, Similarity(tt.a, tt.b), 0.0001)
+		})
+	}
+}
+
+func TestServiceDuplicates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	svc.SetDuplicateCheck(DuplicateCheck{WindowDays: 3, MinSimilarity: 0.75})
+	ctx := context.Background()
+	spentOn, _ := time.Parse(DateLayout, "2026-03-10")
+	candidates := func() *sqlmock.Rows {
+		return sqlmock.NewRows(expenseColumns).
+			AddRow(1, 120, "Books", "", pq.Array([]string{}), "THB", spentOn, 120, nil, "draft", nil).
+			AddRow(2, 120, "Starbuck", "", pq.Array([]string{}), "THB", spentOn, 120, nil, "draft", nil).
+			AddRow(3, 120, "STARBUCKS", "", pq.Array([]string{}), "THB", spentOn, 120, nil, "draft", nil)
+	}
+	newExpense := func() *Expense {
+		return &Expense{Amount: 120, Title: "Starbucks", Currency: "THB", SpentOn: "2026-03-11"}
+	}
+
+	t.Run("Save() rejects a likely duplicate", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM expenses WHERE (.+) abs\(amount`).
+			WithArgs("THB", "", 120.0, amountTolerance, "2026-03-08", "2026-03-14", int64(0)).
+			WillReturnRows(candidates())
+
+		_, err := svc.Save(ctx, newExpense())
+
+		assert.ErrorIs(t, err, ErrDuplicate)
+		var dup *DuplicateError
+		if assert.ErrorAs(t, err, &dup) && assert.Len(t, dup.Matches, 2) {
+			assert.Equal(t, int64(3), dup.Matches[0].Expense.ID)
+			assert.Equal(t, 1.0, dup.Matches[0].Similarity)
+			assert.Equal(t, int64(2), dup.Matches[1].Expense.ID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Save() with AllowDuplicates skips the check", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+			WillReturnRows(sqlmock.NewRows(expenseColumns).
+				AddRow(4, 120, "Starbucks", "", pq.Array([]string{}), "THB", spentOn, 120, nil, "draft", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		_, err := svc.Save(AllowDuplicates(ctx), newExpense())
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Duplicates() returns the pairs with similar titles", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT a.id, b.id, a.title, b.title FROM expenses a JOIN expenses b`).
+			WithArgs(amountTolerance, 3, "", "2026-07-01", "2026-09-30", maxDuplicatePairs).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "id", "title", "title"}).
+				AddRow(1, 2, "Books", "Starbuck").
+				AddRow(2, 3, "Starbuck", "STARBUCKS"))
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).
+			WillReturnRows(sqlmock.NewRows(expenseColumns).
+				AddRow(2, 120, "Starbuck", "", pq.Array([]string{}), "THB", spentOn, 120, nil, "draft", nil).
+				AddRow(3, 120, "STARBUCKS", "", pq.Array([]string{}), "THB", spentOn, 120, nil, "draft", nil))
+
+		pairs, err := svc.Duplicates(ctx, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC))
+
+		if assert.NoError(t, err) && assert.Len(t, pairs, 1) {
+			assert.Equal(t, int64(2), pairs[0].Expense.ID)
+			assert.Equal(t, int64(3), pairs[0].Duplicate.ID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
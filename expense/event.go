@@ -0,0 +1,265 @@
+package expense
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/txn"
+)
+
+const (
+	EventCreated = "expense.created"
+	EventUpdated = "expense.updated"
+	EventDeleted = "expense.deleted"
+	// EventStatusChanged is recorded for every approval workflow transition.
+	EventStatusChanged = "expense.status_changed"
+)
+
+// Event records a change to an expense. Events are written to the
+// expense_events table in the same transaction as the change itself, so the
+// table acts as a transactional outbox for consumers such as webhooks.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	ExpenseID int64           `json:"expense_id"`
+	TenantID  string          `json:"tenant_id,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+	// TxID is the ID of the transaction that recorded the event.
+	TxID int64 `json:"-"`
+}
+
+// Position returns the position of the event in the outbox.
+func (ev *Event) Position() Position {
+	return Position{TxID: ev.TxID, ID: ev.ID}
+}
+
+// ErrPositionInvalid is returned when a position cannot be parsed.
+var ErrPositionInvalid = errors.New("invalid event position")
+
+// Position orders the events of the outbox by the transaction that recorded
+// them, then by ID. Event IDs are assigned when an event is recorded but
+// become visible when its transaction commits, so a lower ID may show up
+// after a higher one. Events only returns the events of transactions that
+// ended before every running one started, so positions show up in order and
+// a consumer never skips an event by remembering the last position it read.
+type Position struct {
+	TxID int64
+	ID   int64
+}
+
+// Less reports whether p comes before q.
+func (p Position) Less(q Position) bool {
+	return p.TxID < q.TxID || p.TxID == q.TxID && p.ID < q.ID
+}
+
+// String returns the position as "txid-id", as ParsePosition reads it.
+func (p Position) String() string {
+	return strconv.FormatInt(p.TxID, 10) + "-" + strconv.FormatInt(p.ID, 10)
+}
+
+// ParsePosition parses the String of a Position.
+func ParsePosition(s string) (Position, error) {
+	tx, id, ok := strings.Cut(s, "-")
+	if !ok {
+		return Position{}, ErrPositionInvalid
+	}
+	var p Position
+	var err error
+	if p.TxID, err = strconv.ParseInt(tx, 10, 64); err != nil {
+		return Position{}, ErrPositionInvalid
+	}
+	if p.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return Position{}, ErrPositionInvalid
+	}
+	return p, nil
+}
+
+// Events returns at most limit events after the position after, in the order
+// of their positions. Events of transactions that may still be followed by
+// the commit of a lower position are held back; held reports that some were,
+// and a later call returns them once the transactions before them ended.
+func (s *Service) Events(ctx context.Context, after Position, limit uint64) (evs []Event, held bool, err error) {
+	err = s.read(ctx, func(ctx context.Context, db querier) (err error) {
+		evs, held, err = listEvents(ctx, db, after, limit)
+		return err
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("listEvents(%s): %w", after, err)
+	}
+	return evs, held, nil
+}
+
+// LatestPosition returns the position of the most recent event that Events
+// would return, or the zero Position.
+func (s *Service) LatestPosition(ctx context.Context) (Position, error) {
+	var p Position
+	err := s.conn(ctx).QueryRowContext(ctx, `
+	  SELECT tx_id, id FROM expense_events
+	  WHERE tx_id < txid_snapshot_xmin(txid_current_snapshot())
+	  ORDER BY tx_id DESC, id DESC
+	  LIMIT 1`).Scan(&p.TxID, &p.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Position{}, fmt.Errorf("latestPosition(): %w", err)
+	}
+	return p, nil
+}
+
+// Retire records an EventDeleted for each of the expenses ids in the unit of
+// work of ctx, before they are removed for good by a job that spans tenants,
+// such as retention. Each event is recorded in the tenant of its expense,
+// soft-deleted expenses included, and the expenses are dropped from the
+// cache once the unit of work committed.
+func (s *Service) Retire(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := retireExpenses(ctx, s.conn(ctx), ids); err != nil {
+		return fmt.Errorf("retireExpenses(): %w", err)
+	}
+	txn.AfterCommit(ctx, func() {
+		for _, id := range ids {
+			s.invalidate(id)
+		}
+	})
+	return nil
+}
+
+// retireExpenses records an EventDeleted for each of the expenses ids.
+func retireExpenses(ctx context.Context, db querier, ids []int64) error {
+	query, args, err := sq.Select(append([]string{"tenant_id"}, expenseColumns...)...).
+		From("expenses").
+		Where("id = ANY(?)", pq.Array(ids)).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	ib := sq.Insert("expense_events").
+		Columns("type", "expense_id", "tenant_id", "data").
+		PlaceholderFormat(sq.Dollar)
+	n := 0
+	for rows.Next() {
+		var tenant string
+		e, err := scanExpense(func(dest ...any) error {
+			return rows.Scan(append([]any{&tenant}, dest...)...)
+		})
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		ib = ib.Values(EventDeleted, e.ID, tenant, data)
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+
+	query, args, err = ib.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func createEvent(ctx context.Context, db querier, typ string, e *Expense) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := sq.Insert("expense_events").
+		Columns("type", "expense_id", "tenant_id", "data").
+		Values(typ, e.ID, TenantFromContext(ctx), data).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// listEvents returns the events after the position after whose transaction
+// ended before the oldest running one started, and whether it held back
+// others. Every transaction that has yet to commit an event has a higher ID
+// than those, so later events all come after them.
+func listEvents(ctx context.Context, db querier, after Position, limit uint64) ([]Event, bool, error) {
+	query, args, err := sq.Select(eventColumns...).
+		Columns("tx_id", "tx_id < txid_snapshot_xmin(txid_current_snapshot())").
+		From("expense_events").
+		Where("(tx_id, id) > (?, ?)", after.TxID, after.ID).
+		OrderBy("tx_id", "id").
+		Limit(limit).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, false, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	evs := make([]Event, 0)
+	held := false
+	for rows.Next() {
+		var ev Event
+		var ended bool
+		if err := rows.Scan(
+			&ev.ID,
+			&ev.Type,
+			&ev.ExpenseID,
+			&ev.TenantID,
+			&ev.Data,
+			&ev.CreatedAt,
+			&ev.TxID,
+			&ended,
+		); err != nil {
+			return nil, false, err
+		}
+		if !ended {
+			held = true
+			break
+		}
+		evs = append(evs, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	return evs, held, nil
+}
+
+var eventColumns = []string{
+	"id",
+	"type",
+	"expense_id",
+	"tenant_id",
+	"data",
+	"created_at",
+}
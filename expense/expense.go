@@ -3,16 +3,47 @@ package expense
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/phuangpheth/assessment/postgres"
+	"github.com/phuangpheth/assessment/txn"
 
 	"github.com/lib/pq"
 )
 
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier = txn.Querier
+
+// Listener is notified once the save or update of an expense committed.
+// prev is nil when the expense was created.
+type Listener interface {
+	ExpenseSaved(ctx context.Context, prev, e *Expense)
+}
+
+// Converter converts amounts between currencies at the rate of a date.
+type Converter interface {
+	Convert(ctx context.Context, amount float64, from, to string, on time.Time) (float64, error)
+}
+
 type Service struct {
-	db *sql.DB
+	db         *sql.DB
+	tx         *txn.Manager
+	listeners  []Listener
+	searchMode string
+
+	baseCurrency string
+	converter    Converter
+	tagger       Tagger
+	dupCheck     *DuplicateCheck
+
+	cache   *Cache
+	reads   postgres.ReadPolicy
+	replica *postgres.Replica
 }
 
 // ErrNotFound is returned when the expense could not be found.
@@ -24,36 +55,265 @@ var ErrAmountInvalid = errors.New("amount must be greater than zero")
 // ErrTitleEmpty is returned when the title is empty.
 var ErrTitleEmpty = errors.New("empty title")
 
+// ErrCurrencyInvalid is returned when the currency is not a three-letter code.
+var ErrCurrencyInvalid = errors.New("currency must be a three-letter ISO 4217 code")
+
+// ErrSpentOnInvalid is returned when the spend date is not formatted as DateLayout.
+var ErrSpentOnInvalid = errors.New("spent_on must be formatted as YYYY-MM-DD")
+
+// DateLayout is the layout of Expense.SpentOn.
+const DateLayout = "2006-01-02"
+
 func NewService(db *sql.DB) *Service {
 	return &Service{
 		db: db,
+		tx: txn.NewManager(db, txn.Options{}),
+	}
+}
+
+// SetTxManager replaces the manager that runs the transactions of the
+// service and of WithTx.
+func (s *Service) SetTxManager(m *txn.Manager) {
+	s.tx = m
+}
+
+// WithTx runs fn as a unit of work: every call of this or another service
+// made with the context passed to fn runs in one transaction.
+func (s *Service) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.tx.WithTx(ctx, fn)
+}
+
+// conn returns the transaction of the unit of work of ctx, or the database.
+func (s *Service) conn(ctx context.Context) querier {
+	return txn.From(ctx, s.db)
+}
+
+// SetReadPolicy bounds the reads of the service by p.Timeout and retries
+// those that failed for transient reasons.
+func (s *Service) SetReadPolicy(p postgres.ReadPolicy) {
+	s.reads = p
+}
+
+// read runs the idempotent read fn under the read policy of the service.
+func (s *Service) read(ctx context.Context, fn func(ctx context.Context, db querier) error) error {
+	return s.reads.Do(ctx, func(ctx context.Context) error {
+		return fn(ctx, s.conn(ctx))
+	})
+}
+
+// SetCache makes GetByID read through c. Save, Update, Delete and Transition
+// invalidate the expense they change; changes made by other replicas must
+// be fed to c.ExpenseEvent.
+func (s *Service) SetCache(c *Cache) {
+	s.cache = c
+}
+
+// Invalidate drops the expense from the cache after it was changed or
+// removed without the service.
+func (s *Service) Invalidate(id int64) {
+	s.invalidate(id)
+}
+
+// invalidate drops the expense from the cache, if any.
+func (s *Service) invalidate(id int64) {
+	if s.cache != nil {
+		s.cache.Invalidate(id)
+	}
+}
+
+// SetConverter makes the service store every expense amount converted to
+// base, at the rate of the spend date, in BaseAmount. Without a converter
+// BaseAmount equals Amount.
+func (s *Service) SetConverter(base string, c Converter) {
+	s.baseCurrency = base
+	s.converter = c
+}
+
+// BaseCurrency returns the currency of BaseAmount, or an empty string when
+// no converter is set.
+func (s *Service) BaseCurrency() string {
+	return s.baseCurrency
+}
+
+// convert fills in the defaults of Currency and SpentOn and computes
+// BaseAmount.
+func (s *Service) convert(ctx context.Context, e *Expense) error {
+	if e.Currency == "" {
+		e.Currency = s.baseCurrency
+	}
+	if e.SpentOn == "" {
+		e.SpentOn = time.Now().Format(DateLayout)
 	}
+	if s.converter == nil || e.Currency == s.baseCurrency {
+		e.BaseAmount = e.Amount
+		return nil
+	}
+	on, _ := time.Parse(DateLayout, e.SpentOn)
+	base, err := s.converter.Convert(ctx, e.Amount, e.Currency, s.baseCurrency, on)
+	if err != nil {
+		return err
+	}
+	e.BaseAmount = base
+	return nil
 }
 
+// AddListener registers l to be notified after every Save and Update has
+// committed.
+func (s *Service) AddListener(l Listener) {
+	s.listeners = append(s.listeners, l)
+}
+
+func (s *Service) notify(ctx context.Context, prev, e *Expense) {
+	for _, l := range s.listeners {
+		l.ExpenseSaved(ctx, prev, e)
+	}
+}
+
+// inTx runs fn inside a unit of work, joining the one of ctx if any. fn is
+// run again when the transaction fails to serialize. A successful fn counts
+// as a write of the principal of ctx once the unit of work committed.
+//
+// Callers defer their notifications and cache invalidations with
+// txn.AfterCommit(ctx, ...): when ctx belongs to an outer unit of work they
+// run once it committed, and never for a unit of work that was rolled back.
+func (s *Service) inTx(ctx context.Context, fn func(db querier) error) error {
+	err := s.WithTx(ctx, func(ctx context.Context) error {
+		return fn(s.conn(ctx))
+	})
+	if err == nil {
+		txn.AfterCommit(ctx, func() { s.wrote(ctx) })
+	}
+	return err
+}
+
+// Save converts the expense, tags it with the tagger of the service, creates
+// it and records an EventCreated in the same transaction. With a duplicate
+// check it returns a *DuplicateError instead when the expense is a likely
+// duplicate.
 func (s *Service) Save(ctx context.Context, e *Expense) (*Expense, error) {
-	if err := createExpense(ctx, s.db, e); err != nil {
-		return nil, fmt.Errorf("createExpense(): %w", err)
+	e.Status = StatusDraft
+	if err := s.convert(ctx, e); err != nil {
+		return nil, fmt.Errorf("convert(): %w", err)
+	}
+	if err := s.tag(ctx, e); err != nil {
+		return nil, fmt.Errorf("tag(): %w", err)
+	}
+	if err := s.checkDuplicates(ctx, e); err != nil {
+		return nil, fmt.Errorf("checkDuplicates(): %w", err)
+	}
+	err := s.inTx(ctx, func(db querier) error {
+		if err := createExpense(ctx, db, e); err != nil {
+			return fmt.Errorf("createExpense(): %w", err)
+		}
+		if err := createEvent(ctx, db, EventCreated, e); err != nil {
+			return fmt.Errorf("createEvent(): %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	txn.AfterCommit(ctx, func() { s.notify(ctx, nil, e) })
 	return e, nil
 }
 
+// Update updates the expense and records an EventUpdated in the same
+// transaction.
 func (s *Service) Update(ctx context.Context, e *Expense) (*Expense, error) {
-	exp, err := getExpenseByID(ctx, s.db, e.ID)
+	var exp, prev *Expense
+	err := s.inTx(ctx, func(db querier) error {
+		var err error
+		exp, err = lockExpenseByID(ctx, db, e.ID)
+		if err != nil {
+			return fmt.Errorf("lockExpenseByID(%d): %w", e.ID, err)
+		}
+		if exp.Status != StatusDraft {
+			return ErrNotEditable
+		}
+		old := *exp
+		prev = &old
+		exp.Amount = e.Amount
+		exp.Title = e.Title
+		exp.Note = e.Note
+		exp.Tags = e.Tags
+		exp.Split = e.Split
+		if e.Currency != "" {
+			exp.Currency = e.Currency
+		}
+		if e.SpentOn != "" {
+			exp.SpentOn = e.SpentOn
+		}
+		if err := s.convert(ctx, exp); err != nil {
+			return fmt.Errorf("convert(): %w", err)
+		}
+		if err := updateExpense(ctx, db, exp); err != nil {
+			return fmt.Errorf("updateExpense(): %w", err)
+		}
+		if err := createEvent(ctx, db, EventUpdated, exp); err != nil {
+			return fmt.Errorf("createEvent(): %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("getExpenseByID(%d): %w", e.ID, err)
-	}
-	exp.Amount = e.Amount
-	exp.Title = e.Title
-	exp.Note = e.Note
-	exp.Tags = e.Tags
-	if err := updateExpense(ctx, s.db, exp); err != nil {
-		return nil, fmt.Errorf("updateExpense(): %w", err)
+		return nil, err
 	}
+	txn.AfterCommit(ctx, func() {
+		s.invalidate(exp.ID)
+		s.notify(ctx, prev, exp)
+	})
 	return exp, nil
 }
 
+// Delete soft-deletes the expense and records an EventDeleted in the same
+// transaction.
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	err := s.inTx(ctx, func(db querier) error {
+		exp, err := lockExpenseByID(ctx, db, id)
+		if err != nil {
+			return fmt.Errorf("lockExpenseByID(%d): %w", id, err)
+		}
+		if err := deleteExpense(ctx, db, id); err != nil {
+			return fmt.Errorf("deleteExpense(%d): %w", id, err)
+		}
+		if err := createEvent(ctx, db, EventDeleted, exp); err != nil {
+			return fmt.Errorf("createEvent(): %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	txn.AfterCommit(ctx, func() { s.invalidate(id) })
+	return nil
+}
+
+// GetByID returns the expense, from the cache when one is set. Within a
+// unit of work it always reads the database. Without a cache, a ctx from
+// AllowStale reads from the replica; the cache is only filled from the
+// primary, so that it never holds an expense older than its last change.
 func (s *Service) GetByID(ctx context.Context, id int64) (*Expense, error) {
-	exp, err := getExpenseByID(ctx, s.db, id)
+	cached := s.cache != nil && !txn.InTx(ctx)
+	tenant := TenantFromContext(ctx)
+	var gen uint64
+	if cached {
+		exp, g, ok := s.cache.get(tenant, id)
+		if ok {
+			return exp, nil
+		}
+		gen = g
+	}
+	read := s.read
+	if !cached && staleAllowed(ctx) {
+		read = s.readReplica
+	}
+	var exp *Expense
+	err := read(ctx, func(ctx context.Context, db querier) (err error) {
+		exp, err = getExpenseByID(ctx, db, id)
+		return err
+	})
+	if cached {
+		s.cache.done(tenant, id, exp, gen)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("getExpenseByID(%d): %w", id, err)
 	}
@@ -61,7 +321,11 @@ func (s *Service) GetByID(ctx context.Context, id int64) (*Expense, error) {
 }
 
 func (s *Service) List(ctx context.Context) ([]Expense, error) {
-	exps, err := listExpenses(ctx, s.db)
+	var exps []Expense
+	err := s.readReplica(ctx, func(ctx context.Context, db querier) (err error) {
+		exps, err = listExpenses(ctx, db)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("listExpenses(): %w", err)
 	}
@@ -74,8 +338,25 @@ type Expense struct {
 	Title  string   `json:"title"`
 	Note   string   `json:"note"`
 	Tags   []string `json:"tags"`
+	// Currency of Amount. It defaults to the base currency of the service.
+	Currency string `json:"currency,omitempty"`
+	// SpentOn is the date formatted as DateLayout whose exchange rate
+	// converts Amount. It defaults to the day the expense is saved.
+	SpentOn string `json:"spent_on,omitempty"`
+	// BaseAmount is Amount converted to the base currency of the service.
+	BaseAmount float64 `json:"base_amount,omitempty"`
+	// Split shares the expense between several people.
+	Split *Split `json:"split,omitempty"`
+	// Status in the approval workflow. New expenses are drafts and it only
+	// changes through Service.Transition.
+	Status Status `json:"status,omitempty"`
+	// UpdatedAt is when the expense last changed. It is served as the
+	// Last-Modified header rather than in the body.
+	UpdatedAt time.Time `json:"-"`
 }
 
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
 func (e *Expense) Validate() error {
 	if e.Amount <= 0 {
 		return ErrAmountInvalid
@@ -83,25 +364,51 @@ func (e *Expense) Validate() error {
 	if e.Title == "" {
 		return ErrTitleEmpty
 	}
+	if e.Currency != "" && !currencyCode.MatchString(e.Currency) {
+		return ErrCurrencyInvalid
+	}
+	if e.SpentOn != "" {
+		if _, err := time.Parse(DateLayout, e.SpentOn); err != nil {
+			return ErrSpentOnInvalid
+		}
+	}
+	if e.Split != nil {
+		return e.Split.Validate(e.Amount)
+	}
 	return nil
 }
 
-func createExpense(ctx context.Context, db *sql.DB, e *Expense) error {
+// createExpense inserts e, owned by the actor of ctx within its tenant.
+func createExpense(ctx context.Context, db querier, e *Expense) error {
 	query, args, err := sq.Insert("expenses").
 		Columns(
 			"amount",
 			"title",
 			"note",
 			"tags",
+			"currency",
+			"spent_on",
+			"base_amount",
+			"split",
+			"status",
+			"tenant_id",
+			"owner_id",
 		).
 		Values(
 			e.Amount,
 			e.Title,
 			e.Note,
 			pq.Array(e.Tags),
+			e.Currency,
+			e.SpentOn,
+			e.BaseAmount,
+			e.Split,
+			e.Status,
+			TenantFromContext(ctx),
+			ActorFromContext(ctx).ID,
 		).
 		Suffix(`
-      RETURNING id, amount, title, note, tags
+      RETURNING id, amount, title, note, tags, currency, spent_on, base_amount, split, status, updated_at
     `).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
@@ -110,25 +417,27 @@ func createExpense(ctx context.Context, db *sql.DB, e *Expense) error {
 	}
 
 	row := db.QueryRowContext(ctx, query, args...)
-	if err := row.Scan(
-		&e.ID,
-		&e.Amount,
-		&e.Title,
-		&e.Note,
-		pq.Array(&e.Tags),
-	); err != nil {
+	got, err := scanExpense(row.Scan)
+	if err != nil {
 		return err
 	}
+	*e = got
 	return nil
 }
 
-func updateExpense(ctx context.Context, db *sql.DB, e *Expense) error {
+func updateExpense(ctx context.Context, db querier, e *Expense) error {
 	query, args, err := sq.Update("expenses").
 		Set("amount", e.Amount).
 		Set("title", e.Title).
 		Set("note", e.Note).
 		Set("tags", pq.Array(e.Tags)).
+		Set("currency", e.Currency).
+		Set("spent_on", e.SpentOn).
+		Set("base_amount", e.BaseAmount).
+		Set("split", e.Split).
+		Set("updated_at", sq.Expr("now()")).
 		Where(sq.Eq{"id": e.ID}).
+		Where(inTenant(ctx)).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
@@ -140,13 +449,43 @@ func updateExpense(ctx context.Context, db *sql.DB, e *Expense) error {
 	return nil
 }
 
-func getExpenseByID(ctx context.Context, db *sql.DB, id int64) (*Expense, error) {
-	query, args, err := sq.Select(expenseColumns...).
-		From("expenses").
+func deleteExpense(ctx context.Context, db querier, id int64) error {
+	query, args, err := sq.Update("expenses").
+		Set("deleted_at", sq.Expr("now()")).
+		Set("updated_at", sq.Expr("now()")).
 		Where(sq.Eq{"id": id}).
-		Limit(1).
+		Where(inTenant(ctx)).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func getExpenseByID(ctx context.Context, db querier, id int64) (*Expense, error) {
+	return queryExpenseByID(ctx, db, selectExpenseByID(ctx, id))
+}
+
+// lockExpenseByID is getExpenseByID that locks the expense until the end of
+// the transaction, so that concurrent changes made from what was read are
+// applied one after the other.
+func lockExpenseByID(ctx context.Context, db querier, id int64) (*Expense, error) {
+	return queryExpenseByID(ctx, db, selectExpenseByID(ctx, id).Suffix("FOR UPDATE"))
+}
+
+func selectExpenseByID(ctx context.Context, id int64) sq.SelectBuilder {
+	return sq.Select(expenseColumns...).
+		From("expenses").
+		Where(sq.Eq{"id": id, "deleted_at": nil}).
+		Where(inTenant(ctx)).
+		Limit(1).
+		PlaceholderFormat(sq.Dollar)
+}
+
+func queryExpenseByID(ctx context.Context, db querier, qb sq.SelectBuilder) (*Expense, error) {
+	query, args, err := qb.ToSql()
 	if err != nil {
 		return nil, err
 	}
@@ -162,9 +501,11 @@ func getExpenseByID(ctx context.Context, db *sql.DB, id int64) (*Expense, error)
 	return &e, nil
 }
 
-func listExpenses(ctx context.Context, db *sql.DB) ([]Expense, error) {
+func listExpenses(ctx context.Context, db querier) ([]Expense, error) {
 	query, args, err := sq.Select(expenseColumns...).
 		From("expenses").
+		Where(sq.Eq{"deleted_at": nil}).
+		Where(inTenant(ctx)).
 		OrderBy("id DESC").
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
@@ -197,14 +538,45 @@ var expenseColumns = []string{
 	"title",
 	"note",
 	"tags",
+	"currency",
+	"spent_on",
+	"base_amount",
+	"split",
+	"status",
+	"updated_at",
 }
 
 func scanExpense(scan func(...any) error) (e Expense, _ error) {
-	return e, scan(
+	var (
+		spentOn   sql.NullTime
+		split     []byte
+		updatedAt sql.NullTime
+	)
+	err := scan(
 		&e.ID,
 		&e.Amount,
 		&e.Title,
 		&e.Note,
 		pq.Array(&e.Tags),
+		&e.Currency,
+		&spentOn,
+		&e.BaseAmount,
+		&split,
+		&e.Status,
+		&updatedAt,
 	)
+	if err != nil {
+		return e, err
+	}
+	if spentOn.Valid {
+		e.SpentOn = spentOn.Time.Format(DateLayout)
+	}
+	e.UpdatedAt = updatedAt.Time
+	if split != nil {
+		e.Split = new(Split)
+		if err := json.Unmarshal(split, e.Split); err != nil {
+			return e, err
+		}
+	}
+	return e, nil
 }
@@ -1,11 +1,36 @@
 package expense
 
 import (
+	"context"
+	"database/sql"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/txn"
 	"github.com/stretchr/testify/assert"
 )
 
+// rateConverter converts at a fixed rate and records the dates it was asked
+// about.
+type rateConverter struct {
+	rate float64
+	on   []time.Time
+}
+
+func (c *rateConverter) Convert(ctx context.Context, amount float64, from, to string, on time.Time) (float64, error) {
+	c.on = append(c.on, on)
+	return amount * c.rate, nil
+}
+
+// taggerFunc adapts a function to a Tagger.
+type taggerFunc func(ctx context.Context, e *Expense) error
+
+func (f taggerFunc) Tag(ctx context.Context, e *Expense) error {
+	return f(ctx, e)
+}
+
 func TestExpenseValidate(t *testing.T) {
 	t.Run("ErrAmountInvalid", func(t *testing.T) {
 		exp := &Expense{
@@ -37,6 +62,22 @@ func TestExpenseValidate(t *testing.T) {
 		assert.EqualError(t, err, want.Error())
 	})
 
+	t.Run("ErrCurrencyInvalid", func(t *testing.T) {
+		exp := &Expense{Amount: 10, Title: "Hot Tea", Currency: "thb"}
+
+		err := exp.Validate()
+
+		assert.ErrorIs(t, err, ErrCurrencyInvalid)
+	})
+
+	t.Run("ErrSpentOnInvalid", func(t *testing.T) {
+		exp := &Expense{Amount: 10, Title: "Hot Tea", SpentOn: "18/10/2026"}
+
+		err := exp.Validate()
+
+		assert.ErrorIs(t, err, ErrSpentOnInvalid)
+	})
+
 	t.Run("Validate No Error", func(t *testing.T) {
 		exp := &Expense{
 			ID:     1,
@@ -51,3 +92,175 @@ func TestExpenseValidate(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestServiceSaveConverts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	conv := &rateConverter{rate: 0.0016}
+	svc := NewService(db)
+	svc.SetConverter("THB", conv)
+
+	t.Run("Save() stores the base amount at the rate of the spend date", func(t *testing.T) {
+		spentOn := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+			WithArgs(50000.0, "Noodles", "", pq.Array([]string{"food"}), "LAK", "2026-10-01", 80.0, nil, StatusDraft, "", "").
+			WillReturnRows(sqlmock.NewRows(expenseColumns).
+				AddRow(1, 50000, "Noodles", "", pq.Array([]string{"food"}), "LAK", spentOn, 80, nil, "draft", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		e, err := svc.Save(context.Background(), &Expense{
+			Amount:   50000,
+			Title:    "Noodles",
+			Tags:     []string{"food"},
+			Currency: "LAK",
+			SpentOn:  "2026-10-01",
+		})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 80.0, e.BaseAmount)
+			assert.Equal(t, "2026-10-01", e.SpentOn)
+			assert.Equal(t, []time.Time{spentOn}, conv.on)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Save() defaults to the base currency", func(t *testing.T) {
+		conv.on = nil
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+			WithArgs(75.0, "Tea", "", pq.Array([]string(nil)), "THB", time.Now().Format(DateLayout), 75.0, nil, StatusDraft, "", "").
+			WillReturnRows(sqlmock.NewRows(expenseColumns).
+				AddRow(2, 75, "Tea", "", pq.Array([]string{}), "THB", time.Now(), 75, nil, "draft", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		e, err := svc.Save(context.Background(), &Expense{Amount: 75, Title: "Tea"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 75.0, e.BaseAmount)
+			assert.Empty(t, conv.on)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Save() tags the expense after converting it", func(t *testing.T) {
+		var seen float64
+		svc.SetTagger(taggerFunc(func(ctx context.Context, e *Expense) error {
+			seen = e.BaseAmount
+			return nil
+		}))
+		defer svc.SetTagger(nil)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+			WillReturnRows(sqlmock.NewRows(expenseColumns).
+				AddRow(3, 50000, "Noodles", "", pq.Array([]string{}), "LAK", time.Now(), 80, nil, "draft", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		_, err := svc.Save(context.Background(), &Expense{Amount: 50000, Title: "Noodles", Currency: "LAK", SpentOn: "2026-10-01"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 80.0, seen)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestServiceWithTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	row := sqlmock.NewRows(expenseColumns).
+		AddRow(1, 75, "Tea", "", pq.Array([]string{}), "THB", time.Now(), 75, nil, "draft", time.Now())
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO expenses`).WillReturnRows(row)
+	mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	err = svc.WithTx(context.Background(), func(ctx context.Context) error {
+		e, err := svc.Save(ctx, &Expense{Amount: 75, Title: "Tea"})
+		if err != nil {
+			return err
+		}
+		return svc.Delete(ctx, e.ID)
+	})
+
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceGetByIDOtherTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	mock.ExpectQuery(`SELECT (.+) FROM expenses WHERE (.+) tenant_id = \$2`).
+		WithArgs(1, "globex").
+		WillReturnRows(sqlmock.NewRows(expenseColumns))
+
+	_, err = svc.GetByID(WithTenant(context.Background(), "globex"), 1)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceNotifiesAfterCommit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	svc.SetTxManager(txn.NewManager(db, txn.Options{MaxRetries: 1}))
+	var saved savedTitles
+	svc.AddListener(&saved)
+	row := func() *sqlmock.Rows {
+		return sqlmock.NewRows(expenseColumns).
+			AddRow(1, 75, "Tea", "", pq.Array([]string{}), "THB", time.Now(), 75, nil, "draft", time.Now())
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO expenses`).WillReturnRows(row())
+	mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE budgets`).WillReturnError(&pq.Error{Code: "40001"})
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO expenses`).WillReturnRows(row())
+	mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE budgets`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = svc.WithTx(context.Background(), func(ctx context.Context) error {
+		if _, err := svc.Save(ctx, &Expense{Amount: 75, Title: "Tea"}); err != nil {
+			return err
+		}
+		assert.Empty(t, saved, "not before commit")
+		_, err := txn.From(ctx, db).ExecContext(ctx, "UPDATE budgets SET name = name")
+		return err
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, savedTitles{"Tea"}, saved, "once despite the retry")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// savedTitles keeps the titles of the expenses it is notified of.
+type savedTitles []string
+
+func (s *savedTitles) ExpenseSaved(ctx context.Context, prev, e *Expense) {
+	*s = append(*s, e.Title)
+}
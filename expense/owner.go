@@ -0,0 +1,355 @@
+package expense
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/txn"
+)
+
+// ErrOwnerEmpty is returned when the data of an anonymous caller is
+// requested.
+var ErrOwnerEmpty = errors.New("requests must identify a user")
+
+// ErasedTitle replaces the title of anonymized expenses.
+const ErasedTitle = "erased"
+
+// Owner is the principal an expense belongs to: the actor that saved it,
+// within its tenant.
+type Owner struct {
+	Tenant string
+	ID     string
+}
+
+// OwnerFromContext returns the tenant and the actor of ctx as an Owner.
+func OwnerFromContext(ctx context.Context) Owner {
+	return Owner{Tenant: TenantFromContext(ctx), ID: ActorFromContext(ctx).ID}
+}
+
+// History is what was recorded about the expenses of an owner.
+type History struct {
+	Events      []Event      `json:"events"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// Erasure is what Erase did to the expenses of an owner.
+type Erasure struct {
+	Deleted    []int64
+	Anonymized []int64
+}
+
+// ListOwned returns every expense of o, soft-deleted ones included, oldest
+// first.
+func (s *Service) ListOwned(ctx context.Context, o Owner) ([]Expense, error) {
+	if o.ID == "" {
+		return nil, ErrOwnerEmpty
+	}
+	var exps []Expense
+	err := s.read(ctx, func(ctx context.Context, db querier) (err error) {
+		exps, err = listOwnedExpenses(ctx, db, o)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listOwnedExpenses(): %w", err)
+	}
+	return exps, nil
+}
+
+// History returns the events of the expenses ids, and the transitions of
+// those expenses or made by o within its tenant, oldest first.
+func (s *Service) History(ctx context.Context, o Owner, ids []int64) (*History, error) {
+	if o.ID == "" {
+		return nil, ErrOwnerEmpty
+	}
+	h := &History{}
+	err := s.read(ctx, func(ctx context.Context, db querier) (err error) {
+		if h.Events, err = listEventsOf(ctx, db, ids); err != nil {
+			return fmt.Errorf("listEventsOf(): %w", err)
+		}
+		if h.Transitions, err = listTransitionsOf(ctx, db, o, ids); err != nil {
+			return fmt.Errorf("listTransitionsOf(): %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Erase removes o from every expense, in the unit of work of ctx. Approved
+// and reimbursed expenses are kept for accounting, anonymized: their title,
+// note, tags, split and owner are cleared. The others are deleted. The
+// events of both are deleted, since they hold copies of the expenses, and o
+// is cleared from the transitions it made. An EventDeleted is then recorded
+// for each deleted expense and an EventUpdated with the anonymized copy for
+// each anonymized one, so that subscribers and the caches of every replica
+// drop what they hold of them.
+func (s *Service) Erase(ctx context.Context, o Owner) (*Erasure, error) {
+	if o.ID == "" {
+		return nil, ErrOwnerEmpty
+	}
+	er := &Erasure{}
+	err := s.inTx(ctx, func(db querier) error {
+		er.Deleted, er.Anonymized = nil, nil
+		owned, err := lockOwnedExpenses(ctx, db, o)
+		if err != nil {
+			return fmt.Errorf("lockOwnedExpenses(): %w", err)
+		}
+		ids := make([]int64, 0, len(owned))
+		var deleted []Expense
+		for _, e := range owned {
+			ids = append(ids, e.ID)
+			if e.Status == StatusApproved || e.Status == StatusReimbursed {
+				er.Anonymized = append(er.Anonymized, e.ID)
+			} else {
+				er.Deleted = append(er.Deleted, e.ID)
+				deleted = append(deleted, e)
+			}
+		}
+
+		if err := deleteEventsOf(ctx, db, ids); err != nil {
+			return fmt.Errorf("deleteEventsOf(): %w", err)
+		}
+		if err := eraseTransitions(ctx, db, o, er.Anonymized); err != nil {
+			return fmt.Errorf("eraseTransitions(): %w", err)
+		}
+		if err := anonymizeExpenses(ctx, db, er.Anonymized); err != nil {
+			return fmt.Errorf("anonymizeExpenses(): %w", err)
+		}
+		if err := purgeExpenses(ctx, db, er.Deleted); err != nil {
+			return fmt.Errorf("purgeExpenses(): %w", err)
+		}
+		if err := createErasureEvents(ctx, db, o.Tenant, deleted, er.Anonymized); err != nil {
+			return fmt.Errorf("createErasureEvents(): %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	txn.AfterCommit(ctx, func() {
+		for _, id := range er.Deleted {
+			s.invalidate(id)
+		}
+		for _, id := range er.Anonymized {
+			s.invalidate(id)
+		}
+	})
+	return er, nil
+}
+
+func listOwnedExpenses(ctx context.Context, db querier, o Owner) ([]Expense, error) {
+	qb := sq.Select(expenseColumns...).
+		From("expenses").
+		Where(sq.Eq{"tenant_id": o.Tenant, "owner_id": o.ID}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar)
+	return queryExpenses(ctx, db, qb)
+}
+
+// lockOwnedExpenses returns the ID and status of every expense of o.
+func lockOwnedExpenses(ctx context.Context, db querier, o Owner) ([]Expense, error) {
+	query, args, err := sq.Select("id", "status").
+		From("expenses").
+		Where(sq.Eq{"tenant_id": o.Tenant, "owner_id": o.ID}).
+		OrderBy("id").
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var owned []Expense
+	for rows.Next() {
+		var e Expense
+		if err := rows.Scan(&e.ID, &e.Status); err != nil {
+			return nil, err
+		}
+		owned = append(owned, e)
+	}
+	return owned, rows.Err()
+}
+
+func listEventsOf(ctx context.Context, db querier, ids []int64) ([]Event, error) {
+	query, args, err := sq.Select(eventColumns...).
+		From("expense_events").
+		Where("expense_id = ANY(?)", pq.Array(ids)).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	evs := make([]Event, 0)
+	for rows.Next() {
+		var ev Event
+		if err := rows.Scan(&ev.ID, &ev.Type, &ev.ExpenseID, &ev.TenantID, &ev.Data, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		evs = append(evs, ev)
+	}
+	return evs, rows.Err()
+}
+
+// madeBy selects the transitions o made on expenses of its tenant.
+func madeBy(o Owner) sq.Sqlizer {
+	return sq.And{
+		sq.Eq{"actor": o.ID},
+		sq.Expr("expense_id IN (SELECT id FROM expenses WHERE tenant_id = ?)", o.Tenant),
+	}
+}
+
+func listTransitionsOf(ctx context.Context, db querier, o Owner, ids []int64) ([]Transition, error) {
+	query, args, err := sq.Select("id", "expense_id", "action", "from_status", "to_status", "actor", "reason", "created_at").
+		From("expense_transitions").
+		Where(sq.Or{sq.Expr("expense_id = ANY(?)", pq.Array(ids)), madeBy(o)}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts := make([]Transition, 0)
+	for rows.Next() {
+		var t Transition
+		if err := rows.Scan(&t.ID, &t.ExpenseID, &t.Action, &t.From, &t.To, &t.Actor, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+	return ts, rows.Err()
+}
+
+func deleteEventsOf(ctx context.Context, db querier, ids []int64) error {
+	query, args, err := sq.Delete("expense_events").
+		Where("expense_id = ANY(?)", pq.Array(ids)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// eraseTransitions clears o from the transitions it made, and the reasons
+// given on the anonymized expenses.
+func eraseTransitions(ctx context.Context, db querier, o Owner, anonymized []int64) error {
+	query, args, err := sq.Update("expense_transitions").
+		Set("actor", sq.Expr("CASE WHEN actor = ? THEN '' ELSE actor END", o.ID)).
+		Set("reason", sq.Expr("CASE WHEN expense_id = ANY(?) THEN '' ELSE reason END", pq.Array(anonymized))).
+		Where(sq.Or{sq.Expr("expense_id = ANY(?)", pq.Array(anonymized)), madeBy(o)}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func anonymizeExpenses(ctx context.Context, db querier, ids []int64) error {
+	query, args, err := sq.Update("expenses").
+		Set("title", ErasedTitle).
+		Set("note", "").
+		Set("tags", pq.Array([]string{})).
+		Set("split", nil).
+		Set("owner_id", "").
+		Set("updated_at", sq.Expr("now()")).
+		Where("id = ANY(?)", pq.Array(ids)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// createErasureEvents records an EventDeleted for each of the deleted
+// expenses, which only carries their ID and status, and an EventUpdated for
+// each of the anonymized expenses ids.
+func createErasureEvents(ctx context.Context, db querier, tenant string, deleted []Expense, anonymized []int64) error {
+	if len(deleted) == 0 && len(anonymized) == 0 {
+		return nil
+	}
+	ib := sq.Insert("expense_events").
+		Columns("type", "expense_id", "tenant_id", "data").
+		PlaceholderFormat(sq.Dollar)
+	n := len(deleted)
+	for i := range deleted {
+		data, err := json.Marshal(&deleted[i])
+		if err != nil {
+			return err
+		}
+		ib = ib.Values(EventDeleted, deleted[i].ID, tenant, data)
+	}
+	if len(anonymized) > 0 {
+		exps, err := queryExpenses(ctx, db, sq.Select(expenseColumns...).
+			From("expenses").
+			Where("id = ANY(?)", pq.Array(anonymized)).
+			OrderBy("id").
+			PlaceholderFormat(sq.Dollar))
+		if err != nil {
+			return err
+		}
+		for i := range exps {
+			data, err := json.Marshal(&exps[i])
+			if err != nil {
+				return err
+			}
+			ib = ib.Values(EventUpdated, exps[i].ID, tenant, data)
+		}
+		n += len(exps)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	query, args, err := ib.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// purgeExpenses deletes the expenses for good, with their attachments and
+// transitions.
+func purgeExpenses(ctx context.Context, db querier, ids []int64) error {
+	query, args, err := sq.Delete("expenses").
+		Where("id = ANY(?)", pq.Array(ids)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,81 @@
+package expense
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceErase(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	o := Owner{Tenant: "acme", ID: "ana"}
+
+	t.Run("Erase() deletes drafts and anonymizes approved expenses", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, status FROM expenses WHERE (.+) FOR UPDATE`).
+			WithArgs("ana", "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).
+				AddRow(1, "draft").
+				AddRow(2, "approved").
+				AddRow(3, "rejected"))
+		mock.ExpectExec(`DELETE FROM expense_events WHERE expense_id = ANY`).
+			WithArgs(pq.Array([]int64{1, 2, 3})).
+			WillReturnResult(sqlmock.NewResult(0, 5))
+		mock.ExpectExec(`UPDATE expense_transitions SET actor = CASE (.+) WHERE \(expense_id = ANY\(\$3\) OR \(actor = \$4 AND expense_id IN \(SELECT id FROM expenses WHERE tenant_id = \$5\)\)\)`).
+			WithArgs("ana", pq.Array([]int64{2}), pq.Array([]int64{2}), "ana", "acme").
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`UPDATE expenses SET title = \$1`).
+			WithArgs(ErasedTitle, "", pq.Array([]string{}), nil, "", pq.Array([]int64{2})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM expenses WHERE id = ANY`).
+			WithArgs(pq.Array([]int64{1, 3})).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(`SELECT (.+) FROM expenses WHERE id = ANY`).
+			WithArgs(pq.Array([]int64{2})).
+			WillReturnRows(sqlmock.NewRows(expenseColumns).
+				AddRow(2, 40, ErasedTitle, "", pq.Array([]string{}), "THB", nil, 40, nil, "approved", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).
+			WithArgs(
+				EventDeleted, 1, "acme", sqlmock.AnyArg(),
+				EventDeleted, 3, "acme", sqlmock.AnyArg(),
+				EventUpdated, 2, "acme", sqlmock.AnyArg(),
+			).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		er, err := svc.Erase(context.Background(), o)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, &Erasure{Deleted: []int64{1, 3}, Anonymized: []int64{2}}, er)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Erase() requires an owner", func(t *testing.T) {
+		_, err := svc.Erase(context.Background(), Owner{Tenant: "acme"})
+
+		assert.ErrorIs(t, err, ErrOwnerEmpty)
+	})
+
+	t.Run("ListOwned() includes deleted expenses", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM expenses WHERE owner_id = \$1 AND tenant_id = \$2 ORDER BY id`).
+			WithArgs("ana", "acme").
+			WillReturnRows(sqlmock.NewRows(expenseColumns))
+
+		exps, err := svc.ListOwned(context.Background(), o)
+
+		if assert.NoError(t, err) {
+			assert.Empty(t, exps)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
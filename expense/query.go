@@ -0,0 +1,236 @@
+package expense
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ErrGroupByInvalid is returned when a summary is grouped by an unknown key.
+var ErrGroupByInvalid = errors.New("group by must be one of tag or month")
+
+// ErrConverterMissing is returned when a summary asks for a currency other
+// than the base currency and the service has no Converter.
+var ErrConverterMissing = errors.New("currency conversion is not configured")
+
+// Summary groupings.
+const (
+	GroupByTag   = "tag"
+	GroupByMonth = "month"
+)
+
+// Filter narrows the expenses returned by Find. Zero fields do not filter.
+type Filter struct {
+	Tag       string
+	Title     string
+	MinAmount *float64
+	MaxAmount *float64
+}
+
+// Group is the number and total amount of the expenses that share Key.
+type Group struct {
+	Key   string  `json:"key"`
+	Count int64   `json:"count"`
+	Total float64 `json:"total"`
+	// Currency of Total. It is empty when the service has no base currency.
+	Currency string `json:"currency,omitempty"`
+}
+
+// Find returns up to first expenses that match f, newest first, starting
+// after the expense with ID after (0 for the first page). more reports
+// whether further pages exist.
+func (s *Service) Find(ctx context.Context, f Filter, first uint64, after int64) (exps []Expense, more bool, _ error) {
+	err := s.readReplica(ctx, func(ctx context.Context, db querier) (err error) {
+		exps, err = findExpenses(ctx, db, f, first+1, after)
+		return err
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("findExpenses(): %w", err)
+	}
+	if uint64(len(exps)) > first {
+		return exps[:first], true, nil
+	}
+	return exps, false, nil
+}
+
+// GetByIDs returns the expenses with the given IDs in a single query. IDs
+// that could not be found are missing from the map.
+func (s *Service) GetByIDs(ctx context.Context, ids []int64) (map[int64]*Expense, error) {
+	var exps []Expense
+	err := s.read(ctx, func(ctx context.Context, db querier) (err error) {
+		exps, err = getExpensesByIDs(ctx, db, ids)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getExpensesByIDs(): %w", err)
+	}
+	m := make(map[int64]*Expense, len(exps))
+	for i := range exps {
+		m[exps[i].ID] = &exps[i]
+	}
+	return m, nil
+}
+
+// ListSplit returns every expense that has a Split, oldest first.
+func (s *Service) ListSplit(ctx context.Context) ([]Expense, error) {
+	var exps []Expense
+	err := s.read(ctx, func(ctx context.Context, db querier) (err error) {
+		exps, err = listSplitExpenses(ctx, db)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listSplitExpenses(): %w", err)
+	}
+	return exps, nil
+}
+
+// Summary returns the count and total base amount of expenses grouped by
+// GroupByTag or GroupByMonth of the spend date. The totals are converted to
+// currency at today's rate unless currency is empty or the base currency.
+func (s *Service) Summary(ctx context.Context, groupBy, currency string) ([]Group, error) {
+	var key string
+	switch groupBy {
+	case GroupByTag:
+		key = "unnest(tags)"
+	case GroupByMonth:
+		key = "to_char(spent_on, 'YYYY-MM')"
+	default:
+		return nil, ErrGroupByInvalid
+	}
+	if currency != "" && !currencyCode.MatchString(currency) {
+		return nil, ErrCurrencyInvalid
+	}
+	if currency != "" && currency != s.baseCurrency && s.converter == nil {
+		return nil, ErrConverterMissing
+	}
+
+	var gs []Group
+	err := s.readReplica(ctx, func(ctx context.Context, db querier) (err error) {
+		gs, err = summarizeExpenses(ctx, db, key)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("summarizeExpenses(%s): %w", groupBy, err)
+	}
+	today := time.Now()
+	for i := range gs {
+		g := &gs[i]
+		g.Currency = s.baseCurrency
+		if currency == "" || currency == s.baseCurrency {
+			continue
+		}
+		if g.Total, err = s.converter.Convert(ctx, g.Total, s.baseCurrency, currency, today); err != nil {
+			return nil, fmt.Errorf("convert(%s): %w", currency, err)
+		}
+		g.Currency = currency
+	}
+	return gs, nil
+}
+
+func findExpenses(ctx context.Context, db querier, f Filter, limit uint64, after int64) ([]Expense, error) {
+	qb := sq.Select(expenseColumns...).
+		From("expenses").
+		Where(sq.Eq{"deleted_at": nil}).
+		Where(inTenant(ctx)).
+		OrderBy("id DESC").
+		Limit(limit).
+		PlaceholderFormat(sq.Dollar)
+	if after > 0 {
+		qb = qb.Where(sq.Lt{"id": after})
+	}
+	if f.Tag != "" {
+		qb = qb.Where("? = ANY(tags)", f.Tag)
+	}
+	if f.Title != "" {
+		qb = qb.Where(sq.ILike{"title": "%" + f.Title + "%"})
+	}
+	if f.MinAmount != nil {
+		qb = qb.Where(sq.GtOrEq{"amount": *f.MinAmount})
+	}
+	if f.MaxAmount != nil {
+		qb = qb.Where(sq.LtOrEq{"amount": *f.MaxAmount})
+	}
+	return queryExpenses(ctx, db, qb)
+}
+
+func getExpensesByIDs(ctx context.Context, db querier, ids []int64) ([]Expense, error) {
+	qb := sq.Select(expenseColumns...).
+		From("expenses").
+		Where(sq.Eq{"id": ids, "deleted_at": nil}).
+		Where(inTenant(ctx)).
+		PlaceholderFormat(sq.Dollar)
+	return queryExpenses(ctx, db, qb)
+}
+
+func listSplitExpenses(ctx context.Context, db querier) ([]Expense, error) {
+	qb := sq.Select(expenseColumns...).
+		From("expenses").
+		Where(sq.Eq{"deleted_at": nil}).
+		Where(inTenant(ctx)).
+		Where(sq.NotEq{"split": nil}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar)
+	return queryExpenses(ctx, db, qb)
+}
+
+func queryExpenses(ctx context.Context, db querier, qb sq.SelectBuilder) ([]Expense, error) {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exps := make([]Expense, 0)
+	for rows.Next() {
+		e, err := scanExpense(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		exps = append(exps, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return exps, nil
+}
+
+func summarizeExpenses(ctx context.Context, db querier, key string) ([]Group, error) {
+	query, args, err := sq.Select(key+" AS key", "count(*)", "COALESCE(SUM(base_amount), 0)").
+		From("expenses").
+		Where(sq.Eq{"deleted_at": nil}).
+		Where(inTenant(ctx)).
+		GroupBy("key").
+		OrderBy("key").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	gs := make([]Group, 0)
+	for rows.Next() {
+		var g Group
+		if err := rows.Scan(&g.Key, &g.Count, &g.Total); err != nil {
+			return nil, err
+		}
+		gs = append(gs, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
@@ -0,0 +1,86 @@
+package expense
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceFind(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db)
+	ctx := context.Background()
+
+	t.Run("Find() reports more pages", func(t *testing.T) {
+		min := 10.0
+		mock.ExpectQuery("SELECT (.+) FROM expenses WHERE (.+) amount >= (.+) LIMIT 2").
+			WithArgs("", min).
+			WillReturnRows(sqlmock.NewRows(expenseColumns).
+				AddRow(3, 30, "Milk", "", pq.Array([]string{}), "", nil, 0, nil, "draft", nil).
+				AddRow(2, 20, "Tea", "", pq.Array([]string{}), "", nil, 0, nil, "draft", nil))
+
+		exps, more, err := svc.Find(ctx, Filter{MinAmount: &min}, 1, 0)
+
+		if assert.NoError(t, err) {
+			assert.True(t, more)
+			assert.Len(t, exps, 1)
+			assert.Equal(t, int64(3), exps[0].ID)
+		}
+	})
+
+	t.Run("Find() returns the last page", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM expenses WHERE (.+) title ILIKE (.+) LIMIT 3").
+			WithArgs("", 2, "%tea%").
+			WillReturnRows(sqlmock.NewRows(expenseColumns).AddRow(1, 20, "Tea", "", pq.Array([]string{}), "", nil, 0, nil, "draft", nil))
+
+		exps, more, err := svc.Find(ctx, Filter{Title: "tea"}, 2, 2)
+
+		if assert.NoError(t, err) {
+			assert.False(t, more)
+			assert.Len(t, exps, 1)
+		}
+	})
+}
+
+func TestServiceSummary(t *testing.T) {
+	svc := NewService(nil)
+
+	t.Run("Summary() returns ErrGroupByInvalid", func(t *testing.T) {
+		_, err := svc.Summary(context.Background(), "day", "")
+
+		assert.ErrorIs(t, err, ErrGroupByInvalid)
+	})
+
+	t.Run("Summary() returns ErrConverterMissing", func(t *testing.T) {
+		_, err := svc.Summary(context.Background(), GroupByTag, "USD")
+
+		assert.ErrorIs(t, err, ErrConverterMissing)
+	})
+
+	t.Run("Summary() converts totals to currency", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		svc := NewService(db)
+		svc.SetConverter("THB", &rateConverter{rate: 0.03})
+		mock.ExpectQuery(`SELECT unnest\(tags\) AS key, count\(\*\), COALESCE\(SUM\(base_amount\), 0\) FROM expenses`).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "count", "sum"}).AddRow("food", 2, 1000))
+
+		gs, err := svc.Summary(context.Background(), GroupByTag, "USD")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []Group{{Key: "food", Count: 2, Total: 30, Currency: "USD"}}, gs)
+		}
+	})
+}
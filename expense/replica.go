@@ -0,0 +1,66 @@
+package expense
+
+import (
+	"context"
+	"errors"
+
+	"github.com/phuangpheth/assessment/postgres"
+	"github.com/phuangpheth/assessment/txn"
+)
+
+type staleKey struct{}
+
+// AllowStale returns a copy of ctx in which GetByID may read from the read
+// replica, and so return an expense that lags behind the primary.
+func AllowStale(ctx context.Context) context.Context {
+	return context.WithValue(ctx, staleKey{}, true)
+}
+
+func staleAllowed(ctx context.Context) bool {
+	ok, _ := ctx.Value(staleKey{}).(bool)
+	return ok
+}
+
+// SetReplica makes List, Find, Summary and, with AllowStale, GetByID read
+// from r. Every other read, and every read within a unit of work, goes to
+// the primary.
+func (s *Service) SetReplica(r *postgres.Replica) {
+	s.replica = r
+}
+
+// writer identifies the principal of ctx for read-your-writes stickiness.
+func writer(ctx context.Context) string {
+	return TenantFromContext(ctx) + "/" + ActorFromContext(ctx).ID
+}
+
+// wrote records a write by the principal of ctx.
+func (s *Service) wrote(ctx context.Context) {
+	if s.replica != nil {
+		s.replica.Wrote(writer(ctx))
+	}
+}
+
+// readReplica runs the read fn against the replica when it may be used, and
+// against the primary otherwise or when the replica failed. A read that ran
+// out of its timeout while ctx is still live counts as a failure of the
+// replica.
+func (s *Service) readReplica(ctx context.Context, fn func(ctx context.Context, db querier) error) error {
+	if s.replica == nil || txn.InTx(ctx) || !s.replica.Use(writer(ctx)) {
+		return s.read(ctx, fn)
+	}
+	once := postgres.ReadPolicy{Timeout: s.reads.Timeout}
+	var timedOut bool
+	err := once.Do(ctx, func(ctx context.Context) error {
+		err := fn(ctx, s.replica.DB())
+		timedOut = err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)
+		return err
+	})
+	if timedOut && ctx.Err() == nil {
+		s.replica.Down()
+		return s.read(ctx, fn)
+	}
+	if s.replica.Failed(err) {
+		return s.read(ctx, fn)
+	}
+	return err
+}
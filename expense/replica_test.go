@@ -0,0 +1,105 @@
+package expense
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceReplica(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	replicaDB, replicaMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer replicaDB.Close()
+
+	svc := NewService(db)
+	svc.SetReplica(postgres.NewReplica(replicaDB, time.Minute))
+	alice := WithActor(context.Background(), Actor{ID: "alice"})
+	bob := WithActor(context.Background(), Actor{ID: "bob"})
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(expenseColumns).
+			AddRow(1, 75, "Tea", "", pq.Array([]string{}), "THB", time.Now(), 75, nil, "draft", nil)
+	}
+
+	t.Run("List() reads from the replica", func(t *testing.T) {
+		replicaMock.ExpectQuery(`SELECT (.+) FROM expenses`).WillReturnRows(rows())
+
+		_, err := svc.List(alice)
+
+		assert.NoError(t, err)
+		assert.NoError(t, replicaMock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByID() reads from the replica only when stale reads are allowed", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnRows(rows())
+		replicaMock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").WillReturnRows(rows())
+
+		_, err := svc.GetByID(alice, 1)
+		assert.NoError(t, err)
+		_, err = svc.GetByID(AllowStale(alice), 1)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.NoError(t, replicaMock.ExpectationsWereMet())
+	})
+
+	t.Run("List() reads the writes of the principal from the primary", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).WillReturnRows(rows())
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WillReturnRows(rows())
+		replicaMock.ExpectQuery(`SELECT (.+) FROM expenses`).WillReturnRows(rows())
+
+		_, err := svc.Save(alice, &Expense{Amount: 75, Title: "Tea"})
+		assert.NoError(t, err)
+		_, err = svc.List(alice)
+		assert.NoError(t, err)
+		_, err = svc.List(bob)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.NoError(t, replicaMock.ExpectationsWereMet())
+	})
+
+	t.Run("List() falls back to the primary when the replica fails", func(t *testing.T) {
+		replicaMock.ExpectQuery(`SELECT (.+) FROM expenses`).WillReturnError(&pq.Error{Code: "57P01"})
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WillReturnRows(rows())
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WillReturnRows(rows())
+
+		_, err := svc.List(bob)
+		assert.NoError(t, err)
+		_, err = svc.List(bob)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.NoError(t, replicaMock.ExpectationsWereMet())
+	})
+	t.Run("List() falls back to the primary when the replica times out", func(t *testing.T) {
+		svc := NewService(db)
+		svc.SetReadPolicy(postgres.ReadPolicy{Timeout: 10 * time.Millisecond})
+		svc.SetReplica(postgres.NewReplica(replicaDB, time.Minute))
+		replicaMock.ExpectQuery(`SELECT (.+) FROM expenses`).WillDelayFor(time.Second).WillReturnRows(rows())
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WillReturnRows(rows())
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WillReturnRows(rows())
+
+		_, err := svc.List(bob)
+		assert.NoError(t, err)
+		_, err = svc.List(bob)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.NoError(t, replicaMock.ExpectationsWereMet())
+	})
+}
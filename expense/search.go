@@ -0,0 +1,219 @@
+package expense
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ErrQueryEmpty is returned when a search query has no terms.
+var ErrQueryEmpty = errors.New("empty search query")
+
+// Search modes.
+const (
+	// SearchFullText uses the search tsvector column of expenses.
+	SearchFullText = "fulltext"
+	// SearchILike matches title and note with ILIKE. It needs no index and
+	// works on databases without full-text search.
+	SearchILike = "ilike"
+)
+
+// SearchResult is an expense that matched a search query. Snippet is an
+// HTML-escaped excerpt of the title and note with matches wrapped in <b>.
+type SearchResult struct {
+	Expense
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// SearchQuery is a parsed web-search query: bare words and quoted phrases
+// must all match, and words or phrases prefixed with "-" must not.
+type SearchQuery struct {
+	Terms    []string
+	Excluded []string
+}
+
+var searchToken = regexp.MustCompile(`(-?)(?:"([^"]*)"?|(\S+))`)
+
+// ParseSearchQuery parses q the way websearch_to_tsquery does, except that
+// OR is treated as a plain word.
+func ParseSearchQuery(q string) SearchQuery {
+	var sq SearchQuery
+	for _, m := range searchToken.FindAllStringSubmatch(q, -1) {
+		term := strings.Join(strings.Fields(m[2]+m[3]), " ")
+		if term == "" || term == "-" {
+			continue
+		}
+		if m[1] == "-" {
+			sq.Excluded = append(sq.Excluded, term)
+		} else {
+			sq.Terms = append(sq.Terms, term)
+		}
+	}
+	return sq
+}
+
+// Match reports whether text contains every term and no excluded term,
+// ignoring case. It is the in-memory equivalent of SearchILike.
+func (q SearchQuery) Match(text string) bool {
+	text = strings.ToLower(text)
+	for _, t := range q.Terms {
+		if !strings.Contains(text, strings.ToLower(t)) {
+			return false
+		}
+	}
+	for _, t := range q.Excluded {
+		if strings.Contains(text, strings.ToLower(t)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Highlight HTML-escapes text and wraps every occurrence of a term in <b>.
+func (q SearchQuery) Highlight(text string) string {
+	if len(q.Terms) == 0 {
+		return html.EscapeString(text)
+	}
+	quoted := make([]string, 0, len(q.Terms))
+	for _, t := range q.Terms {
+		quoted = append(quoted, regexp.QuoteMeta(t))
+	}
+	re := regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		b.WriteString("<b>")
+		b.WriteString(html.EscapeString(text[loc[0]:loc[1]]))
+		b.WriteString("</b>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
+
+// SetSearchMode selects how Search matches expenses. The default is
+// SearchFullText.
+func (s *Service) SetSearchMode(mode string) error {
+	switch mode {
+	case SearchFullText, SearchILike:
+		s.searchMode = mode
+		return nil
+	}
+	return fmt.Errorf("unknown search mode %q", mode)
+}
+
+// Search returns up to limit expenses whose title or note match q, best
+// matches first.
+func (s *Service) Search(ctx context.Context, q string, limit uint64) ([]SearchResult, error) {
+	parsed := ParseSearchQuery(q)
+	if len(parsed.Terms) == 0 {
+		return nil, ErrQueryEmpty
+	}
+
+	if s.searchMode == SearchILike {
+		var rs []SearchResult
+		err := s.read(ctx, func(ctx context.Context, db querier) (err error) {
+			rs, err = searchExpensesILike(ctx, db, parsed, limit)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("searchExpensesILike(): %w", err)
+		}
+		return rs, nil
+	}
+	var rs []SearchResult
+	err := s.read(ctx, func(ctx context.Context, db querier) (err error) {
+		rs, err = searchExpenses(ctx, db, q, limit)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("searchExpenses(): %w", err)
+	}
+	return rs, nil
+}
+
+// searchConfig is the text search configuration of the search column.
+const searchConfig = "english"
+
+func searchExpenses(ctx context.Context, db querier, q string, limit uint64) ([]SearchResult, error) {
+	query, args, err := sq.Select(expenseColumns...).
+		Column("ts_rank(search, query) AS rank").
+		Column(`ts_headline('`+searchConfig+`', coalesce(title, '') || ' ' || coalesce(note, ''), query,
+		  'StartSel=<b>, StopSel=</b>, MaxFragments=2, MinWords=5, MaxWords=20')`).
+		From("expenses").
+		JoinClause("CROSS JOIN websearch_to_tsquery('"+searchConfig+"', ?) AS query", q).
+		Where(sq.Eq{"deleted_at": nil}).
+		Where(inTenant(ctx)).
+		Where("search @@ query").
+		OrderBy("rank DESC", "id DESC").
+		Limit(limit).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rs := make([]SearchResult, 0)
+	for rows.Next() {
+		var r SearchResult
+		e, err := scanExpense(func(dest ...any) error {
+			return rows.Scan(append(dest, &r.Rank, &r.Snippet)...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		r.Expense = e
+		rs = append(rs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func searchExpensesILike(ctx context.Context, db querier, q SearchQuery, limit uint64) ([]SearchResult, error) {
+	qb := sq.Select(expenseColumns...).
+		From("expenses").
+		Where(sq.Eq{"deleted_at": nil}).
+		Where(inTenant(ctx)).
+		OrderBy("id DESC").
+		Limit(limit).
+		PlaceholderFormat(sq.Dollar)
+	for _, t := range q.Terms {
+		p := "%" + likeEscaper.Replace(t) + "%"
+		qb = qb.Where(sq.Or{sq.ILike{"title": p}, sq.ILike{"note": p}})
+	}
+	for _, t := range q.Excluded {
+		p := "%" + likeEscaper.Replace(t) + "%"
+		qb = qb.Where(sq.And{sq.NotILike{"title": p}, sq.NotILike{"note": p}})
+	}
+
+	exps, err := queryExpenses(ctx, db, qb)
+	if err != nil {
+		return nil, err
+	}
+	rs := make([]SearchResult, 0, len(exps))
+	for _, e := range exps {
+		rs = append(rs, SearchResult{
+			Expense: e,
+			Snippet: q.Highlight(strings.TrimSpace(e.Title + " " + e.Note)),
+		})
+	}
+	return rs, nil
+}
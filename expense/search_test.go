@@ -0,0 +1,99 @@
+package expense
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want SearchQuery
+	}{
+		{`taxi bangkok`, SearchQuery{Terms: []string{"taxi", "bangkok"}}},
+		{`"taxi  to bangkok" -airport`, SearchQuery{Terms: []string{"taxi to bangkok"}, Excluded: []string{"airport"}}},
+		{`taxi -"night market"`, SearchQuery{Terms: []string{"taxi"}, Excluded: []string{"night market"}}},
+		{`"unterminated phrase`, SearchQuery{Terms: []string{"unterminated phrase"}}},
+		{` - "" `, SearchQuery{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseSearchQuery(tt.q))
+		})
+	}
+}
+
+func TestSearchQueryMatch(t *testing.T) {
+	q := ParseSearchQuery(`taxi "to Bangkok" -airport`)
+
+	assert.True(t, q.Match("Taxi to bangkok from the hotel"))
+	assert.False(t, q.Match("Taxi to the airport in Bangkok"))
+	assert.False(t, q.Match("Taxi to Bangkok airport"))
+}
+
+func TestSearchQueryHighlight(t *testing.T) {
+	q := ParseSearchQuery(`taxi bangkok`)
+
+	got := q.Highlight("Taxi <Bangkok> & taxi")
+
+	assert.Equal(t, "<b>Taxi</b> &lt;<b>Bangkok</b>&gt; &amp; <b>taxi</b>", got)
+}
+
+func TestServiceSearch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	columns := append(append([]string(nil), expenseColumns...), "rank", "snippet")
+
+	t.Run("Search() uses full-text search", func(t *testing.T) {
+		svc := NewService(db)
+		mock.ExpectQuery(`SELECT (.+) ts_rank(.+) FROM expenses CROSS JOIN websearch_to_tsquery\('english', \$1\) (.+) ORDER BY rank DESC, id DESC LIMIT 20`).
+			WithArgs(`taxi -airport`, "").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(3, 250, "Taxi", "to Bangkok", pq.Array([]string{"travel"}), "", nil, 0, nil, "draft", nil, 0.6, "<b>Taxi</b> to Bangkok"))
+
+		rs, err := svc.Search(ctx, `taxi -airport`, 20)
+
+		if assert.NoError(t, err) && assert.Len(t, rs, 1) {
+			assert.Equal(t, int64(3), rs[0].ID)
+			assert.Equal(t, 0.6, rs[0].Rank)
+			assert.Equal(t, "<b>Taxi</b> to Bangkok", rs[0].Snippet)
+		}
+	})
+
+	t.Run("Search() falls back to ILIKE", func(t *testing.T) {
+		svc := NewService(db)
+		assert.NoError(t, svc.SetSearchMode(SearchILike))
+		mock.ExpectQuery(`SELECT (.+) FROM expenses WHERE (.+) \(title ILIKE \$2 OR note ILIKE \$3\) AND \(title NOT ILIKE \$4 AND note NOT ILIKE \$5\)`).
+			WithArgs("", `%100\%%`, `%100\%%`, "%airport%", "%airport%").
+			WillReturnRows(sqlmock.NewRows(expenseColumns).
+				AddRow(3, 250, "Taxi", "100% fare", pq.Array([]string{}), "", nil, 0, nil, "draft", nil))
+
+		rs, err := svc.Search(ctx, `100% -airport`, 20)
+
+		if assert.NoError(t, err) && assert.Len(t, rs, 1) {
+			assert.Equal(t, "Taxi <b>100%</b> fare", rs[0].Snippet)
+		}
+	})
+
+	t.Run("Search() returns ErrQueryEmpty", func(t *testing.T) {
+		_, err := NewService(db).Search(ctx, ` -taxi `, 20)
+
+		assert.ErrorIs(t, err, ErrQueryEmpty)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetSearchMode() returns unknown mode", func(t *testing.T) {
+		err := NewService(db).SetSearchMode("regex")
+
+		assert.EqualError(t, err, `unknown search mode "regex"`)
+	})
+}
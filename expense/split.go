@@ -0,0 +1,157 @@
+package expense
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrSplitPayerEmpty is returned when a split has no payer.
+var ErrSplitPayerEmpty = errors.New("split must have a payer")
+
+// ErrSplitParticipantsEmpty is returned when a split has no participants.
+var ErrSplitParticipantsEmpty = errors.New("split must have at least one participant")
+
+// ErrSplitParticipantInvalid is returned when a participant has no name or
+// appears twice.
+var ErrSplitParticipantInvalid = errors.New("participant names must be unique and not empty")
+
+// ErrSplitMethodInvalid is returned when the split method is unknown.
+var ErrSplitMethodInvalid = errors.New("split method must be one of equal, percentage or exact")
+
+// ErrSplitSharesInvalid is returned when the shares of a split do not add up
+// to 100 percent or to the amount of the expense.
+var ErrSplitSharesInvalid = errors.New("split shares must sum to the amount")
+
+type SplitMethod string
+
+const (
+	SplitEqual      SplitMethod = "equal"
+	SplitPercentage SplitMethod = "percentage"
+	SplitExact      SplitMethod = "exact"
+)
+
+// Split records that PaidBy paid an expense on behalf of the participants.
+// The payer owes their own share only when they are a participant.
+type Split struct {
+	PaidBy       string        `json:"paid_by"`
+	Method       SplitMethod   `json:"method"`
+	Participants []Participant `json:"participants"`
+}
+
+// Participant owes a share of a split expense. Percent is used by
+// SplitPercentage and Amount by SplitExact.
+type Participant struct {
+	Name    string  `json:"name"`
+	Percent float64 `json:"percent,omitempty"`
+	Amount  float64 `json:"amount,omitempty"`
+}
+
+// Validate checks the split of an expense of amount.
+func (s *Split) Validate(amount float64) error {
+	if s.PaidBy == "" {
+		return ErrSplitPayerEmpty
+	}
+	if len(s.Participants) == 0 {
+		return ErrSplitParticipantsEmpty
+	}
+	seen := make(map[string]bool, len(s.Participants))
+	for _, p := range s.Participants {
+		if p.Name == "" || seen[p.Name] {
+			return ErrSplitParticipantInvalid
+		}
+		seen[p.Name] = true
+	}
+
+	if s.Method == "" {
+		s.Method = SplitEqual
+	}
+	var sum, want float64
+	switch s.Method {
+	case SplitEqual:
+		return nil
+	case SplitPercentage:
+		for _, p := range s.Participants {
+			if p.Percent <= 0 {
+				return ErrSplitSharesInvalid
+			}
+			sum += p.Percent
+		}
+		want = 100
+	case SplitExact:
+		for _, p := range s.Participants {
+			if p.Amount <= 0 {
+				return ErrSplitSharesInvalid
+			}
+			sum += p.Amount
+		}
+		want = amount
+	default:
+		return ErrSplitMethodInvalid
+	}
+	if toCents(sum) != toCents(want) {
+		return ErrSplitSharesInvalid
+	}
+	return nil
+}
+
+// Shares divides total between the participants in proportion to their
+// shares, in the order of Participants. The shares are rounded to cents and
+// always sum to total; leftover cents go to the largest remainders.
+func (s *Split) Shares(total float64) []float64 {
+	weights := make([]float64, len(s.Participants))
+	for i, p := range s.Participants {
+		switch s.Method {
+		case SplitPercentage:
+			weights[i] = p.Percent
+		case SplitExact:
+			weights[i] = p.Amount
+		default:
+			weights[i] = 1
+		}
+	}
+
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+	cents := toCents(total)
+	shares := make([]int64, len(weights))
+	rems := make([]float64, len(weights))
+	var allocated int64
+	for i, w := range weights {
+		exact := float64(cents) * w / sum
+		shares[i] = int64(math.Floor(exact))
+		rems[i] = exact - float64(shares[i])
+		allocated += shares[i]
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return rems[order[i]] > rems[order[j]] })
+	for i := 0; allocated < cents; i++ {
+		shares[order[i%len(order)]]++
+		allocated++
+	}
+
+	out := make([]float64, len(shares))
+	for i, c := range shares {
+		out[i] = float64(c) / 100
+	}
+	return out
+}
+
+// Value implements driver.Valuer. A nil split is stored as NULL.
+func (s *Split) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+func toCents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
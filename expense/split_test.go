@@ -0,0 +1,80 @@
+package expense
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitValidate(t *testing.T) {
+	t.Run("ErrSplitPayerEmpty", func(t *testing.T) {
+		s := &Split{Participants: []Participant{{Name: "Ana"}}}
+
+		assert.ErrorIs(t, s.Validate(100), ErrSplitPayerEmpty)
+	})
+
+	t.Run("ErrSplitParticipantInvalid", func(t *testing.T) {
+		s := &Split{PaidBy: "Ana", Participants: []Participant{{Name: "Bo"}, {Name: "Bo"}}}
+
+		assert.ErrorIs(t, s.Validate(100), ErrSplitParticipantInvalid)
+	})
+
+	t.Run("ErrSplitMethodInvalid", func(t *testing.T) {
+		s := &Split{PaidBy: "Ana", Method: "shares", Participants: []Participant{{Name: "Bo"}}}
+
+		assert.ErrorIs(t, s.Validate(100), ErrSplitMethodInvalid)
+	})
+
+	t.Run("ErrSplitSharesInvalid when percentages do not sum to 100", func(t *testing.T) {
+		s := &Split{PaidBy: "Ana", Method: SplitPercentage, Participants: []Participant{
+			{Name: "Ana", Percent: 50},
+			{Name: "Bo", Percent: 40},
+		}}
+
+		assert.ErrorIs(t, s.Validate(100), ErrSplitSharesInvalid)
+	})
+
+	t.Run("ErrSplitSharesInvalid when amounts do not sum to the amount", func(t *testing.T) {
+		s := &Split{PaidBy: "Ana", Method: SplitExact, Participants: []Participant{
+			{Name: "Ana", Amount: 50},
+			{Name: "Bo", Amount: 40},
+		}}
+
+		assert.ErrorIs(t, s.Validate(100), ErrSplitSharesInvalid)
+	})
+
+	t.Run("Validate() defaults to an equal split", func(t *testing.T) {
+		s := &Split{PaidBy: "Ana", Participants: []Participant{{Name: "Ana"}, {Name: "Bo"}}}
+
+		if assert.NoError(t, s.Validate(100)) {
+			assert.Equal(t, SplitEqual, s.Method)
+		}
+	})
+}
+
+func TestSplitShares(t *testing.T) {
+	t.Run("Shares() gives leftover cents to the first participants", func(t *testing.T) {
+		s := &Split{Method: SplitEqual, Participants: []Participant{{Name: "Ana"}, {Name: "Bo"}, {Name: "Chai"}}}
+
+		assert.Equal(t, []float64{33.34, 33.33, 33.33}, s.Shares(100))
+	})
+
+	t.Run("Shares() divides by percentage", func(t *testing.T) {
+		s := &Split{Method: SplitPercentage, Participants: []Participant{
+			{Name: "Ana", Percent: 70},
+			{Name: "Bo", Percent: 30},
+		}}
+
+		assert.Equal(t, []float64{7, 3}, s.Shares(10))
+	})
+
+	t.Run("Shares() scales exact amounts to the total", func(t *testing.T) {
+		s := &Split{Method: SplitExact, Participants: []Participant{
+			{Name: "Ana", Amount: 30000},
+			{Name: "Bo", Amount: 20000},
+		}}
+
+		assert.Equal(t, []float64{30000, 20000}, s.Shares(50000))
+		assert.Equal(t, []float64{48, 32}, s.Shares(80))
+	})
+}
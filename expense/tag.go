@@ -0,0 +1,111 @@
+package expense
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/txn"
+)
+
+// Tagger adds tags to expenses before they are saved.
+type Tagger interface {
+	Tag(ctx context.Context, e *Expense) error
+}
+
+// SetTagger makes Save tag every new expense with t.
+func (s *Service) SetTagger(t Tagger) {
+	s.tagger = t
+}
+
+// Tag computes the BaseAmount of e and adds the tags of the tagger of the
+// service to it, as Save does, so that importers can show them before saving.
+func (s *Service) Tag(ctx context.Context, e *Expense) error {
+	if err := s.convert(ctx, e); err != nil {
+		return fmt.Errorf("convert(): %w", err)
+	}
+	return s.tag(ctx, e)
+}
+
+// tag adds the tags of the tagger of the service to e. Taggers compare
+// amounts in the base currency, so e must have been converted.
+func (s *Service) tag(ctx context.Context, e *Expense) error {
+	if s.tagger == nil {
+		return nil
+	}
+	return s.tagger.Tag(ctx, e)
+}
+
+// AddTags adds the tags the expense does not have yet and records an
+// EventUpdated in the same transaction. Unlike Update it is allowed in every
+// status, as tags do not change what was approved.
+func (s *Service) AddTags(ctx context.Context, id int64, tags []string) (*Expense, error) {
+	var exp, prev *Expense
+	err := s.inTx(ctx, func(db querier) error {
+		var err error
+		exp, err = lockExpenseByID(ctx, db, id)
+		if err != nil {
+			return fmt.Errorf("lockExpenseByID(%d): %w", id, err)
+		}
+		old := *exp
+		prev = &old
+		exp.Tags = MergeTags(exp.Tags, tags)
+		if len(exp.Tags) == len(prev.Tags) {
+			prev = nil
+			return nil
+		}
+		if err := updateTags(ctx, db, id, exp.Tags); err != nil {
+			return fmt.Errorf("updateTags(%d): %w", id, err)
+		}
+		if err := createEvent(ctx, db, EventUpdated, exp); err != nil {
+			return fmt.Errorf("createEvent(): %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		txn.AfterCommit(ctx, func() {
+			s.invalidate(id)
+			s.notify(ctx, prev, exp)
+		})
+	}
+	return exp, nil
+}
+
+// MergeTags returns tags followed by those of add it does not contain.
+func MergeTags(tags, add []string) []string {
+	merged := append(make([]string, 0, len(tags)+len(add)), tags...)
+	for _, t := range add {
+		if !hasTag(merged, t) {
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func updateTags(ctx context.Context, db querier, id int64, tags []string) error {
+	query, args, err := sq.Update("expenses").
+		Set("tags", pq.Array(tags)).
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"id": id}).
+		Where(inTenant(ctx)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
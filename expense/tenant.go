@@ -0,0 +1,34 @@
+package expense
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx that carries the tenant of the caller.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant stored in ctx by WithTenant, or an
+// empty string for the default tenant.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// inTenant selects the expenses of the tenant of ctx. Every query of
+// expenses is narrowed by it, so that a caller never sees or changes the
+// expenses of another tenant.
+func inTenant(ctx context.Context) sq.Eq {
+	return sq.Eq{"tenant_id": TenantFromContext(ctx)}
+}
+
+// ofTenant selects the rows of other tables that belong to an expense of the
+// tenant of ctx.
+func ofTenant(ctx context.Context) sq.Sqlizer {
+	return sq.Expr("expense_id IN (SELECT id FROM expenses WHERE tenant_id = ?)", TenantFromContext(ctx))
+}
@@ -0,0 +1,5 @@
+// Package expensepb contains the generated protobuf and gRPC code for
+// proto/expense/v1/expense.proto.
+package expensepb
+
+//go:generate buf generate --template ../buf.gen.yaml -o .. ../proto
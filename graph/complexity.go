@@ -0,0 +1,98 @@
+package graph
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// ErrTooComplex is returned when a query exceeds the complexity limit.
+var ErrTooComplex = errors.New("query is too complex")
+
+// Complexity returns the cost of the operation named operationName in doc, or
+// of its only operation when operationName is empty. Every field costs one,
+// and the cost of the selections of a paginated field is multiplied by its
+// "first" argument, so a query's cost bounds the number of objects it can
+// return.
+func Complexity(doc *ast.Document, operationName string, variables map[string]any) int {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	var op *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				op = def
+			}
+		}
+	}
+	if op == nil {
+		return 0
+	}
+	c := complexity{fragments: fragments, variables: variables, visiting: make(map[string]bool)}
+	return c.selectionSet(op.SelectionSet)
+}
+
+type complexity struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	visiting  map[string]bool
+}
+
+func (c *complexity) selectionSet(set *ast.SelectionSet) int {
+	if set == nil {
+		return 0
+	}
+	cost := 0
+	for _, sel := range set.Selections {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			cost += 1 + c.multiplier(sel)*c.selectionSet(sel.SelectionSet)
+		case *ast.InlineFragment:
+			cost += c.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			name := sel.Name.Value
+			if f, ok := c.fragments[name]; ok && !c.visiting[name] {
+				c.visiting[name] = true
+				cost += c.selectionSet(f.SelectionSet)
+				c.visiting[name] = false
+			}
+		}
+	}
+	return cost
+}
+
+func (c *complexity) multiplier(f *ast.Field) int {
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+				return n
+			}
+		case *ast.Variable:
+			if n, ok := toInt(c.variables[v.Name.Value]); ok && n > 0 {
+				return n
+			}
+		}
+		return 1
+	}
+	if f.Name.Value == "expenses" {
+		return DefaultPageSize
+	}
+	return 1
+}
+
+func toInt(v any) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
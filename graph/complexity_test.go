@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComplexity(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      int
+	}{
+		{
+			name:  "scalar fields",
+			query: `{ expense(id: 1) { id title } }`,
+			want:  3,
+		},
+		{
+			name:  "first multiplies the selections",
+			query: `{ expenses(first: 10) { edges { node { id } } } }`,
+			want:  1 + 10*3,
+		},
+		{
+			name:      "first from a variable",
+			query:     `query ($n: Int) { expenses(first: $n) { edges { node { id } } } }`,
+			variables: map[string]any{"n": float64(5)},
+			want:      1 + 5*3,
+		},
+		{
+			name:  "default page size",
+			query: `{ expenses { pageInfo { hasNextPage } } }`,
+			want:  1 + DefaultPageSize*2,
+		},
+		{
+			name:  "fragments",
+			query: `{ a: expense(id: 1) { ...f } b: expense(id: 2) { ...f } } fragment f on Expense { id amount }`,
+			want:  6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := Complexity(doc, "", tt.variables)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
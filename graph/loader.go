@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/phuangpheth/assessment/expense"
+)
+
+// FetchFunc loads the expenses with the given IDs in one round trip.
+type FetchFunc func(ctx context.Context, ids []int64) (map[int64]*expense.Expense, error)
+
+// Loader batches the expense lookups of one request. Load only queues the ID;
+// the first thunk that is called fetches every ID queued so far, in
+// ascending order, in a single FetchFunc call. Results are cached for the
+// lifetime of the Loader.
+type Loader struct {
+	fetch FetchFunc
+
+	mu      sync.Mutex
+	pending *batch
+	cache   map[int64]*batch
+}
+
+type batch struct {
+	ids  []int64
+	once sync.Once
+	res  map[int64]*expense.Expense
+	err  error
+}
+
+func NewLoader(fetch FetchFunc) *Loader {
+	return &Loader{
+		fetch: fetch,
+		cache: make(map[int64]*batch),
+	}
+}
+
+// Load queues id and returns a thunk that resolves it. The thunk returns
+// expense.ErrNotFound when the expense does not exist.
+func (l *Loader) Load(ctx context.Context, id int64) func() (*expense.Expense, error) {
+	l.mu.Lock()
+	b, ok := l.cache[id]
+	if !ok {
+		if l.pending == nil {
+			l.pending = &batch{}
+		}
+		b = l.pending
+		b.ids = append(b.ids, id)
+		l.cache[id] = b
+	}
+	l.mu.Unlock()
+
+	return func() (*expense.Expense, error) {
+		b.once.Do(func() {
+			l.mu.Lock()
+			if l.pending == b {
+				l.pending = nil
+			}
+			l.mu.Unlock()
+			sort.Slice(b.ids, func(i, j int) bool { return b.ids[i] < b.ids[j] })
+			b.res, b.err = l.fetch(ctx, b.ids)
+		})
+		if b.err != nil {
+			return nil, b.err
+		}
+		e, ok := b.res[id]
+		if !ok {
+			return nil, expense.ErrNotFound
+		}
+		return e, nil
+	}
+}
+
+type loaderKey struct{}
+
+func withLoader(ctx context.Context, l *Loader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, l)
+}
+
+func loaderFromContext(ctx context.Context) *Loader {
+	l, _ := ctx.Value(loaderKey{}).(*Loader)
+	return l
+}
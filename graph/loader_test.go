@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoader(t *testing.T) {
+	var calls [][]int64
+	l := NewLoader(func(ctx context.Context, ids []int64) (map[int64]*expense.Expense, error) {
+		calls = append(calls, ids)
+		m := make(map[int64]*expense.Expense)
+		for _, id := range ids {
+			if id != 3 {
+				m[id] = &expense.Expense{ID: id}
+			}
+		}
+		return m, nil
+	})
+	ctx := context.Background()
+
+	t.Run("Load() batches queued IDs", func(t *testing.T) {
+		a := l.Load(ctx, 1)
+		b := l.Load(ctx, 2)
+		again := l.Load(ctx, 1)
+
+		e, err := b()
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(2), e.ID)
+		}
+		e, err = a()
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), e.ID)
+		}
+		_, err = again()
+		assert.NoError(t, err)
+		assert.Equal(t, [][]int64{{1, 2}}, calls)
+	})
+
+	t.Run("Load() returns cached expenses", func(t *testing.T) {
+		_, err := l.Load(ctx, 2)()
+
+		assert.NoError(t, err)
+		assert.Len(t, calls, 1)
+	})
+
+	t.Run("Load() returns not found", func(t *testing.T) {
+		_, err := l.Load(ctx, 3)()
+
+		assert.ErrorIs(t, err, expense.ErrNotFound)
+		assert.Equal(t, []int64{3}, calls[1])
+	})
+}
@@ -0,0 +1,424 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/phuangpheth/assessment/expense"
+)
+
+// DefaultPageSize is the page size of expenses when first is not given.
+const DefaultPageSize = 20
+
+// MaxPageSize is the largest page of expenses that can be requested.
+const MaxPageSize = 100
+
+// DefaultMaxComplexity is used by NewSchema when maxComplexity is zero.
+const DefaultMaxComplexity = 1000
+
+// ErrCursorInvalid is returned when the after cursor was not returned by
+// expenses.
+var ErrCursorInvalid = errors.New("invalid cursor")
+
+// ErrFirstInvalid is returned when first is out of range.
+var ErrFirstInvalid = errors.New("first must be between 1 and 100")
+
+// Request is a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Authorizer returns an error when the caller in ctx may not run an
+// operation of the given type, "query" or "mutation".
+type Authorizer func(ctx context.Context, operation string) error
+
+// Schema executes GraphQL requests against expense.Service.
+type Schema struct {
+	schema        graphql.Schema
+	expenseSvc    *expense.Service
+	maxComplexity int
+	authorize     Authorizer
+}
+
+func NewSchema(svc *expense.Service, maxComplexity int) (*Schema, error) {
+	if svc == nil {
+		return nil, errors.New("invalid argument")
+	}
+	if maxComplexity <= 0 {
+		maxComplexity = DefaultMaxComplexity
+	}
+	s := &Schema{
+		expenseSvc:    svc,
+		maxComplexity: maxComplexity,
+	}
+	schema, err := s.build()
+	if err != nil {
+		return nil, err
+	}
+	s.schema = schema
+	return s, nil
+}
+
+// SetAuthorizer makes Do check every operation with a before it is executed.
+func (s *Schema) SetAuthorizer(a Authorizer) {
+	s.authorize = a
+}
+
+// Do parses, validates and executes req. Queries whose Complexity exceeds
+// the limit are rejected before anything is resolved.
+func (s *Schema) Do(ctx context.Context, req Request) *graphql.Result {
+	src := source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})
+	doc, err := parser.Parse(parser.ParseParams{Source: src})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if vr := graphql.ValidateDocument(&s.schema, doc, nil); !vr.IsValid {
+		return &graphql.Result{Errors: vr.Errors}
+	}
+	if c := Complexity(doc, req.OperationName, req.Variables); c > s.maxComplexity {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(ErrTooComplex)}
+	}
+	if s.authorize != nil {
+		if err := s.authorize(ctx, operationType(doc, req.OperationName)); err != nil {
+			return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+		}
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoader(ctx, NewLoader(s.expenseSvc.GetByIDs)),
+	})
+}
+
+func (s *Schema) build() (graphql.Schema, error) {
+	expenseType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Expense",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.ID),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return strconv.FormatInt(p.Source.(*expense.Expense).ID, 10), nil
+				},
+			},
+			"amount": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"title":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"note":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"tags": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					if tags := p.Source.(*expense.Expense).Tags; tags != nil {
+						return tags, nil
+					}
+					return []string{}, nil
+				},
+			},
+			"currency": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"spentOn": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*expense.Expense).SpentOn, nil
+				},
+			},
+			"baseAmount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*expense.Expense).BaseAmount, nil
+				},
+			},
+		},
+	})
+
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ExpenseEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(expenseType)},
+		},
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	connectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ExpenseConnection",
+		Fields: graphql.Fields{
+			"edges":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType)))},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+		},
+	})
+
+	groupType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "SummaryGroup",
+		Fields: graphql.Fields{
+			"key":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"count":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"total":    &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"currency": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	groupByType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "SummaryGroupBy",
+		Values: graphql.EnumValueConfigMap{
+			"TAG":   &graphql.EnumValueConfig{Value: expense.GroupByTag},
+			"MONTH": &graphql.EnumValueConfig{Value: expense.GroupByMonth},
+		},
+	})
+
+	filterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ExpenseFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"tag":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"title":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"minAmount": &graphql.InputObjectFieldConfig{Type: graphql.Float},
+			"maxAmount": &graphql.InputObjectFieldConfig{Type: graphql.Float},
+		},
+	})
+
+	inputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ExpenseInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"amount":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Float)},
+			"title":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"note":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"tags":     &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"currency": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"spentOn":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"expense": &graphql.Field{
+				Type: expenseType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: s.resolveExpense,
+			},
+			"expenses": &graphql.Field{
+				Type: graphql.NewNonNull(connectionType),
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: filterType},
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: DefaultPageSize},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: s.resolveExpenses,
+			},
+			"summary": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(groupType))),
+				Args: graphql.FieldConfigArgument{
+					"groupBy":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(groupByType)},
+					"currency": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					currency, _ := p.Args["currency"].(string)
+					return s.expenseSvc.Summary(p.Context, p.Args["groupBy"].(string), currency)
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createExpense": &graphql.Field{
+				Type: graphql.NewNonNull(expenseType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(inputType)},
+					// force saves the expense even when it is a likely
+					// duplicate.
+					"force": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+				},
+				Resolve: s.resolveCreateExpense,
+			},
+			"updateExpense": &graphql.Field{
+				Type: graphql.NewNonNull(expenseType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(inputType)},
+				},
+				Resolve: s.resolveUpdateExpense,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+type edge struct {
+	Cursor string           `json:"cursor"`
+	Node   *expense.Expense `json:"node"`
+}
+
+type pageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+type connection struct {
+	Edges    []edge   `json:"edges"`
+	PageInfo pageInfo `json:"pageInfo"`
+}
+
+func (s *Schema) resolveExpense(p graphql.ResolveParams) (any, error) {
+	id, err := strconv.ParseInt(p.Args["id"].(string), 10, 64)
+	if err != nil {
+		return nil, expense.ErrNotFound
+	}
+	thunk := loaderFromContext(p.Context).Load(p.Context, id)
+	return func() (any, error) {
+		e, err := thunk()
+		if errors.Is(err, expense.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	}, nil
+}
+
+func (s *Schema) resolveExpenses(p graphql.ResolveParams) (any, error) {
+	first, _ := p.Args["first"].(int)
+	if first < 1 || first > MaxPageSize {
+		return nil, ErrFirstInvalid
+	}
+	var after int64
+	if cursor, ok := p.Args["after"].(string); ok {
+		var err error
+		if after, err = decodeCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	var f expense.Filter
+	if m, ok := p.Args["filter"].(map[string]any); ok {
+		f.Tag, _ = m["tag"].(string)
+		f.Title, _ = m["title"].(string)
+		if v, ok := m["minAmount"].(float64); ok {
+			f.MinAmount = &v
+		}
+		if v, ok := m["maxAmount"].(float64); ok {
+			f.MaxAmount = &v
+		}
+	}
+
+	exps, more, err := s.expenseSvc.Find(p.Context, f, uint64(first), after)
+	if err != nil {
+		return nil, err
+	}
+	conn := connection{
+		Edges:    make([]edge, 0, len(exps)),
+		PageInfo: pageInfo{HasNextPage: more},
+	}
+	for i := range exps {
+		conn.Edges = append(conn.Edges, edge{
+			Cursor: encodeCursor(exps[i].ID),
+			Node:   &exps[i],
+		})
+	}
+	if n := len(conn.Edges); n > 0 {
+		conn.PageInfo.EndCursor = &conn.Edges[n-1].Cursor
+	}
+	return conn, nil
+}
+
+func (s *Schema) resolveCreateExpense(p graphql.ResolveParams) (any, error) {
+	exp := expenseInput(p.Args["input"])
+	if err := exp.Validate(); err != nil {
+		return nil, err
+	}
+	ctx := p.Context
+	if force, _ := p.Args["force"].(bool); force {
+		ctx = expense.AllowDuplicates(ctx)
+	}
+	return s.expenseSvc.Save(ctx, exp)
+}
+
+func (s *Schema) resolveUpdateExpense(p graphql.ResolveParams) (any, error) {
+	id, err := strconv.ParseInt(p.Args["id"].(string), 10, 64)
+	if err != nil {
+		return nil, expense.ErrNotFound
+	}
+	exp := expenseInput(p.Args["input"])
+	exp.ID = id
+	if err := exp.Validate(); err != nil {
+		return nil, err
+	}
+	return s.expenseSvc.Update(p.Context, exp)
+}
+
+func expenseInput(v any) *expense.Expense {
+	m, _ := v.(map[string]any)
+	var e expense.Expense
+	e.Amount, _ = m["amount"].(float64)
+	e.Title, _ = m["title"].(string)
+	e.Note, _ = m["note"].(string)
+	e.Currency, _ = m["currency"].(string)
+	e.SpentOn, _ = m["spentOn"].(string)
+	if tags, ok := m["tags"].([]any); ok {
+		e.Tags = make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t, ok := t.(string); ok {
+				e.Tags = append(e.Tags, t)
+			}
+		}
+	}
+	return &e
+}
+
+const cursorPrefix = "expense:"
+
+func encodeCursor(id int64) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	b, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(b), cursorPrefix) {
+		return 0, ErrCursorInvalid
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(string(b), cursorPrefix), 10, 64)
+	if err != nil {
+		return 0, ErrCursorInvalid
+	}
+	return id, nil
+}
+
+// operationType returns the type of the operation named operationName in
+// doc, or of its only operation when operationName is empty.
+func operationType(doc *ast.Document, operationName string) string {
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if ok && (operationName == "" || (op.Name != nil && op.Name.Value == operationName)) {
+			return op.Operation
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaDo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	columns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
+	s, err := NewSchema(expense.NewService(db), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	do := func(req Request) string {
+		b, _ := json.Marshal(s.Do(ctx, req))
+		return string(b)
+	}
+
+	t.Run("Do() batches expense lookups", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM expenses WHERE").
+			WithArgs(1, 2, "").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, 75, "Halo Kitty", "", pq.Array([]string{"drinks"}), "", nil, 0, nil, "draft", nil).
+				AddRow(2, 30, "Milk", "", pq.Array([]string{}), "", nil, 0, nil, "draft", nil))
+		want := `{"data":{"a":{"id":"1","title":"Halo Kitty"},"b":{"id":"2","title":"Milk"},"c":{"id":"1","title":"Halo Kitty"}}}`
+
+		got := do(Request{Query: `{ a: expense(id: 1) { id title } b: expense(id: 2) { id title } c: expense(id: 1) { id title } }`})
+
+		assert.JSONEq(t, want, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Do() paginates expenses", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM expenses WHERE (.+) id < (.+) = ANY\\(tags\\) (.+) LIMIT 3").
+			WithArgs("", 10, "food").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(9, 75, "Noodles", "", pq.Array([]string{"food"}), "", nil, 0, nil, "draft", nil).
+				AddRow(8, 30, "Rice", "", pq.Array([]string{"food"}), "", nil, 0, nil, "draft", nil).
+				AddRow(7, 20, "Soup", "", pq.Array([]string{"food"}), "", nil, 0, nil, "draft", nil))
+
+		got := do(Request{
+			Query:     `query ($after: String) { expenses(first: 2, after: $after, filter: {tag: "food"}) { edges { node { id } } pageInfo { hasNextPage endCursor } } }`,
+			Variables: map[string]any{"after": encodeCursor(10)},
+		})
+
+		want := `{"data":{"expenses":{"edges":[{"node":{"id":"9"}},{"node":{"id":"8"}}],"pageInfo":{"hasNextPage":true,"endCursor":"` + encodeCursor(8) + `"}}}}`
+		assert.JSONEq(t, want, got)
+	})
+
+	t.Run("Do() returns summary", func(t *testing.T) {
+		mock.ExpectQuery("SELECT unnest\\(tags\\) AS key, (.+) FROM expenses").
+			WillReturnRows(sqlmock.NewRows([]string{"key", "count", "sum"}).
+				AddRow("drinks", 2, 105).
+				AddRow("food", 1, 50))
+
+		got := do(Request{Query: `{ summary(groupBy: TAG) { key count total } }`})
+
+		assert.JSONEq(t, `{"data":{"summary":[{"key":"drinks","count":2,"total":105},{"key":"food","count":1,"total":50}]}}`, got)
+	})
+
+	t.Run("Do() creates expense", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+			WithArgs(75.0, "Halo Kitty", "", pq.Array([]string{"drinks"}), "", sqlmock.AnyArg(), 75.0, nil, expense.StatusDraft, "", "").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 75, "Halo Kitty", "", pq.Array([]string{"drinks"}), "", nil, 0, nil, "draft", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		got := do(Request{Query: `mutation { createExpense(input: {amount: 75, title: "Halo Kitty", tags: ["drinks"]}) { id } }`})
+
+		assert.JSONEq(t, `{"data":{"createExpense":{"id":"1"}}}`, got)
+	})
+
+	t.Run("Do() creates likely duplicates with force", func(t *testing.T) {
+		svc := expense.NewService(db)
+		svc.SetDuplicateCheck(expense.DefaultDuplicateCheck)
+		s, err := NewSchema(svc, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(2, 75, "Halo Kitty", "", pq.Array([]string{"drinks"}), "", nil, 0, nil, "draft", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		got := s.Do(ctx, Request{Query: `mutation { createExpense(input: {amount: 75, title: "Halo Kitty"}, force: true) { id } }`})
+
+		assert.Empty(t, got.Errors)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Do() returns validation errors", func(t *testing.T) {
+		got := s.Do(ctx, Request{Query: `mutation { createExpense(input: {amount: 0, title: "Halo Kitty"}) { id } }`})
+
+		if assert.Len(t, got.Errors, 1) {
+			assert.Equal(t, expense.ErrAmountInvalid.Error(), got.Errors[0].Message)
+		}
+	})
+
+	t.Run("Do() rejects complex queries", func(t *testing.T) {
+		got := s.Do(ctx, Request{Query: `{ expenses(first: 100) { edges { node { id } } } }`})
+
+		if assert.Len(t, got.Errors, 1) {
+			assert.Equal(t, ErrTooComplex.Error(), got.Errors[0].Message)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
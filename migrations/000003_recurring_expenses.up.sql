@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS recurring_expenses (
+  id SERIAL PRIMARY KEY,
+  title TEXT NOT NULL,
+  amount FLOAT NOT NULL,
+  note TEXT NOT NULL DEFAULT '',
+  tags TEXT[],
+  frequency TEXT NOT NULL,
+  interval_count INT NOT NULL DEFAULT 1,
+  start_date DATE NOT NULL,
+  end_date DATE,
+  next_index INT NOT NULL DEFAULT 0,
+  next_date DATE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS recurring_expenses_next_date_idx ON recurring_expenses (next_date);
+
+CREATE TABLE IF NOT EXISTS recurring_occurrences (
+  recurring_id INT NOT NULL REFERENCES recurring_expenses (id) ON DELETE CASCADE,
+  occurs_on DATE NOT NULL,
+  expense_id INT NOT NULL REFERENCES expenses (id) ON DELETE CASCADE,
+  PRIMARY KEY (recurring_id, occurs_on)
+);
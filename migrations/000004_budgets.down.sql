@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS budgets;
+
+ALTER TABLE expenses DROP COLUMN IF EXISTS created_at;
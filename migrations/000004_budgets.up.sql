@@ -0,0 +1,13 @@
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS expenses_created_at_idx ON expenses (created_at);
+
+CREATE TABLE IF NOT EXISTS budgets (
+  id SERIAL PRIMARY KEY,
+  name TEXT NOT NULL DEFAULT '',
+  tag TEXT NOT NULL,
+  amount_limit FLOAT NOT NULL,
+  period TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS budgets_tag_idx ON budgets (tag);
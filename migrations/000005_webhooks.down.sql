@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS webhook_cursor;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS expense_events;
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS expense_events_notify ON expense_events;
+
+DROP FUNCTION IF EXISTS notify_expense_event();
+
+ALTER TABLE expense_events DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE expenses DROP COLUMN IF EXISTS deleted_at;
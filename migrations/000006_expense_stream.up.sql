@@ -0,0 +1,15 @@
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+ALTER TABLE expense_events ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+
+CREATE OR REPLACE FUNCTION notify_expense_event() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('expense_events', NEW.id::text);
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS expense_events_notify ON expense_events;
+
+CREATE TRIGGER expense_events_notify AFTER INSERT ON expense_events
+  FOR EACH ROW EXECUTE PROCEDURE notify_expense_event();
@@ -0,0 +1,4 @@
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS search tsvector
+  GENERATED ALWAYS AS (to_tsvector('english', coalesce(title, '') || ' ' || coalesce(note, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS expenses_search_idx ON expenses USING GIN (search);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE expenses DROP COLUMN IF EXISTS base_amount;
+ALTER TABLE expenses DROP COLUMN IF EXISTS spent_on;
+ALTER TABLE expenses DROP COLUMN IF EXISTS currency;
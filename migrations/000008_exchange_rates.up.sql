@@ -0,0 +1,17 @@
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT '';
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS spent_on DATE;
+UPDATE expenses SET spent_on = created_at::date WHERE spent_on IS NULL;
+ALTER TABLE expenses ALTER COLUMN spent_on SET DEFAULT CURRENT_DATE;
+ALTER TABLE expenses ALTER COLUMN spent_on SET NOT NULL;
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS base_amount FLOAT;
+UPDATE expenses SET base_amount = amount WHERE base_amount IS NULL;
+
+ALTER TABLE recurring_expenses ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+  date DATE NOT NULL,
+  base TEXT NOT NULL,
+  quote TEXT NOT NULL,
+  rate FLOAT NOT NULL CHECK (rate > 0),
+  PRIMARY KEY (date, base, quote)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS settlements;
+
+ALTER TABLE expenses DROP COLUMN IF EXISTS split;
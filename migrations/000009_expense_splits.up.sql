@@ -0,0 +1,10 @@
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS split JSONB;
+
+CREATE TABLE IF NOT EXISTS settlements (
+  id SERIAL PRIMARY KEY,
+  from_name TEXT NOT NULL,
+  to_name TEXT NOT NULL,
+  amount FLOAT NOT NULL CHECK (amount > 0),
+  note TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS expense_transitions;
+
+ALTER TABLE expenses DROP COLUMN IF EXISTS status;
@@ -0,0 +1,14 @@
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'draft';
+
+CREATE TABLE IF NOT EXISTS expense_transitions (
+  id BIGSERIAL PRIMARY KEY,
+  expense_id INT NOT NULL REFERENCES expenses (id) ON DELETE CASCADE,
+  action TEXT NOT NULL,
+  from_status TEXT NOT NULL,
+  to_status TEXT NOT NULL,
+  actor TEXT NOT NULL DEFAULT '',
+  reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS expense_transitions_expense_id_idx ON expense_transitions (expense_id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS settlements_tenant_id_idx;
+ALTER TABLE settlements DROP COLUMN IF EXISTS tenant_id;
+DROP INDEX IF EXISTS budgets_tenant_tag_idx;
+ALTER TABLE budgets DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS tenant_id;
+DROP INDEX IF EXISTS expenses_tenant_id_idx;
+ALTER TABLE expenses DROP COLUMN IF EXISTS tenant_id;
+DROP TABLE IF EXISTS role_assignments;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS role_assignments (
+  tenant_id TEXT NOT NULL DEFAULT '',
+  user_id TEXT NOT NULL,
+  role TEXT NOT NULL CHECK (role IN ('viewer', 'editor', 'approver', 'admin')),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (tenant_id, user_id, role)
+);
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS expenses_tenant_id_idx ON expenses (tenant_id, id);
+ALTER TABLE recurring_expenses ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS budgets_tenant_tag_idx ON budgets (tenant_id, tag);
+ALTER TABLE settlements ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS settlements_tenant_id_idx ON settlements (tenant_id, id);
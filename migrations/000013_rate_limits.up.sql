@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+  key TEXT PRIMARY KEY,
+  tokens DOUBLE PRECISION NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE IF NOT EXISTS rate_limit_quotas (
+  key TEXT NOT NULL,
+  day DATE NOT NULL,
+  count BIGINT NOT NULL,
+  PRIMARY KEY (key, day)
+);
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
+CREATE INDEX IF NOT EXISTS rate_limit_quotas_day_idx ON rate_limit_quotas (day);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS statement_transactions (
+  tenant_id TEXT NOT NULL DEFAULT '',
+  fingerprint TEXT NOT NULL,
+  action TEXT NOT NULL CHECK (action IN ('confirm', 'skip')),
+  expense_id INT REFERENCES expenses (id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (tenant_id, fingerprint)
+);
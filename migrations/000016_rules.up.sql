@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS rules (
+  id SERIAL PRIMARY KEY,
+  tenant_id TEXT NOT NULL DEFAULT '',
+  name TEXT NOT NULL,
+  priority INT NOT NULL DEFAULT 0,
+  conditions JSONB NOT NULL,
+  tags TEXT[] NOT NULL,
+  stop BOOLEAN NOT NULL DEFAULT false,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS rules_tenant_priority_idx ON rules (tenant_id, priority, id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS expenses_duplicate_idx;
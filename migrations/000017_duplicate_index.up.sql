@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS expenses_duplicate_idx ON expenses (currency, amount, spent_on);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS expenses_spent_on_idx;
+DROP INDEX IF EXISTS expenses_deleted_at_idx;
+DROP TABLE IF EXISTS retention_log;
+DROP TABLE IF EXISTS expenses_archive;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS expenses_archive (
+  id INT PRIMARY KEY,
+  spent_on DATE NOT NULL,
+  data JSONB NOT NULL,
+  archived_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS retention_log (
+  id BIGSERIAL PRIMARY KEY,
+  action TEXT NOT NULL CHECK (action IN ('archive', 'purge')),
+  cutoff TIMESTAMPTZ NOT NULL,
+  expense_ids INT[] NOT NULL,
+  actor TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS expenses_deleted_at_idx ON expenses (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS expenses_spent_on_idx ON expenses (spent_on);
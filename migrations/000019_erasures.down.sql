@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS expenses_archive_owner_idx;
+DROP TABLE IF EXISTS erasures;
+DROP INDEX IF EXISTS expenses_owner_idx;
+ALTER TABLE expenses DROP COLUMN IF EXISTS owner_id;
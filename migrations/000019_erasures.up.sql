@@ -0,0 +1,12 @@
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS owner_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS expenses_owner_idx ON expenses (tenant_id, owner_id);
+CREATE TABLE IF NOT EXISTS erasures (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  deleted INT NOT NULL,
+  anonymized INT NOT NULL,
+  attachments INT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS expenses_archive_owner_idx ON expenses_archive ((data->'expense'->>'tenant_id'), (data->'expense'->>'owner_id'));
@@ -0,0 +1,5 @@
+ALTER TABLE webhook_cursor DROP COLUMN IF EXISTS last_tx_id;
+
+DROP INDEX IF EXISTS expense_events_position_idx;
+
+ALTER TABLE expense_events DROP COLUMN IF EXISTS tx_id;
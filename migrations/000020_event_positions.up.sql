@@ -0,0 +1,9 @@
+ALTER TABLE expense_events ADD COLUMN IF NOT EXISTS tx_id BIGINT NOT NULL DEFAULT txid_current();
+
+CREATE INDEX IF NOT EXISTS expense_events_position_idx ON expense_events (tx_id, id);
+
+ALTER TABLE webhook_cursor ADD COLUMN IF NOT EXISTS last_tx_id BIGINT NOT NULL DEFAULT 0;
+
+-- The existing events were all given the ID of this transaction, so the
+-- cursor keeps its place among them.
+UPDATE webhook_cursor SET last_tx_id = txid_current() WHERE last_event_id > 0;
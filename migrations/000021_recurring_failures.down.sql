@@ -0,0 +1,3 @@
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS last_error;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS retry_at;
+ALTER TABLE recurring_expenses DROP COLUMN IF EXISTS owner_id;
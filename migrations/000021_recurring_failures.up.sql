@@ -0,0 +1,3 @@
+ALTER TABLE recurring_expenses ADD COLUMN IF NOT EXISTS owner_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE recurring_expenses ADD COLUMN IF NOT EXISTS retry_at TIMESTAMPTZ;
+ALTER TABLE recurring_expenses ADD COLUMN IF NOT EXISTS last_error TEXT NOT NULL DEFAULT '';
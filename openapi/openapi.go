@@ -0,0 +1,158 @@
+// Package openapi embeds the OpenAPI 3.1 document of the REST API and
+// validates requests against it.
+//
+// openapi.json is maintained by hand: a change to a route or to the JSON of
+// a type must update it too. TestRoutesOpenAPI in cmd fails when a route has
+// no operation in the document, or an operation has no route, and
+// TestOpenAPISchemas fails when a component schema and the JSON of the type
+// it describes disagree on their properties.
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+//go:embed openapi.json
+var specJSON []byte
+
+//go:embed docs.html
+var docsHTML []byte
+
+// JSON returns the OpenAPI document as served at /openapi.json.
+func JSON() []byte {
+	return specJSON
+}
+
+// DocsHTML returns a self-contained page that renders the document.
+func DocsHTML() []byte {
+	return docsHTML
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *Document
+	loadErr  error
+)
+
+// Load parses the embedded document once.
+func Load() (*Document, error) {
+	loadOnce.Do(func() {
+		var d Document
+		dec := json.NewDecoder(bytes.NewReader(specJSON))
+		if err := dec.Decode(&d); err != nil {
+			loadErr = fmt.Errorf("parse openapi.json: %w", err)
+			return
+		}
+		loaded = &d
+	})
+	return loaded, loadErr
+}
+
+// Document is the subset of an OpenAPI 3.1 document used for validation.
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+type Components struct {
+	Schemas    map[string]*Schema    `json:"schemas"`
+	Parameters map[string]*Parameter `json:"parameters"`
+}
+
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []*Parameter `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+type Parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON Schema 2020-12 that the validator supports.
+type Schema struct {
+	Ref              string             `json:"$ref"`
+	Type             Types              `json:"type"`
+	Properties       map[string]*Schema `json:"properties"`
+	Required         []string           `json:"required"`
+	Items            *Schema            `json:"items"`
+	Enum             []any              `json:"enum"`
+	Minimum          *float64           `json:"minimum"`
+	Maximum          *float64           `json:"maximum"`
+	ExclusiveMinimum *float64           `json:"exclusiveMinimum"`
+	MinLength        *int               `json:"minLength"`
+	MinItems         *int               `json:"minItems"`
+	Pattern          string             `json:"pattern"`
+	Format           string             `json:"format"`
+}
+
+// Types is the "type" keyword, which is either a string or an array.
+type Types []string
+
+func (t *Types) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = Types{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+func (t Types) has(s string) bool {
+	for _, v := range t {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Operation returns the operation for method on the path template, or nil.
+func (d *Document) Operation(method, path string) *Operation {
+	return d.Paths[path][strings.ToLower(method)]
+}
+
+var echoParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// PathTemplate converts an echo route path such as /expenses/:id to the
+// OpenAPI path template /expenses/{id}.
+func PathTemplate(path string) string {
+	return echoParam.ReplaceAllString(path, "{$1}")
+}
+
+func (d *Document) parameter(p *Parameter) *Parameter {
+	if p.Ref == "" {
+		return p
+	}
+	if r, ok := d.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]; ok {
+		return r
+	}
+	return p
+}
+
+func (d *Document) schema(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
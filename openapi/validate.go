@@ -0,0 +1,239 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// ErrBodyRequired is returned when a required request body is missing.
+var ErrBodyRequired = errors.New("request body is required")
+
+// ValidationError describes the first part of a request that does not match
+// the document.
+type ValidationError struct {
+	// Field is the parameter name or the JSON path within the body.
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidateRequest validates the parameters and JSON body of r against op.
+// pathParams are the values of the path template parameters. The body is
+// restored so that handlers can read it again.
+func (d *Document) ValidateRequest(op *Operation, r *http.Request, pathParams map[string]string) error {
+	for _, p := range op.Parameters {
+		p = d.parameter(p)
+		var (
+			v  string
+			ok bool
+		)
+		switch p.In {
+		case "path":
+			v, ok = pathParams[p.Name]
+		case "query":
+			if vs, found := r.URL.Query()[p.Name]; found && len(vs) > 0 {
+				v, ok = vs[0], true
+			}
+		case "header":
+			v = r.Header.Get(p.Name)
+			ok = v != ""
+		}
+		if !ok {
+			if p.Required {
+				return &ValidationError{Field: p.Name, Message: "is required"}
+			}
+			continue
+		}
+		if err := d.validateParam(p, v); err != nil {
+			return err
+		}
+	}
+
+	if op.RequestBody == nil {
+		return nil
+	}
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	media, ok := op.RequestBody.Content[ct]
+	if ct != "application/json" || !ok {
+		// Only JSON bodies are validated.
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			return ErrBodyRequired
+		}
+		return nil
+	}
+
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return &ValidationError{Field: "body", Message: "invalid JSON"}
+	}
+	return d.validate(media.Schema, v, "body")
+}
+
+func (d *Document) validateParam(p *Parameter, v string) error {
+	s := d.schema(p.Schema)
+	if s == nil {
+		return nil
+	}
+	var val any = v
+	switch {
+	case s.Type.has("integer"):
+		if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+			return &ValidationError{Field: p.Name, Message: "must be an integer"}
+		}
+		val = json.Number(v)
+	case s.Type.has("number"):
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return &ValidationError{Field: p.Name, Message: "must be a number"}
+		}
+		val = json.Number(v)
+	case s.Type.has("boolean"):
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return &ValidationError{Field: p.Name, Message: "must be a boolean"}
+		}
+		val = b
+	}
+	return d.validate(s, val, p.Name)
+}
+
+func (d *Document) validate(s *Schema, v any, field string) error {
+	s = d.schema(s)
+	if s == nil {
+		return nil
+	}
+	if len(s.Type) > 0 && !matchesType(s.Type, v) {
+		return &ValidationError{Field: field, Message: "must be of type " + joinTypes(s.Type)}
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		return &ValidationError{Field: field, Message: "must be one of the allowed values"}
+	}
+
+	switch v := v.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("must be >= %v", *s.Minimum)}
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("must be <= %v", *s.Maximum)}
+		}
+		if s.ExclusiveMinimum != nil && f <= *s.ExclusiveMinimum {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("must be > %v", *s.ExclusiveMinimum)}
+		}
+	case string:
+		if s.MinLength != nil && len([]rune(v)) < *s.MinLength {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("must be at least %d characters", *s.MinLength)}
+		}
+		if s.Pattern != "" {
+			re, err := regexp.Compile(s.Pattern)
+			if err == nil && !re.MatchString(v) {
+				return &ValidationError{Field: field, Message: "must match " + s.Pattern}
+			}
+		}
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("must have at least %d items", *s.MinItems)}
+		}
+		for i, item := range v {
+			if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", field, i)); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return &ValidationError{Field: field + "." + name, Message: "is required"}
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if ps, ok := s.Properties[name]; ok {
+				if err := d.validate(ps, v[name], field+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func matchesType(types Types, v any) bool {
+	for _, t := range types {
+		switch t {
+		case "null":
+			if v == nil {
+				return true
+			}
+		case "boolean":
+			if _, ok := v.(bool); ok {
+				return true
+			}
+		case "string":
+			if _, ok := v.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := v.(json.Number); ok {
+				return true
+			}
+		case "integer":
+			if n, ok := v.(json.Number); ok {
+				if _, err := n.Int64(); err == nil {
+					return true
+				}
+			}
+		case "array":
+			if _, ok := v.([]any); ok {
+				return true
+			}
+		case "object":
+			if _, ok := v.(map[string]any); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func inEnum(enum []any, v any) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinTypes(types Types) string {
+	s := types[0]
+	for _, t := range types[1:] {
+		s += " or " + t
+	}
+	return s
+}
@@ -0,0 +1,75 @@
+package openapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathTemplate(t *testing.T) {
+	got := PathTemplate("/expenses/:id/attachments/:attachmentID")
+
+	assert.Equal(t, "/expenses/{id}/attachments/{attachmentID}", got)
+}
+
+func TestDocumentValidateRequest(t *testing.T) {
+	doc, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		params map[string]string
+		body   string
+		want   string
+	}{
+		{"valid expense", "POST", "/expenses", nil, `{"amount":79,"title":"Tea","note":"","tags":null}`, ""},
+		{"amount not positive", "POST", "/expenses", nil, `{"amount":0,"title":"Tea"}`, "body.amount: must be > 0"},
+		{"empty title", "POST", "/expenses", nil, `{"amount":1,"title":""}`, "body.title: must be at least 1 characters"},
+		{"tag not a string", "POST", "/expenses", nil, `{"amount":1,"title":"Tea","tags":[1]}`, "body.tags[0]: must be of type string"},
+		{"invalid JSON", "POST", "/expenses", nil, `{"amount":`, "body: invalid JSON"},
+		{"missing body", "POST", "/expenses", nil, ``, ErrBodyRequired.Error()},
+		{"unknown enum", "POST", "/webhooks", nil, `{"url":"http://x","event_types":["expense.archived"]}`, "body.event_types[0]: must be one of the allowed values"},
+		{"nested ref", "POST", "/recurring-expenses", nil, `{"template":{"amount":1,"title":"Rent"},"schedule":{"frequency":"monthly","interval":0,"start_date":"2026-01-01T00:00:00Z"}}`, "body.schedule.interval: must be >= 1"},
+		{"invalid path param", "GET", "/expenses/{id}", map[string]string{"id": "x"}, ``, "id: must be an integer"},
+		{"optional header", "GET", "/expenses/stream", nil, ``, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := doc.Operation(tt.method, tt.path)
+			if op == nil {
+				t.Fatalf("no operation for %s %s", tt.method, tt.path)
+			}
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			err := doc.ValidateRequest(op, req, tt.params)
+
+			if tt.want == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("restores the body", func(t *testing.T) {
+		body := `{"amount":79,"title":"Tea"}`
+		req := httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		err := doc.ValidateRequest(doc.Operation("POST", "/expenses"), req, nil)
+
+		if assert.NoError(t, err) {
+			got, _ := io.ReadAll(req.Body)
+			assert.Equal(t, body, string(got))
+		}
+	})
+}
@@ -0,0 +1,149 @@
+// Package postgres opens the connection pool to Postgres, waits for the
+// database at startup and retries reads that failed for transient reasons.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/txn"
+)
+
+// Config tunes the connection pool. Zero values keep the defaults of
+// database/sql.
+type Config struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// ConnectTimeout is how long Open waits for the database to accept
+	// connections. Zero makes Open fail on the first attempt.
+	ConnectTimeout time.Duration
+}
+
+// Apply sets the pool limits of db. ConnectTimeout is not used.
+func (c Config) Apply(db *sql.DB) {
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+}
+
+// Open opens a pool to the database at url and waits until it accepts
+// connections.
+func Open(ctx context.Context, url string, cfg Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Apply(db)
+
+	if err := Wait(ctx, db, cfg.ConnectTimeout); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Wait pings db until it answers, backing off from 100ms up to 5s between
+// attempts, for at most timeout. Errors that are not transient end the wait
+// at once.
+func Wait(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := 100 * time.Millisecond
+	for {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if !IsTransient(err) && ctx.Err() == nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database is not available after %s: %w", timeout, err)
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > 5*time.Second {
+			backoff = 5 * time.Second
+		}
+	}
+}
+
+// IsTransient reports whether err is likely to go away when the operation
+// is retried: the database is starting up, shutting down, out of resources
+// or unreachable, or the connection was lost. Cancelled and timed out
+// contexts are not transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "08", // connection exception
+			"53": // insufficient resources
+			return true
+		}
+		switch pqErr.Code {
+		case "57P01", // admin shutdown
+			"57P02", // crash shutdown
+			"57P03", // cannot connect now
+			"40P01": // deadlock detected
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		(errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// ReadPolicy bounds and retries idempotent reads.
+type ReadPolicy struct {
+	// Attempts is how often a read is tried. Values below one try once.
+	Attempts int
+	// Timeout bounds each attempt, on top of the deadline of the context.
+	// Zero leaves the context as is.
+	Timeout time.Duration
+}
+
+// Do runs the read fn with a context derived from ctx, and runs it again
+// while it fails with a transient error. Reads within a unit of work are not
+// retried, as the error aborted the transaction.
+func (p ReadPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := p.try(ctx, fn)
+		if attempt >= p.Attempts || !IsTransient(err) || txn.InTx(ctx) || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * 50 * time.Millisecond):
+		}
+	}
+}
+
+func (p ReadPolicy) try(ctx context.Context, fn func(ctx context.Context) error) error {
+	if p.Timeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+	return fn(ctx)
+}
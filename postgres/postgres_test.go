@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"connection failure", &pq.Error{Code: "08006"}, true},
+		{"too many connections", &pq.Error{Code: "53300"}, true},
+		{"cannot connect now", &pq.Error{Code: "57P03"}, true},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+		{"bad connection", fmt.Errorf("query: %w", driver.ErrBadConn), true},
+		{"timeout", fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+		{"other", errors.New("failed"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransient(tt.err))
+		})
+	}
+}
+
+func TestWait(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	t.Run("Wait() pings until the database is up", func(t *testing.T) {
+		mock.ExpectPing().WillReturnError(&pq.Error{Code: "57P03"})
+		mock.ExpectPing()
+
+		err := Wait(ctx, db, time.Second)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Wait() gives up on errors that are not transient", func(t *testing.T) {
+		errAuth := &pq.Error{Code: "28P01"}
+		mock.ExpectPing().WillReturnError(errAuth)
+
+		err := Wait(ctx, db, time.Second)
+
+		assert.ErrorIs(t, err, errAuth)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Wait() gives up after the timeout", func(t *testing.T) {
+		mock.ExpectPing().WillReturnError(&pq.Error{Code: "57P03"})
+
+		err := Wait(ctx, db, 10*time.Millisecond)
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestReadPolicyDo(t *testing.T) {
+	ctx := context.Background()
+	errConn := &pq.Error{Code: "08006"}
+
+	t.Run("Do() retries transient errors", func(t *testing.T) {
+		calls := 0
+		err := ReadPolicy{Attempts: 3}.Do(ctx, func(ctx context.Context) error {
+			if calls++; calls < 3 {
+				return errConn
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Do() gives up after Attempts", func(t *testing.T) {
+		calls := 0
+		err := ReadPolicy{Attempts: 2}.Do(ctx, func(ctx context.Context) error {
+			calls++
+			return errConn
+		})
+
+		assert.ErrorIs(t, err, errConn)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Do() does not retry other errors", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		calls := 0
+		err := ReadPolicy{Attempts: 3}.Do(ctx, func(ctx context.Context) error {
+			calls++
+			return errFailed
+		})
+
+		assert.ErrorIs(t, err, errFailed)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Do() bounds each attempt by Timeout", func(t *testing.T) {
+		err := ReadPolicy{Timeout: time.Millisecond}.Do(ctx, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
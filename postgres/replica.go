@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// ReplicaCooldown is how long reads go to the primary after the replica
+// failed.
+const ReplicaCooldown = 10 * time.Second
+
+// Replica routes reads that tolerate replication lag to a read replica. A
+// principal reads from the primary for a while after it wrote, so that it
+// reads its own writes, and everyone reads from the primary for
+// ReplicaCooldown after the replica failed.
+type Replica struct {
+	db     *sql.DB
+	sticky time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	writes    map[string]time.Time
+	swept     time.Time
+	downUntil time.Time
+}
+
+// NewReplica returns a Replica that reads from db, sticking principals to
+// the primary for the window after they wrote.
+func NewReplica(db *sql.DB, window time.Duration) *Replica {
+	return &Replica{
+		db:     db,
+		sticky: window,
+		now:    time.Now,
+		writes: make(map[string]time.Time),
+	}
+}
+
+// DB returns the database of the replica.
+func (r *Replica) DB() *sql.DB {
+	return r.db
+}
+
+// Wrote records that principal wrote to the primary.
+func (r *Replica) Wrote(principal string) {
+	now := r.now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes[principal] = now
+	if now.Sub(r.swept) < r.sticky {
+		return
+	}
+	for p, at := range r.writes {
+		if now.Sub(at) >= r.sticky {
+			delete(r.writes, p)
+		}
+	}
+	r.swept = now
+}
+
+// Use reports whether a read of principal may go to the replica: the
+// replica is healthy and principal did not write within the window.
+func (r *Replica) Use(principal string) bool {
+	now := r.now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now.Before(r.downUntil) {
+		return false
+	}
+	at, ok := r.writes[principal]
+	return !ok || now.Sub(at) >= r.sticky
+}
+
+// Failed reports whether err, returned by a read from the replica, means
+// the replica is unavailable. The replica is then not used for
+// ReplicaCooldown, and the read should be run against the primary.
+func (r *Replica) Failed(err error) bool {
+	if !IsTransient(err) {
+		return false
+	}
+	r.Down()
+	return true
+}
+
+// Down marks the replica as unavailable for ReplicaCooldown, as when a read
+// from it timed out.
+func (r *Replica) Down() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.downUntil = r.now().Add(ReplicaCooldown)
+}
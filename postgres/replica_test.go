@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplica(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	r := NewReplica(nil, 5*time.Second)
+	r.now = func() time.Time { return now }
+
+	assert.True(t, r.Use("alice"))
+
+	r.Wrote("alice")
+	assert.False(t, r.Use("alice"), "the writer reads from the primary")
+	assert.True(t, r.Use("bob"))
+
+	now = now.Add(5 * time.Second)
+	assert.True(t, r.Use("alice"), "stickiness ends after the window")
+
+	assert.False(t, r.Failed(errors.New("syntax error")))
+	assert.True(t, r.Use("bob"))
+
+	assert.True(t, r.Failed(&pq.Error{Code: "57P01"}))
+	assert.False(t, r.Use("bob"), "unhealthy replica is not used")
+
+	now = now.Add(ReplicaCooldown)
+	assert.True(t, r.Use("bob"))
+}
@@ -0,0 +1,237 @@
+// Package privacy exports and erases the personal data of a user: the
+// expenses they own, with their attachments and history, including those in
+// the archive.
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/attachment"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/retention"
+	"github.com/phuangpheth/assessment/txn"
+	"go.uber.org/zap"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier = txn.Querier
+
+// Erasure is the tombstone left by Erase. It records that the data of a
+// user was erased without identifying them.
+type Erasure struct {
+	ID     int64  `json:"id"`
+	Tenant string `json:"tenant_id"`
+	// Subject is the hex SHA-256 of the tenant and the user, which tells
+	// whether a given user was erased without naming them.
+	Subject     string    `json:"subject"`
+	Deleted     int       `json:"deleted"`
+	Anonymized  int       `json:"anonymized"`
+	Attachments int       `json:"attachments"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Subject returns the subject of the erasures of o.
+func Subject(o expense.Owner) string {
+	sum := sha256.Sum256([]byte(o.Tenant + "/" + o.ID))
+	return hex.EncodeToString(sum[:])
+}
+
+// Export is the data of a user, written as a zip archive by Write.
+type Export struct {
+	Expenses    []expense.Expense       `json:"expenses"`
+	Attachments []attachment.Attachment `json:"attachments"`
+	History     *expense.History        `json:"history"`
+	Archived    []retention.Archived    `json:"archived"`
+
+	attachmentSvc *attachment.Service
+}
+
+type Service struct {
+	db            *sql.DB
+	expenseSvc    *expense.Service
+	attachmentSvc *attachment.Service
+	accessSvc     *access.Service
+	retentionSvc  *retention.Service
+}
+
+func NewService(db *sql.DB, expenseSvc *expense.Service, attachmentSvc *attachment.Service, accessSvc *access.Service, retentionSvc *retention.Service) *Service {
+	return &Service{
+		db:            db,
+		expenseSvc:    expenseSvc,
+		attachmentSvc: attachmentSvc,
+		accessSvc:     accessSvc,
+		retentionSvc:  retentionSvc,
+	}
+}
+
+// conn returns the transaction of the unit of work of ctx, or the database.
+func (s *Service) conn(ctx context.Context) querier {
+	return txn.From(ctx, s.db)
+}
+
+// Export collects the expenses owned by the principal of ctx, their
+// attachments and their history, and their archived expenses.
+func (s *Service) Export(ctx context.Context) (*Export, error) {
+	o := expense.OwnerFromContext(ctx)
+	exps, err := s.expenseSvc.ListOwned(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	ex := &Export{
+		Expenses:      exps,
+		Attachments:   make([]attachment.Attachment, 0),
+		attachmentSvc: s.attachmentSvc,
+	}
+	ids := make([]int64, 0, len(exps))
+	for _, e := range exps {
+		ids = append(ids, e.ID)
+		as, err := s.attachmentSvc.List(ctx, e.ID)
+		if err != nil {
+			return nil, err
+		}
+		ex.Attachments = append(ex.Attachments, as...)
+	}
+	if ex.History, err = s.expenseSvc.History(ctx, o, ids); err != nil {
+		return nil, err
+	}
+	if ex.Archived, err = s.retentionSvc.ListArchived(ctx, o); err != nil {
+		return nil, err
+	}
+	return ex, nil
+}
+
+// Write writes the export to w as a zip archive of expenses.json,
+// attachments.json, history.json and archived.json, and the content of every
+// attachment under attachments/<expense id>/.
+func (ex *Export) Write(ctx context.Context, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{"expenses.json", ex.Expenses},
+		{"attachments.json", ex.Attachments},
+		{"history.json", ex.History},
+		{"archived.json", ex.Archived},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.v); err != nil {
+			return err
+		}
+	}
+	for _, a := range ex.Attachments {
+		if err := ex.writeAttachment(ctx, zw, a); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (ex *Export) writeAttachment(ctx context.Context, zw *zip.Writer, a attachment.Attachment) error {
+	_, rc, err := ex.attachmentSvc.Open(ctx, a.ExpenseID, a.ID)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	name := path.Base(strings.ReplaceAll(a.Filename, "\\", "/"))
+	fw, err := zw.Create(fmt.Sprintf("attachments/%d/%d-%s", a.ExpenseID, a.ID, name))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, rc)
+	return err
+}
+
+// Erase erases the data of the principal of ctx in one unit of work, as
+// expense.Service.Erase does, together with their attachments, archived
+// expenses and roles, and leaves an Erasure as tombstone. The content of the
+// attachments is deleted from the store once the unit of work committed.
+func (s *Service) Erase(ctx context.Context) (*Erasure, error) {
+	o := expense.OwnerFromContext(ctx)
+	if o.ID == "" {
+		return nil, expense.ErrOwnerEmpty
+	}
+
+	var er *Erasure
+	var removed []attachment.Attachment
+	var archived *retention.ArchiveErasure
+	err := s.expenseSvc.WithTx(ctx, func(ctx context.Context) error {
+		exps, err := s.expenseSvc.ListOwned(ctx, o)
+		if err != nil {
+			return err
+		}
+		ids := make([]int64, 0, len(exps))
+		for _, e := range exps {
+			ids = append(ids, e.ID)
+		}
+		if removed, err = s.attachmentSvc.Remove(ctx, ids); err != nil {
+			return err
+		}
+		erased, err := s.expenseSvc.Erase(ctx, o)
+		if err != nil {
+			return err
+		}
+		if archived, err = s.retentionSvc.EraseArchived(ctx, o); err != nil {
+			return err
+		}
+		if _, err := s.accessSvc.RevokeAll(ctx, o.Tenant, o.ID); err != nil {
+			return err
+		}
+
+		er = &Erasure{
+			Tenant:      o.Tenant,
+			Subject:     Subject(o),
+			Deleted:     len(erased.Deleted) + len(archived.ExpenseIDs),
+			Anonymized:  len(erased.Anonymized),
+			Attachments: len(removed) + len(archived.StorageKeys),
+		}
+		if err := createErasure(ctx, s.conn(ctx), er); err != nil {
+			return fmt.Errorf("createErasure(): %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachmentSvc.DeleteContent(ctx, removed); err != nil {
+		zap.L().Error("failed to delete the content of erased attachments", zap.Int64("erasure", er.ID), zap.Error(err))
+	}
+	if err := s.attachmentSvc.DeleteKeys(ctx, archived.StorageKeys); err != nil {
+		zap.L().Error("failed to delete the content of erased archived attachments", zap.Int64("erasure", er.ID), zap.Error(err))
+	}
+	return er, nil
+}
+
+func createErasure(ctx context.Context, db querier, er *Erasure) error {
+	query, args, err := sq.Insert("erasures").
+		Columns("tenant_id", "subject", "deleted", "anonymized", "attachments").
+		Values(er.Tenant, er.Subject, er.Deleted, er.Anonymized, er.Attachments).
+		Suffix("RETURNING id, created_at").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	return db.QueryRowContext(ctx, query, args...).Scan(&er.ID, &er.CreatedAt)
+}
@@ -0,0 +1,142 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/access"
+	"github.com/phuangpheth/assessment/attachment"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/retention"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	expenseColumns    = []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
+	attachmentColumns = []string{"id", "expense_id", "filename", "content_type", "size", "sha256", "storage_key", "created_at"}
+)
+
+func TestService(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store, err := attachment.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expenseSvc := expense.NewService(db)
+	svc := NewService(db, expenseSvc, attachment.NewService(db, store, attachment.Limits{}), access.NewService(db), retention.NewService(db, expenseSvc, attachment.NewService(db, store, attachment.Limits{})))
+	ctx := expense.WithActor(expense.WithTenant(context.Background(), "acme"), expense.Actor{ID: "ana"})
+	now := time.Now()
+	owned := func() *sqlmock.Rows {
+		return sqlmock.NewRows(expenseColumns).
+			AddRow(1, 75, "Tea", "", pq.Array([]string{}), "THB", now, 75, nil, "draft", nil)
+	}
+	receipt := func() *sqlmock.Rows {
+		return sqlmock.NewRows(attachmentColumns).
+			AddRow(4, 1, "../receipt.pdf", "application/pdf", 7, "", "1/receipt", now)
+	}
+
+	t.Run("Export() writes expenses, history and attachments", func(t *testing.T) {
+		if err := store.Put(context.Background(), "1/receipt", strings.NewReader("%PDF-1."), 7, "application/pdf"); err != nil {
+			t.Fatal(err)
+		}
+		mock.ExpectQuery(`SELECT (.+) FROM expenses WHERE owner_id = \$1 AND tenant_id = \$2`).
+			WithArgs("ana", "acme").
+			WillReturnRows(owned())
+		mock.ExpectQuery(`SELECT (.+) FROM attachments`).WithArgs(1).WillReturnRows(receipt())
+		mock.ExpectQuery(`SELECT (.+) FROM expense_events`).
+			WithArgs(pq.Array([]int64{1})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "expense_id", "tenant_id", "data", "created_at"}).
+				AddRow(9, expense.EventCreated, 1, "acme", []byte(`{"id":1}`), now))
+		mock.ExpectQuery(`SELECT (.+) FROM expense_transitions`).
+			WithArgs(pq.Array([]int64{1}), "ana", "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "expense_id", "action", "from_status", "to_status", "actor", "reason", "created_at"}))
+		mock.ExpectQuery(`SELECT (.+) FROM expenses_archive`).
+			WithArgs("ana", "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "data", "archived_at"}).
+				AddRow(7, []byte(`{"expense":{"id":7},"attachments":[],"transitions":[]}`), now))
+		mock.ExpectQuery(`SELECT (.+) FROM attachments`).WithArgs(1, 4).WillReturnRows(receipt())
+
+		ex, err := svc.Export(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		var buf bytes.Buffer
+		err = ex.Write(ctx, &buf)
+
+		if assert.NoError(t, err) {
+			zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if assert.NoError(t, err) {
+				var names []string
+				for _, f := range zr.File {
+					names = append(names, f.Name)
+				}
+				assert.Equal(t, []string{"expenses.json", "attachments.json", "history.json", "archived.json", "attachments/1/4-receipt.pdf"}, names)
+				rc, _ := zr.File[4].Open()
+				content, _ := io.ReadAll(rc)
+				assert.Equal(t, "%PDF-1.", string(content))
+			}
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Erase() leaves a tombstone", func(t *testing.T) {
+		if err := store.Put(context.Background(), "7/invoice", strings.NewReader("%PDF-1."), 7, "application/pdf"); err != nil {
+			t.Fatal(err)
+		}
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM expenses WHERE owner_id = \$1 AND tenant_id = \$2`).
+			WithArgs("ana", "acme").
+			WillReturnRows(owned())
+		mock.ExpectQuery(`DELETE FROM attachments WHERE expense_id = ANY\(\$1\) RETURNING`).
+			WithArgs(pq.Array([]int64{1})).
+			WillReturnRows(receipt())
+		mock.ExpectQuery(`SELECT id, status FROM expenses`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, "draft"))
+		mock.ExpectExec(`DELETE FROM expense_events`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE expense_transitions`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE expenses`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DELETE FROM expenses`).WithArgs(pq.Array([]int64{1})).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO expense_events`).
+			WithArgs(expense.EventDeleted, 1, "acme", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`DELETE FROM expenses_archive WHERE (.+) RETURNING id`).
+			WithArgs("ana", "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "storage_keys"}).AddRow(7, pq.Array([]string{"7/invoice"})))
+		mock.ExpectExec(`DELETE FROM role_assignments`).WithArgs("acme", "ana").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO erasures`).
+			WithArgs("acme", Subject(expense.Owner{Tenant: "acme", ID: "ana"}), 2, 0, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, now))
+		mock.ExpectCommit()
+
+		er, err := svc.Erase(ctx)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, er.Deleted)
+			assert.Equal(t, 2, er.Attachments)
+			assert.NotContains(t, er.Subject, "ana")
+			for _, key := range []string{"1/receipt", "7/invoice"} {
+				_, err := store.Get(context.Background(), key)
+				assert.ErrorIs(t, err, attachment.ErrBlobNotFound, key)
+			}
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Erase() requires a user", func(t *testing.T) {
+		_, err := svc.Erase(context.Background())
+
+		assert.ErrorIs(t, err, expense.ErrOwnerEmpty)
+	})
+}
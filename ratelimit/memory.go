@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	per     time.Duration
+}
+
+type quota struct {
+	day   time.Time
+	count int64
+}
+
+// MemoryStore keeps buckets and quotas in process. Each replica using a
+// MemoryStore limits requests on its own.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	quotas  map[string]*quota
+	swept   time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		quotas:  make(map[string]*quota),
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, l Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Requests), updated: now}
+		s.buckets[key] = b
+	}
+	var r Result
+	b.tokens, r = take(b.tokens, b.updated, l, now)
+	b.updated, b.per = now, l.Per
+	return r, nil
+}
+
+func (s *MemoryStore) Incr(_ context.Context, key string, day time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.quotas[key]
+	if !ok || !q.day.Equal(day) {
+		q = &quota{day: day}
+		s.quotas[key] = q
+	}
+	q.count++
+	return q.count, nil
+}
+
+func (s *MemoryStore) Sweep(_ context.Context, idle, today time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, b := range s.buckets {
+		if b.updated.Before(idle) {
+			delete(s.buckets, k)
+		}
+	}
+	for k, q := range s.quotas {
+		if q.day.Before(today) {
+			delete(s.quotas, k)
+		}
+	}
+	return nil
+}
+
+// sweep drops buckets that have refilled and quotas of past days, at most
+// once a minute.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < time.Minute {
+		return
+	}
+	s.swept = now
+	for k, b := range s.buckets {
+		if now.Sub(b.updated) >= b.per {
+			delete(s.buckets, k)
+		}
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	for k, q := range s.quotas {
+		if q.day.Before(today) {
+			delete(s.quotas, k)
+		}
+	}
+}
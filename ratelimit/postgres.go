@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// PostgresStore keeps buckets and quotas in Postgres, so that replicas
+// share the limits of a principal. Each bucket is updated under a row lock.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{
+		db: db,
+	}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback()
+
+	tokens, updated, err := lockBucket(ctx, tx, key, l, now)
+	if err != nil {
+		return Result{}, fmt.Errorf("lockBucket(): %w", err)
+	}
+	tokens, r := take(tokens, updated, l, now)
+	if err := updateBucket(ctx, tx, key, tokens, now); err != nil {
+		return Result{}, fmt.Errorf("updateBucket(): %w", err)
+	}
+	return r, tx.Commit()
+}
+
+func (s *PostgresStore) Incr(ctx context.Context, key string, day time.Time) (int64, error) {
+	query, args, err := sq.Insert("rate_limit_quotas").
+		Columns("key", "day", "count").
+		Values(key, day, 1).
+		Suffix("ON CONFLICT (key, day) DO UPDATE SET count = rate_limit_quotas.count + 1 RETURNING count").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *PostgresStore) Sweep(ctx context.Context, idle, today time.Time) error {
+	query, args, err := sq.Delete("rate_limit_buckets").
+		Where(sq.Lt{"updated_at": idle}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("deleteBuckets(): %w", err)
+	}
+
+	query, args, err = sq.Delete("rate_limit_quotas").
+		Where(sq.Lt{"day": today}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("deleteQuotas(): %w", err)
+	}
+	return nil
+}
+
+// lockBucket creates the bucket key full if it does not exist, then locks it
+// and returns its tokens.
+func lockBucket(ctx context.Context, tx *sql.Tx, key string, l Limit, now time.Time) (float64, time.Time, error) {
+	query, args, err := sq.Insert("rate_limit_buckets").
+		Columns("key", "tokens", "updated_at").
+		Values(key, float64(l.Requests), now).
+		Suffix("ON CONFLICT (key) DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	query, args, err = sq.Select("tokens", "updated_at").
+		From("rate_limit_buckets").
+		Where(sq.Eq{"key": key}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	var (
+		tokens  float64
+		updated time.Time
+	)
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&tokens, &updated); err != nil {
+		return 0, time.Time{}, err
+	}
+	return tokens, updated, nil
+}
+
+func updateBucket(ctx context.Context, tx *sql.Tx, key string, tokens float64, now time.Time) error {
+	query, args, err := sq.Update("rate_limit_buckets").
+		Set("tokens", tokens).
+		Set("updated_at", now).
+		Where(sq.Eq{"key": key}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	return err
+}
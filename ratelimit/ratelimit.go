@@ -0,0 +1,188 @@
+// Package ratelimit limits requests per principal with token buckets and
+// daily quotas. Buckets and quotas are kept in a Store, either in process or
+// in Postgres when several replicas share the limits.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLimitInvalid is returned when a limit cannot be parsed.
+var ErrLimitInvalid = errors.New(`limit must look like "100/m", with unit s, m, h or d`)
+
+// Limit allows Requests per Per. Up to Requests can be made at once, after
+// which the bucket refills evenly over Per.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseLimit parses limits such as "10/s", "600/m" or "5000/h".
+func ParseLimit(s string) (Limit, error) {
+	n, unit, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, ErrLimitInvalid
+	}
+	requests, err := strconv.Atoi(n)
+	if err != nil || requests <= 0 {
+		return Limit{}, ErrLimitInvalid
+	}
+	per, ok := map[string]time.Duration{
+		"s": time.Second,
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": 24 * time.Hour,
+	}[unit]
+	if !ok {
+		return Limit{}, ErrLimitInvalid
+	}
+	return Limit{Requests: requests, Per: per}, nil
+}
+
+func (l Limit) String() string {
+	unit := map[time.Duration]string{
+		time.Second:    "s",
+		time.Minute:    "m",
+		time.Hour:      "h",
+		24 * time.Hour: "d",
+	}[l.Per]
+	if unit == "" {
+		return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, unit)
+}
+
+// rate is the number of tokens added per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Result is the state of a bucket after a request was counted.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long to wait before a denied request is allowed.
+	RetryAfter time.Duration
+}
+
+// take refills a bucket that held tokens at updated and takes one token if
+// there is one. It returns the tokens left in the bucket.
+func take(tokens float64, updated time.Time, l Limit, now time.Time) (float64, Result) {
+	if elapsed := now.Sub(updated).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(l.Requests), tokens+elapsed*l.rate())
+	}
+	var r Result
+	if tokens >= 1 {
+		tokens--
+		r.Allowed = true
+	} else {
+		r.RetryAfter = seconds((1 - tokens) / l.rate())
+	}
+	r.Remaining = int(tokens)
+	r.Reset = seconds((float64(l.Requests) - tokens) / l.rate())
+	return tokens, r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Store keeps token buckets and daily counters.
+type Store interface {
+	// Take counts a request against the bucket key.
+	Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error)
+	// Incr counts a request against the quota key on day and returns the
+	// number of requests counted on that day.
+	Incr(ctx context.Context, key string, day time.Time) (int64, error)
+	// Sweep drops the buckets not taken from since idle and the quotas of
+	// days before today.
+	Sweep(ctx context.Context, idle, today time.Time) error
+}
+
+// Config are the limits of a Limiter.
+type Config struct {
+	// Default limits every route without a limit in Routes.
+	Default Limit
+	// Routes limits routes, such as "GET /expenses", with a bucket of their
+	// own.
+	Routes map[string]Limit
+	// DailyQuota limits the requests of a principal per UTC day. Zero means
+	// unlimited.
+	DailyQuota int64
+}
+
+// Decision is the outcome of Limiter.Allow.
+type Decision struct {
+	Result
+	Limit Limit
+	// QuotaExceeded reports whether the request was denied by the daily
+	// quota rather than the rate limit.
+	QuotaExceeded bool
+}
+
+type Limiter struct {
+	store Store
+	cfg   Config
+	now   func() time.Time
+}
+
+func NewLimiter(store Store, cfg Config) *Limiter {
+	return &Limiter{
+		store: store,
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+// Allow counts a request of principal to route against the limit of the
+// route and the daily quota of the principal.
+func (l *Limiter) Allow(ctx context.Context, principal, route string) (Decision, error) {
+	now := l.now()
+	limit, ok := l.cfg.Routes[route]
+	bucket := principal + " " + route
+	if !ok {
+		limit, bucket = l.cfg.Default, principal
+	}
+
+	r, err := l.store.Take(ctx, bucket, limit, now)
+	if err != nil {
+		return Decision{}, fmt.Errorf("take(%s): %w", bucket, err)
+	}
+	d := Decision{Result: r, Limit: limit}
+	if !r.Allowed || l.cfg.DailyQuota <= 0 {
+		return d, nil
+	}
+
+	day := now.UTC().Truncate(24 * time.Hour)
+	n, err := l.store.Incr(ctx, principal, day)
+	if err != nil {
+		return Decision{}, fmt.Errorf("incr(%s): %w", principal, err)
+	}
+	if n > l.cfg.DailyQuota {
+		d.Allowed = false
+		d.QuotaExceeded = true
+		d.RetryAfter = day.Add(24 * time.Hour).Sub(now)
+	}
+	return d, nil
+}
+
+// Sweep drops the buckets that have refilled, since a missing bucket is
+// created full, and the quotas of past days.
+func (l *Limiter) Sweep(ctx context.Context) error {
+	longest := l.cfg.Default.Per
+	for _, limit := range l.cfg.Routes {
+		if limit.Per > longest {
+			longest = limit.Per
+		}
+	}
+	now := l.now()
+	return l.store.Sweep(ctx, now.Add(-longest), now.UTC().Truncate(24*time.Hour))
+}
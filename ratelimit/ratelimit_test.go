@@ -0,0 +1,194 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Limit
+		err  error
+	}{
+		{"10/s", Limit{10, time.Second}, nil},
+		{" 600/m ", Limit{600, time.Minute}, nil},
+		{"5000/d", Limit{5000, 24 * time.Hour}, nil},
+		{"10", Limit{}, ErrLimitInvalid},
+		{"0/s", Limit{}, ErrLimitInvalid},
+		{"ten/s", Limit{}, ErrLimitInvalid},
+		{"10/w", Limit{}, ErrLimitInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLimit(tt.in)
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	l := NewLimiter(NewMemoryStore(), Config{
+		Default: Limit{2, time.Minute},
+		Routes:  map[string]Limit{"GET /expenses": {1, time.Minute}},
+	})
+	l.now = func() time.Time { return now }
+
+	t.Run("Allow() takes the burst, then denies", func(t *testing.T) {
+		d, err := l.Allow(ctx, "ana", "POST /expenses")
+		if assert.NoError(t, err) {
+			assert.True(t, d.Allowed)
+			assert.Equal(t, 1, d.Remaining)
+			assert.Equal(t, 30*time.Second, d.Reset)
+		}
+		d, _ = l.Allow(ctx, "ana", "PUT /expenses/:id")
+		assert.True(t, d.Allowed)
+
+		d, err = l.Allow(ctx, "ana", "POST /expenses")
+		if assert.NoError(t, err) {
+			assert.False(t, d.Allowed)
+			assert.Equal(t, 0, d.Remaining)
+			assert.Equal(t, 30*time.Second, d.RetryAfter)
+		}
+	})
+
+	t.Run("Allow() limits routes with a bucket of their own", func(t *testing.T) {
+		d, _ := l.Allow(ctx, "ana", "GET /expenses")
+		assert.True(t, d.Allowed)
+		assert.Equal(t, Limit{1, time.Minute}, d.Limit)
+
+		d, _ = l.Allow(ctx, "ana", "GET /expenses")
+		assert.False(t, d.Allowed)
+		assert.Equal(t, time.Minute, d.RetryAfter)
+	})
+
+	t.Run("Allow() limits principals apart", func(t *testing.T) {
+		d, _ := l.Allow(ctx, "bob", "POST /expenses")
+		assert.True(t, d.Allowed)
+	})
+
+	t.Run("Allow() refills the bucket over time", func(t *testing.T) {
+		now = now.Add(30 * time.Second)
+
+		d, _ := l.Allow(ctx, "ana", "POST /expenses")
+		assert.True(t, d.Allowed)
+		d, _ = l.Allow(ctx, "ana", "POST /expenses")
+		assert.False(t, d.Allowed)
+	})
+}
+
+func TestLimiterDailyQuota(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 18, 0, 0, 0, time.UTC)
+	l := NewLimiter(NewMemoryStore(), Config{
+		Default:    Limit{10, time.Second},
+		DailyQuota: 2,
+	})
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		d, _ := l.Allow(ctx, "ana", "GET /expenses")
+		assert.True(t, d.Allowed)
+	}
+	d, err := l.Allow(ctx, "ana", "GET /expenses")
+	if assert.NoError(t, err) {
+		assert.False(t, d.Allowed)
+		assert.True(t, d.QuotaExceeded)
+		assert.Equal(t, 6*time.Hour, d.RetryAfter)
+	}
+
+	now = now.Add(6 * time.Hour)
+	d, _ = l.Allow(ctx, "ana", "GET /expenses")
+	assert.True(t, d.Allowed)
+}
+
+func TestLimiterSweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	l := NewLimiter(s, Config{
+		Default:    Limit{10, time.Second},
+		Routes:     map[string]Limit{"GET /expenses": {1, time.Minute}},
+		DailyQuota: 100,
+	})
+	l.now = func() time.Time { return now }
+
+	l.Allow(ctx, "ana", "GET /expenses")
+	l.Allow(ctx, "bob", "POST /expenses")
+	now = now.Add(30 * time.Second)
+	l.Allow(ctx, "bob", "POST /expenses")
+	now = now.Add(24 * time.Hour)
+
+	if assert.NoError(t, l.Sweep(ctx)) {
+		assert.Empty(t, s.buckets, "buckets idle for the longest period are dropped")
+		assert.Empty(t, s.quotas, "quotas of past days are dropped")
+	}
+}
+
+func TestPostgresStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := NewPostgresStore(db)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Take() updates the locked bucket", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO rate_limit_buckets (.+) ON CONFLICT \(key\) DO NOTHING`).
+			WithArgs("ana", 60.0, now).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = \$1 FOR UPDATE`).
+			WithArgs("ana").
+			WillReturnRows(sqlmock.NewRows([]string{"tokens", "updated_at"}).AddRow(0.5, now.Add(-time.Second)))
+		mock.ExpectExec(`UPDATE rate_limit_buckets SET tokens = \$1, updated_at = \$2 WHERE key = \$3`).
+			WithArgs(0.5, now, "ana").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		r, err := s.Take(ctx, "ana", Limit{60, time.Minute}, now)
+
+		if assert.NoError(t, err) {
+			assert.True(t, r.Allowed)
+			assert.Equal(t, 0, r.Remaining)
+		}
+	})
+
+	t.Run("Incr() counts the day", func(t *testing.T) {
+		day := now.Truncate(24 * time.Hour)
+		mock.ExpectQuery(`INSERT INTO rate_limit_quotas (.+) ON CONFLICT \(key, day\) DO UPDATE SET count = rate_limit_quotas.count \+ 1 RETURNING count`).
+			WithArgs("ana", day, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		n, err := s.Incr(ctx, "ana", day)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(3), n)
+		}
+	})
+
+	t.Run("Sweep() deletes idle buckets and past quotas", func(t *testing.T) {
+		today := now.Truncate(24 * time.Hour)
+		mock.ExpectExec(`DELETE FROM rate_limit_buckets WHERE updated_at < \$1`).
+			WithArgs(now.Add(-time.Hour)).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectExec(`DELETE FROM rate_limit_quotas WHERE day < \$1`).
+			WithArgs(today).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		assert.NoError(t, s.Sweep(ctx, now.Add(-time.Hour), today))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
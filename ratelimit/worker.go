@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Worker periodically sweeps the buckets and quotas of a limiter, so that
+// the store does not keep every principal that was ever limited.
+type Worker struct {
+	limiter  *Limiter
+	interval time.Duration
+}
+
+func NewWorker(limiter *Limiter, interval time.Duration) *Worker {
+	return &Worker{
+		limiter:  limiter,
+		interval: interval,
+	}
+}
+
+// Run sweeps on every tick until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.limiter.Sweep(ctx); err != nil {
+				zap.L().Error("failed to sweep rate limits", zap.Error(err))
+			}
+		}
+	}
+}
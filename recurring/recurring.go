@@ -0,0 +1,433 @@
+package recurring
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/txn"
+	"go.uber.org/zap"
+)
+
+// ErrNotFound is returned when the recurring expense could not be found.
+var ErrNotFound = errors.New("not found")
+
+// batchSize is the maximum number of recurring expenses locked by a single
+// Materialize call.
+const batchSize = 100
+
+// retryDelay is how long a recurring expense that failed to materialize is
+// skipped before it is tried again.
+const retryDelay = time.Hour
+
+// systemActor saves the occurrences of recurring expenses whose creator is
+// unknown, as those created before creators were recorded.
+const systemActor = "recurring"
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier = txn.Querier
+
+type Service struct {
+	db         *sql.DB
+	expenseSvc *expense.Service
+}
+
+func NewService(db *sql.DB, expenseSvc *expense.Service) *Service {
+	return &Service{
+		db:         db,
+		expenseSvc: expenseSvc,
+	}
+}
+
+// conn returns the transaction of the unit of work of ctx, or the database.
+func (s *Service) conn(ctx context.Context) querier {
+	return txn.From(ctx, s.db)
+}
+
+// Save creates the recurring expense in the tenant of ctx, which its
+// occurrences are saved in on behalf of the actor of ctx.
+func (s *Service) Save(ctx context.Context, r *RecurringExpense) (*RecurringExpense, error) {
+	r.Tenant = expense.TenantFromContext(ctx)
+	r.Owner = expense.ActorFromContext(ctx).ID
+	r.NextIndex = 0
+	r.NextDate = r.Schedule.Occurrence(0)
+	if err := createRecurringExpense(ctx, s.conn(ctx), r); err != nil {
+		return nil, fmt.Errorf("createRecurringExpense(): %w", err)
+	}
+	return r, nil
+}
+
+func (s *Service) GetByID(ctx context.Context, id int64) (*RecurringExpense, error) {
+	r, err := getRecurringExpenseByID(ctx, s.conn(ctx), id)
+	if err != nil {
+		return nil, fmt.Errorf("getRecurringExpenseByID(%d): %w", id, err)
+	}
+	return r, nil
+}
+
+func (s *Service) List(ctx context.Context) ([]RecurringExpense, error) {
+	rs, err := listRecurringExpenses(ctx, s.conn(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("listRecurringExpenses(): %w", err)
+	}
+	return rs, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	if err := deleteRecurringExpense(ctx, s.conn(ctx), id); err != nil {
+		return fmt.Errorf("deleteRecurringExpense(%d): %w", id, err)
+	}
+	return nil
+}
+
+// Materialize saves an expense for every occurrence that is due at now.
+//
+// Each due recurring expense is locked with FOR UPDATE SKIP LOCKED in a
+// transaction of its own, so several replicas may run Materialize
+// concurrently; each occurrence is saved in the same transaction that
+// advances the schedule and records the occurrence, which makes it happen
+// exactly once. A recurring expense that fails is rolled back on its own,
+// its error is recorded and it is skipped for retryDelay, so it holds back
+// none of the others.
+//
+// When catchUp is true every occurrence missed during downtime is saved,
+// otherwise only the most recent due occurrence is saved and older ones are
+// skipped. It returns the number of expenses saved and the number of due
+// recurring expenses it locked, which is batchSize when more may be due.
+func (s *Service) Materialize(ctx context.Context, now time.Time, catchUp bool) (saved, locked int, err error) {
+	// Occurrences repeat the same template, so they would look like
+	// duplicates of each other.
+	ctx = expense.AllowDuplicates(ctx)
+	for locked < batchSize {
+		var r *RecurringExpense
+		var n int
+		err := s.expenseSvc.WithTx(ctx, func(ctx context.Context) (err error) {
+			r, n = nil, 0
+			tx := s.conn(ctx)
+			r, err = lockDueRecurringExpense(ctx, tx, now)
+			if errors.Is(err, ErrNotFound) {
+				r = nil
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("lockDueRecurringExpense(): %w", err)
+			}
+			n, err = s.materialize(ctx, tx, *r, now, catchUp)
+			return err
+		})
+		if r == nil {
+			if err != nil {
+				return saved, locked, err
+			}
+			break
+		}
+		locked++
+		if err != nil {
+			zap.L().Error("failed to materialize recurring expense", zap.Int64("recurring_id", r.ID), zap.Error(err))
+			if err := failRecurringExpense(ctx, s.db, r, now.Add(retryDelay), err); err != nil {
+				return saved, locked, fmt.Errorf("failRecurringExpense(%d): %w", r.ID, err)
+			}
+			continue
+		}
+		saved += n
+	}
+	return saved, locked, nil
+}
+
+// materialize saves the due occurrences of r, which is locked by tx, on
+// behalf of its creator and advances its schedule.
+func (s *Service) materialize(ctx context.Context, tx querier, r RecurringExpense, now time.Time, catchUp bool) (saved int, err error) {
+	actor := r.Owner
+	if actor == "" {
+		actor = systemActor
+	}
+	ctx = expense.WithActor(expense.WithTenant(ctx, r.Tenant), expense.Actor{ID: actor})
+	for !r.NextDate.After(now) && !r.Schedule.Ended(r.NextDate) {
+		occursOn := r.NextDate
+		r.NextIndex++
+		r.NextDate = r.Schedule.Occurrence(r.NextIndex)
+
+		if !catchUp && !r.NextDate.After(now) && !r.Schedule.Ended(r.NextDate) {
+			continue
+		}
+
+		exp := r.Template
+		exp.Tags = append([]string(nil), r.Template.Tags...)
+		exp.SpentOn = occursOn.Format(expense.DateLayout)
+		e, err := s.expenseSvc.Save(ctx, &exp)
+		if err != nil {
+			return 0, err
+		}
+		if err := createOccurrence(ctx, tx, r.ID, occursOn, e.ID); err != nil {
+			return 0, fmt.Errorf("createOccurrence(%d): %w", r.ID, err)
+		}
+		saved++
+	}
+	if err := advanceRecurringExpense(ctx, tx, &r); err != nil {
+		return 0, fmt.Errorf("advanceRecurringExpense(%d): %w", r.ID, err)
+	}
+	return saved, nil
+}
+
+// RecurringExpense saves a copy of Template on every occurrence of Schedule.
+type RecurringExpense struct {
+	ID        int64           `json:"id"`
+	Tenant    string          `json:"-"`
+	Owner     string          `json:"-"`
+	Template  expense.Expense `json:"template"`
+	Schedule  Schedule        `json:"schedule"`
+	NextIndex int             `json:"-"`
+	NextDate  time.Time       `json:"next_date"`
+}
+
+func (r *RecurringExpense) Validate() error {
+	if err := r.Template.Validate(); err != nil {
+		return err
+	}
+	if r.Schedule.Interval == 0 {
+		r.Schedule.Interval = 1
+	}
+	return r.Schedule.Validate()
+}
+
+func createRecurringExpense(ctx context.Context, db querier, r *RecurringExpense) error {
+	query, args, err := sq.Insert("recurring_expenses").
+		Columns(
+			"tenant_id",
+			"owner_id",
+			"amount",
+			"title",
+			"note",
+			"tags",
+			"currency",
+			"frequency",
+			"interval_count",
+			"start_date",
+			"end_date",
+			"next_index",
+			"next_date",
+		).
+		Values(
+			r.Tenant,
+			r.Owner,
+			r.Template.Amount,
+			r.Template.Title,
+			r.Template.Note,
+			pq.Array(r.Template.Tags),
+			r.Template.Currency,
+			r.Schedule.Frequency,
+			r.Schedule.Interval,
+			r.Schedule.StartDate,
+			r.Schedule.EndDate,
+			r.NextIndex,
+			r.NextDate,
+		).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	return db.QueryRowContext(ctx, query, args...).Scan(&r.ID)
+}
+
+func getRecurringExpenseByID(ctx context.Context, db querier, id int64) (*RecurringExpense, error) {
+	query, args, err := sq.Select(recurringExpenseColumns...).
+		From("recurring_expenses").
+		Where(sq.Eq{"id": id, "tenant_id": expense.TenantFromContext(ctx)}).
+		Limit(1).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	row := db.QueryRowContext(ctx, query, args...)
+	r, err := scanRecurringExpense(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func listRecurringExpenses(ctx context.Context, db querier) ([]RecurringExpense, error) {
+	query, args, err := sq.Select(recurringExpenseColumns...).
+		From("recurring_expenses").
+		Where(sq.Eq{"tenant_id": expense.TenantFromContext(ctx)}).
+		OrderBy("id DESC").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return queryRecurringExpenses(ctx, db, query, args...)
+}
+
+// lockDueRecurringExpense locks the recurring expense that has been due the
+// longest and is not waiting to be retried, or returns ErrNotFound.
+func lockDueRecurringExpense(ctx context.Context, tx querier, now time.Time) (*RecurringExpense, error) {
+	query, args, err := sq.Select(recurringExpenseColumns...).
+		From("recurring_expenses").
+		Where(sq.LtOrEq{"next_date": truncateDate(now)}).
+		Where(sq.Or{
+			sq.Eq{"end_date": nil},
+			sq.Expr("next_date <= end_date"),
+		}).
+		Where(sq.Or{
+			sq.Eq{"retry_at": nil},
+			sq.LtOrEq{"retry_at": now},
+		}).
+		OrderBy("next_date").
+		Limit(1).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRowContext(ctx, query, args...)
+	r, err := scanRecurringExpense(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func queryRecurringExpenses(ctx context.Context, db querier, query string, args ...any) ([]RecurringExpense, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rs := make([]RecurringExpense, 0)
+	for rows.Next() {
+		r, err := scanRecurringExpense(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+func advanceRecurringExpense(ctx context.Context, tx querier, r *RecurringExpense) error {
+	query, args, err := sq.Update("recurring_expenses").
+		Set("next_index", r.NextIndex).
+		Set("next_date", r.NextDate).
+		Set("retry_at", nil).
+		Set("last_error", "").
+		Where(sq.Eq{"id": r.ID}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// failRecurringExpense records cause and skips r until retryAt, unless
+// another replica materialized r in the meantime.
+func failRecurringExpense(ctx context.Context, db querier, r *RecurringExpense, retryAt time.Time, cause error) error {
+	query, args, err := sq.Update("recurring_expenses").
+		Set("retry_at", retryAt).
+		Set("last_error", cause.Error()).
+		Where(sq.Eq{"id": r.ID, "next_index": r.NextIndex}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func createOccurrence(ctx context.Context, tx querier, recurringID int64, occursOn time.Time, expenseID int64) error {
+	query, args, err := sq.Insert("recurring_occurrences").
+		Columns("recurring_id", "occurs_on", "expense_id").
+		Values(recurringID, occursOn, expenseID).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func deleteRecurringExpense(ctx context.Context, db querier, id int64) error {
+	query, args, err := sq.Delete("recurring_expenses").
+		Where(sq.Eq{"id": id, "tenant_id": expense.TenantFromContext(ctx)}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+var recurringExpenseColumns = []string{
+	"id",
+	"tenant_id",
+	"owner_id",
+	"amount",
+	"title",
+	"note",
+	"tags",
+	"currency",
+	"frequency",
+	"interval_count",
+	"start_date",
+	"end_date",
+	"next_index",
+	"next_date",
+}
+
+func scanRecurringExpense(scan func(...any) error) (r RecurringExpense, _ error) {
+	var endDate sql.NullTime
+	if err := scan(
+		&r.ID,
+		&r.Tenant,
+		&r.Owner,
+		&r.Template.Amount,
+		&r.Template.Title,
+		&r.Template.Note,
+		pq.Array(&r.Template.Tags),
+		&r.Template.Currency,
+		&r.Schedule.Frequency,
+		&r.Schedule.Interval,
+		&r.Schedule.StartDate,
+		&endDate,
+		&r.NextIndex,
+		&r.NextDate,
+	); err != nil {
+		return r, err
+	}
+	if endDate.Valid {
+		r.Schedule.EndDate = &endDate.Time
+	}
+	return r, nil
+}
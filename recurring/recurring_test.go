@@ -0,0 +1,139 @@
+package recurring
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceMaterialize(t *testing.T) {
+	expenseColumns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
+	now := date(2026, 3, 15)
+
+	dueRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows(recurringExpenseColumns).
+			AddRow(1, "acme", "ana", 500, "rent", "", pq.Array([]string{"home"}), "LAK", "monthly", 1, date(2026, 1, 1), nil, 0, date(2026, 1, 1))
+	}
+	// noneDue expects the transaction that finds nothing left to materialize.
+	noneDue := func(mock sqlmock.Sqlmock) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM recurring_expenses (.+) FOR UPDATE SKIP LOCKED`).
+			WillReturnRows(sqlmock.NewRows(recurringExpenseColumns))
+		mock.ExpectCommit()
+	}
+
+	t.Run("Materialize() catches up missed occurrences", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+		svc := NewService(db, expense.NewService(db))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM recurring_expenses (.+) FOR UPDATE SKIP LOCKED`).WillReturnRows(dueRow())
+		for i, day := range []int{1, 2, 3} {
+			spentOn := date(2026, 1, 1).AddDate(0, day-1, 0).Format(expense.DateLayout)
+			mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+				WithArgs(500.0, "rent", "", pq.Array([]string{"home"}), "LAK", spentOn, 500.0, nil, expense.StatusDraft, "acme", "ana").
+				WillReturnRows(sqlmock.NewRows(expenseColumns).AddRow(i+1, 500, "rent", "", pq.Array([]string{"home"}), "", nil, 0, nil, "draft", nil))
+			mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(`INSERT INTO recurring_occurrences`).
+				WithArgs(1, date(2026, 1, 1).AddDate(0, day-1, 0), i+1).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectExec(`UPDATE recurring_expenses`).
+			WithArgs(3, date(2026, 4, 1), nil, "", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		noneDue(mock)
+
+		saved, locked, err := svc.Materialize(context.Background(), now, true)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 3, saved)
+			assert.Equal(t, 1, locked)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("Materialize() skips missed occurrences without catch-up", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+		svc := NewService(db, expense.NewService(db))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM recurring_expenses (.+) FOR UPDATE SKIP LOCKED`).WillReturnRows(dueRow())
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+			WillReturnRows(sqlmock.NewRows(expenseColumns).AddRow(7, 500, "rent", "", pq.Array([]string{"home"}), "", nil, 0, nil, "draft", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO recurring_occurrences`).
+			WithArgs(1, date(2026, 3, 1), 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE recurring_expenses`).
+			WithArgs(3, date(2026, 4, 1), nil, "", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		noneDue(mock)
+
+		saved, locked, err := svc.Materialize(context.Background(), now, false)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, saved)
+			assert.Equal(t, 1, locked)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("Materialize() skips a failing recurring expense and continues", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+		svc := NewService(db, expense.NewService(db))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM recurring_expenses (.+) FOR UPDATE SKIP LOCKED`).WillReturnRows(dueRow())
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+			WillReturnRows(sqlmock.NewRows(expenseColumns).AddRow(7, 500, "rent", "", pq.Array([]string{"home"}), "", nil, 0, nil, "draft", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO recurring_occurrences`).WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectRollback()
+		mock.ExpectExec(`UPDATE recurring_expenses SET retry_at = \$1, last_error = \$2 WHERE id = \$3 AND next_index = \$4`).
+			WithArgs(now.Add(retryDelay), sqlmock.AnyArg(), 1, 0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM recurring_expenses (.+) FOR UPDATE SKIP LOCKED`).
+			WillReturnRows(sqlmock.NewRows(recurringExpenseColumns).
+				AddRow(2, "acme", "", 30, "gym", "", pq.Array([]string{"health"}), "LAK", "monthly", 1, date(2026, 3, 1), nil, 0, date(2026, 3, 1)))
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+			WithArgs(30.0, "gym", "", pq.Array([]string{"health"}), "LAK", "2026-03-01", 30.0, nil, expense.StatusDraft, "acme", systemActor).
+			WillReturnRows(sqlmock.NewRows(expenseColumns).AddRow(8, 30, "gym", "", pq.Array([]string{"health"}), "", nil, 0, nil, "draft", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO recurring_occurrences`).
+			WithArgs(2, date(2026, 3, 1), 8).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE recurring_expenses`).
+			WithArgs(1, date(2026, 4, 1), nil, "", 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		noneDue(mock)
+
+		saved, locked, err := svc.Materialize(context.Background(), now, false)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, saved)
+			assert.Equal(t, 2, locked)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+}
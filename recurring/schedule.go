@@ -0,0 +1,92 @@
+package recurring
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrFrequencyInvalid is returned when the frequency of a schedule is unknown.
+var ErrFrequencyInvalid = errors.New("frequency must be one of daily, weekly, monthly or yearly")
+
+// ErrIntervalInvalid is returned when the interval of a schedule is less than one.
+var ErrIntervalInvalid = errors.New("interval must be greater than zero")
+
+// ErrStartDateEmpty is returned when the schedule has no start date.
+var ErrStartDateEmpty = errors.New("empty start date")
+
+// ErrEndDateInvalid is returned when the end date is before the start date.
+var ErrEndDateInvalid = errors.New("end date must not be before start date")
+
+type Frequency string
+
+const (
+	Daily   Frequency = "daily"
+	Weekly  Frequency = "weekly"
+	Monthly Frequency = "monthly"
+	Yearly  Frequency = "yearly"
+)
+
+// Schedule is a subset of an RFC 5545 RRULE: FREQ, INTERVAL and UNTIL.
+type Schedule struct {
+	Frequency Frequency  `json:"frequency"`
+	Interval  int        `json:"interval"`
+	StartDate time.Time  `json:"start_date"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+}
+
+func (s *Schedule) Validate() error {
+	switch s.Frequency {
+	case Daily, Weekly, Monthly, Yearly:
+	default:
+		return ErrFrequencyInvalid
+	}
+	if s.Interval < 1 {
+		return ErrIntervalInvalid
+	}
+	if s.StartDate.IsZero() {
+		return ErrStartDateEmpty
+	}
+	if s.EndDate != nil && s.EndDate.Before(s.StartDate) {
+		return ErrEndDateInvalid
+	}
+	return nil
+}
+
+// Occurrence returns the date of the n-th occurrence, counting from zero.
+// Monthly and yearly occurrences are computed from the start date rather
+// than from the previous occurrence, and are clamped to the end of shorter
+// months, so a schedule starting on Jan 31 falls on Feb 28 and then Mar 31.
+func (s *Schedule) Occurrence(n int) time.Time {
+	start := truncateDate(s.StartDate)
+	switch s.Frequency {
+	case Daily:
+		return start.AddDate(0, 0, n*s.Interval)
+	case Weekly:
+		return start.AddDate(0, 0, 7*n*s.Interval)
+	case Monthly:
+		return addMonths(start, n*s.Interval)
+	case Yearly:
+		return addMonths(start, 12*n*s.Interval)
+	}
+	return start
+}
+
+// Ended reports whether t is past the end date of the schedule.
+func (s *Schedule) Ended(t time.Time) bool {
+	return s.EndDate != nil && truncateDate(t).After(truncateDate(*s.EndDate))
+}
+
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+func truncateDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,52 @@
+package recurring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestScheduleOccurrence(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		n        int
+		want     time.Time
+	}{
+		{"daily", Schedule{Frequency: Daily, Interval: 1, StartDate: date(2026, 1, 30)}, 3, date(2026, 2, 2)},
+		{"every 2 weeks", Schedule{Frequency: Weekly, Interval: 2, StartDate: date(2026, 1, 1)}, 2, date(2026, 1, 29)},
+		{"monthly clamps to end of month", Schedule{Frequency: Monthly, Interval: 1, StartDate: date(2026, 1, 31)}, 1, date(2026, 2, 28)},
+		{"monthly does not drift", Schedule{Frequency: Monthly, Interval: 1, StartDate: date(2026, 1, 31)}, 2, date(2026, 3, 31)},
+		{"yearly on leap day", Schedule{Frequency: Yearly, Interval: 1, StartDate: date(2024, 2, 29)}, 1, date(2025, 2, 28)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.schedule.Occurrence(tt.n))
+		})
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	end := date(2025, 1, 1)
+	tests := []struct {
+		name     string
+		schedule Schedule
+		want     error
+	}{
+		{"ErrFrequencyInvalid", Schedule{Frequency: "hourly", Interval: 1, StartDate: date(2026, 1, 1)}, ErrFrequencyInvalid},
+		{"ErrIntervalInvalid", Schedule{Frequency: Daily, StartDate: date(2026, 1, 1)}, ErrIntervalInvalid},
+		{"ErrStartDateEmpty", Schedule{Frequency: Daily, Interval: 1}, ErrStartDateEmpty},
+		{"ErrEndDateInvalid", Schedule{Frequency: Daily, Interval: 1, StartDate: date(2026, 1, 1), EndDate: &end}, ErrEndDateInvalid},
+		{"Validate No Error", Schedule{Frequency: Monthly, Interval: 1, StartDate: date(2026, 1, 1)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.schedule.Validate())
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package recurring
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Worker periodically materializes due recurring expenses.
+type Worker struct {
+	svc      *Service
+	interval time.Duration
+	catchUp  bool
+	now      func() time.Time
+}
+
+func NewWorker(svc *Service, interval time.Duration, catchUp bool) *Worker {
+	return &Worker{
+		svc:      svc,
+		interval: interval,
+		catchUp:  catchUp,
+		now:      time.Now,
+	}
+}
+
+// Run materializes due occurrences immediately, which catches up after
+// downtime, and then on every tick until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.runOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) runOnce(ctx context.Context) {
+	for {
+		saved, locked, err := w.svc.Materialize(ctx, w.now(), w.catchUp)
+		if err != nil {
+			zap.L().Error("failed to materialize recurring expenses", zap.Error(err))
+			return
+		}
+		if saved > 0 {
+			zap.L().Info("materialized recurring expenses", zap.Int("count", saved))
+		}
+		// A locked recurring expense may save nothing, as when its schedule
+		// ended, so only a full batch tells that more may be due.
+		if locked < batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+)
+
+// Archived is an expense in the archive.
+type Archived struct {
+	ID int64 `json:"id"`
+	// Data is the expense, its attachments and its transitions as they were
+	// when it was archived. The storage keys of the attachments are left
+	// out.
+	Data       json.RawMessage `json:"data"`
+	ArchivedAt time.Time       `json:"archived_at"`
+}
+
+// ArchiveErasure is what EraseArchived deleted from the archive.
+type ArchiveErasure struct {
+	ExpenseIDs []int64
+	// StorageKeys are the keys of the content of the attachments of the
+	// deleted expenses, which is left in the store.
+	StorageKeys []string
+}
+
+// ListArchived returns the archived expenses owned by o.
+func (s *Service) ListArchived(ctx context.Context, o expense.Owner) ([]Archived, error) {
+	as, err := listArchived(ctx, s.conn(ctx), o)
+	if err != nil {
+		return nil, fmt.Errorf("listArchived(): %w", err)
+	}
+	return as, nil
+}
+
+// EraseArchived deletes the archived expenses owned by o in the unit of work
+// of ctx. The content of their attachments is left in the store: delete it
+// by the returned storage keys once the unit of work committed.
+func (s *Service) EraseArchived(ctx context.Context, o expense.Owner) (*ArchiveErasure, error) {
+	if o.ID == "" {
+		return nil, expense.ErrOwnerEmpty
+	}
+	er, err := deleteArchived(ctx, s.conn(ctx), o)
+	if err != nil {
+		return nil, fmt.Errorf("deleteArchived(): %w", err)
+	}
+	return er, nil
+}
+
+// ownedArchive selects the archived expenses of o.
+func ownedArchive(o expense.Owner) sq.Eq {
+	return sq.Eq{
+		"data->'expense'->>'tenant_id'": o.Tenant,
+		"data->'expense'->>'owner_id'":  o.ID,
+	}
+}
+
+func listArchived(ctx context.Context, db querier, o expense.Owner) ([]Archived, error) {
+	query, args, err := sq.Select(
+		"id",
+		`jsonb_set(data, '{attachments}', (
+		  SELECT coalesce(jsonb_agg(a - 'storage_key'), '[]') FROM jsonb_array_elements(data->'attachments') a
+		))`,
+		"archived_at",
+	).
+		From("expenses_archive").
+		Where(ownedArchive(o)).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	as := make([]Archived, 0)
+	for rows.Next() {
+		var a Archived
+		if err := rows.Scan(&a.ID, &a.Data, &a.ArchivedAt); err != nil {
+			return nil, err
+		}
+		as = append(as, a)
+	}
+	return as, rows.Err()
+}
+
+func deleteArchived(ctx context.Context, db querier, o expense.Owner) (*ArchiveErasure, error) {
+	query, args, err := sq.Delete("expenses_archive").
+		Where(ownedArchive(o)).
+		Suffix(`RETURNING id, ARRAY(SELECT a->>'storage_key' FROM jsonb_array_elements(data->'attachments') a)`).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	er := &ArchiveErasure{}
+	for rows.Next() {
+		var id int64
+		var keys []string
+		if err := rows.Scan(&id, pq.Array(&keys)); err != nil {
+			return nil, err
+		}
+		er.ExpenseIDs = append(er.ExpenseIDs, id)
+		er.StorageKeys = append(er.StorageKeys, keys...)
+	}
+	return er, rows.Err()
+}
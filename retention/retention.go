@@ -0,0 +1,331 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/attachment"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/txn"
+	"go.uber.org/zap"
+)
+
+// ErrPolicyInvalid is returned when a retention period is negative.
+var ErrPolicyInvalid = errors.New("retention periods must not be negative")
+
+// batchSize is the maximum number of expenses archived or purged by a single
+// transaction.
+const batchSize = 1000
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier = txn.Querier
+
+// Action is what a retention run did to expenses.
+type Action string
+
+// Actions of retention runs.
+const (
+	ActionArchive Action = "archive"
+	ActionPurge   Action = "purge"
+)
+
+// Policy decides which expenses a run archives and purges. A zero period
+// disables its action.
+type Policy struct {
+	// ArchiveAfterYears moves expenses spent more than this many years ago
+	// to the archive.
+	ArchiveAfterYears int
+	// PurgeAfterDays deletes for good expenses soft-deleted more than this
+	// many days ago.
+	PurgeAfterDays int
+}
+
+func (p Policy) Validate() error {
+	if p.ArchiveAfterYears < 0 || p.PurgeAfterDays < 0 {
+		return ErrPolicyInvalid
+	}
+	return nil
+}
+
+// Enabled reports whether the policy archives or purges anything.
+func (p Policy) Enabled() bool {
+	return p.ArchiveAfterYears > 0 || p.PurgeAfterDays > 0
+}
+
+// Report is the outcome of a Run. In a dry run the counts are those of the
+// expenses the run would archive and purge.
+type Report struct {
+	DryRun bool `json:"dry_run"`
+	// ArchiveBefore is the spend date, formatted as expense.DateLayout,
+	// before which expenses are archived.
+	ArchiveBefore string `json:"archive_before,omitempty"`
+	Archived      int64  `json:"archived"`
+	// PurgeBefore is the time before which soft-deleted expenses are
+	// purged.
+	PurgeBefore *time.Time `json:"purge_before,omitempty"`
+	Purged      int64      `json:"purged"`
+}
+
+// Entry is the audit log entry of one batch of archived or purged expenses.
+type Entry struct {
+	ID         int64     `json:"id"`
+	Action     Action    `json:"action"`
+	Cutoff     time.Time `json:"cutoff"`
+	ExpenseIDs []int64   `json:"expense_ids"`
+	Actor      string    `json:"actor"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Service struct {
+	db            *sql.DB
+	expenseSvc    *expense.Service
+	attachmentSvc *attachment.Service
+}
+
+func NewService(db *sql.DB, expenseSvc *expense.Service, attachmentSvc *attachment.Service) *Service {
+	return &Service{
+		db:            db,
+		expenseSvc:    expenseSvc,
+		attachmentSvc: attachmentSvc,
+	}
+}
+
+// conn returns the transaction of the unit of work of ctx, or the database.
+func (s *Service) conn(ctx context.Context) querier {
+	return txn.From(ctx, s.db)
+}
+
+// Run archives and purges the expenses p selects at now, in batches of one
+// transaction each, and records every batch in the audit log with the
+// actor of ctx. Every archived or purged expense gets an EventDeleted, and
+// the content of the attachments of purged expenses is deleted once their
+// batch committed. With dryRun it only counts them.
+func (s *Service) Run(ctx context.Context, p Policy, now time.Time, dryRun bool) (*Report, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := &Report{DryRun: dryRun}
+	if p.ArchiveAfterYears > 0 {
+		before := now.AddDate(-p.ArchiveAfterYears, 0, 0)
+		r.ArchiveBefore = before.Format(expense.DateLayout)
+		n, err := s.run(ctx, ActionArchive, before, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		r.Archived = n
+	}
+	if p.PurgeAfterDays > 0 {
+		before := now.AddDate(0, 0, -p.PurgeAfterDays)
+		r.PurgeBefore = &before
+		n, err := s.run(ctx, ActionPurge, before, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		r.Purged = n
+	}
+	return r, nil
+}
+
+func (s *Service) run(ctx context.Context, action Action, before time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		n, err := countExpenses(ctx, s.conn(ctx), action, before)
+		if err != nil {
+			return 0, fmt.Errorf("countExpenses(%s): %w", action, err)
+		}
+		return n, nil
+	}
+
+	var total int64
+	for {
+		var ids []int64
+		var removed []attachment.Attachment
+		err := s.expenseSvc.WithTx(ctx, func(ctx context.Context) error {
+			db := s.conn(ctx)
+			var err error
+			if ids, err = lockExpired(ctx, db, action, before, batchSize); err != nil {
+				return fmt.Errorf("lockExpired(%s): %w", action, err)
+			}
+			if len(ids) == 0 {
+				return nil
+			}
+			if err := s.expenseSvc.Retire(ctx, ids); err != nil {
+				return err
+			}
+			if action == ActionArchive {
+				if err := archiveExpenses(ctx, db, ids); err != nil {
+					return fmt.Errorf("archiveExpenses(): %w", err)
+				}
+			} else {
+				if removed, err = s.attachmentSvc.Remove(ctx, ids); err != nil {
+					return err
+				}
+				if err := purgeExpenses(ctx, db, ids); err != nil {
+					return fmt.Errorf("purgeExpenses(): %w", err)
+				}
+			}
+			actor := expense.ActorFromContext(ctx).ID
+			if err := createEntry(ctx, db, action, before, ids, actor); err != nil {
+				return fmt.Errorf("createEntry(): %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		if err := s.attachmentSvc.DeleteContent(ctx, removed); err != nil {
+			zap.L().Error("failed to delete the content of purged attachments", zap.Error(err))
+		}
+		total += int64(len(ids))
+		if len(ids) < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// Log returns the latest limit entries of the audit log, newest first.
+func (s *Service) Log(ctx context.Context, limit uint64) ([]Entry, error) {
+	es, err := listEntries(ctx, s.conn(ctx), limit)
+	if err != nil {
+		return nil, fmt.Errorf("listEntries(): %w", err)
+	}
+	return es, nil
+}
+
+// selectExpired selects the expenses action applies to at before.
+func selectExpired(action Action, before time.Time) sq.SelectBuilder {
+	qb := sq.Select("id").From("expenses")
+	if action == ActionArchive {
+		return qb.Where(sq.Eq{"deleted_at": nil}).
+			Where(sq.Lt{"spent_on": before.Format(expense.DateLayout)})
+	}
+	return qb.Where(sq.Lt{"deleted_at": before})
+}
+
+func countExpenses(ctx context.Context, db querier, action Action, before time.Time) (int64, error) {
+	query, args, err := sq.Select("count(*)").
+		FromSelect(selectExpired(action, before), "expired").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	err = db.QueryRowContext(ctx, query, args...).Scan(&n)
+	return n, err
+}
+
+// lockExpired locks up to limit expenses action applies to at before,
+// skipping those locked by another run, and returns their IDs.
+func lockExpired(ctx context.Context, db querier, action Action, before time.Time, limit uint64) ([]int64, error) {
+	query, args, err := selectExpired(action, before).
+		OrderBy("id").
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return queryIDs(ctx, db, query, args)
+}
+
+// archiveExpenses moves the expenses to the archive, with their attachments
+// and transitions.
+func archiveExpenses(ctx context.Context, db querier, ids []int64) error {
+	_, err := db.ExecContext(ctx, `WITH moved AS (
+		  DELETE FROM expenses WHERE id = ANY($1) RETURNING *
+		)
+		INSERT INTO expenses_archive (id, spent_on, data)
+		SELECT m.id, m.spent_on, jsonb_build_object(
+		  'expense', to_jsonb(m) - 'search',
+		  'attachments', (SELECT coalesce(jsonb_agg(a ORDER BY a.id), '[]') FROM attachments a WHERE a.expense_id = m.id),
+		  'transitions', (SELECT coalesce(jsonb_agg(t ORDER BY t.id), '[]') FROM expense_transitions t WHERE t.expense_id = m.id)
+		)
+		FROM moved m`, pq.Array(ids))
+	return err
+}
+
+// purgeExpenses deletes the expenses for good, with their transitions.
+func purgeExpenses(ctx context.Context, db querier, ids []int64) error {
+	query, args, err := sq.Delete("expenses").
+		Where("id = ANY(?)", pq.Array(ids)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// queryIDs runs query, written with ? placeholders, and returns the IDs of
+// its rows.
+func queryIDs(ctx context.Context, db querier, query string, args []interface{}) ([]int64, error) {
+	query, err := sq.Dollar.ReplacePlaceholders(query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func createEntry(ctx context.Context, db querier, action Action, cutoff time.Time, ids []int64, actor string) error {
+	query, args, err := sq.Insert("retention_log").
+		Columns("action", "cutoff", "expense_ids", "actor").
+		Values(action, cutoff, pq.Array(ids), actor).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func listEntries(ctx context.Context, db querier, limit uint64) ([]Entry, error) {
+	query, args, err := sq.Select("id", "action", "cutoff", "expense_ids", "actor", "created_at").
+		From("retention_log").
+		OrderBy("id DESC").
+		Limit(limit).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	es := make([]Entry, 0)
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.ID, &e.Action, &e.Cutoff, pq.Array(&e.ExpenseIDs), &e.Actor, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		es = append(es, e)
+	}
+	return es, rows.Err()
+}
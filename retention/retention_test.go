@@ -0,0 +1,125 @@
+package retention
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/attachment"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	assert.NoError(t, Policy{}.Validate())
+	assert.NoError(t, Policy{ArchiveAfterYears: 7, PurgeAfterDays: 30}.Validate())
+	assert.ErrorIs(t, Policy{ArchiveAfterYears: -1}.Validate(), ErrPolicyInvalid)
+	assert.ErrorIs(t, Policy{PurgeAfterDays: -1}.Validate(), ErrPolicyInvalid)
+	assert.False(t, Policy{}.Enabled())
+}
+
+func TestServiceRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store, err := attachment.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewService(db, expense.NewService(db), attachment.NewService(db, store, attachment.Limits{}))
+	ctx := expense.WithActor(context.Background(), expense.Actor{ID: "ana"})
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	policy := Policy{ArchiveAfterYears: 7, PurgeAfterDays: 30}
+	purgeBefore := now.AddDate(0, 0, -30)
+	expenseColumns := []string{"tenant_id", "id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
+	expired := func(ids ...int64) *sqlmock.Rows {
+		rows := sqlmock.NewRows(expenseColumns)
+		for _, id := range ids {
+			rows.AddRow("acme", id, 75, "Tea", "", pq.Array([]string{}), "THB", now, 75, nil, "draft", nil)
+		}
+		return rows
+	}
+
+	t.Run("Run() with dryRun only counts", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT count\(\*\) FROM \(SELECT id FROM expenses WHERE deleted_at IS NULL AND spent_on < \$1\)`).
+			WithArgs("2019-03-15").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM \(SELECT id FROM expenses WHERE deleted_at < \$1\)`).
+			WithArgs(purgeBefore).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		r, err := svc.Run(ctx, policy, now, true)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, &Report{DryRun: true, ArchiveBefore: "2019-03-15", Archived: 12, PurgeBefore: &purgeBefore, Purged: 3}, r)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Run() archives and purges and logs each batch", func(t *testing.T) {
+		if err := store.Put(context.Background(), "3/receipt", strings.NewReader("%PDF-1."), 7, "application/pdf"); err != nil {
+			t.Fatal(err)
+		}
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM expenses WHERE deleted_at IS NULL AND spent_on < \$1 ORDER BY id LIMIT 1000 FOR UPDATE SKIP LOCKED`).
+			WithArgs("2019-03-15").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+		mock.ExpectQuery(`SELECT tenant_id, (.+) FROM expenses WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int64{1, 2})).
+			WillReturnRows(expired(1, 2))
+		mock.ExpectExec(`INSERT INTO expense_events \(type,expense_id,tenant_id,data\) VALUES \(\$1,\$2,\$3,\$4\),\(\$5,\$6,\$7,\$8\)`).
+			WithArgs(expense.EventDeleted, 1, "acme", sqlmock.AnyArg(), expense.EventDeleted, 2, "acme", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(2, 2))
+		mock.ExpectExec(`WITH moved AS \( DELETE FROM expenses WHERE id = ANY\(\$1\) RETURNING \* \) INSERT INTO expenses_archive`).
+			WithArgs(pq.Array([]int64{1, 2})).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`INSERT INTO retention_log`).
+			WithArgs(ActionArchive, now.AddDate(-7, 0, 0), pq.Array([]int64{1, 2}), "ana").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM expenses WHERE deleted_at < \$1`).
+			WithArgs(purgeBefore).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectQuery(`SELECT tenant_id, (.+) FROM expenses WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int64{3})).
+			WillReturnRows(expired(3))
+		mock.ExpectExec(`INSERT INTO expense_events`).
+			WithArgs(expense.EventDeleted, 3, "acme", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectQuery(`DELETE FROM attachments WHERE expense_id = ANY\(\$1\) RETURNING`).
+			WithArgs(pq.Array([]int64{3})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "expense_id", "filename", "content_type", "size", "sha256", "storage_key", "created_at"}).
+				AddRow(4, 3, "receipt.pdf", "application/pdf", 7, "", "3/receipt", now))
+		mock.ExpectExec(`DELETE FROM expenses WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int64{3})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO retention_log`).
+			WithArgs(ActionPurge, purgeBefore, pq.Array([]int64{3}), "ana").
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		r, err := svc.Run(ctx, policy, now, false)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(2), r.Archived)
+			assert.Equal(t, int64(1), r.Purged)
+			_, err := store.Get(context.Background(), "3/receipt")
+			assert.ErrorIs(t, err, attachment.ErrBlobNotFound, "the content of purged attachments is deleted")
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Run() rejects a negative period", func(t *testing.T) {
+		_, err := svc.Run(ctx, Policy{PurgeAfterDays: -1}, now, false)
+
+		assert.ErrorIs(t, err, ErrPolicyInvalid)
+	})
+}
@@ -0,0 +1,52 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Worker periodically archives and purges expenses by a policy.
+type Worker struct {
+	svc      *Service
+	policy   Policy
+	interval time.Duration
+	now      func() time.Time
+}
+
+func NewWorker(svc *Service, policy Policy, interval time.Duration) *Worker {
+	return &Worker{
+		svc:      svc,
+		policy:   policy,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run applies the policy immediately and then on every tick until ctx is
+// done.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.runOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) runOnce(ctx context.Context) {
+	r, err := w.svc.Run(ctx, w.policy, w.now(), false)
+	if err != nil {
+		zap.L().Error("failed to apply the retention policy", zap.Error(err))
+		return
+	}
+	if r.Archived > 0 || r.Purged > 0 {
+		zap.L().Info("applied the retention policy", zap.Int64("archived", r.Archived), zap.Int64("purged", r.Purged))
+	}
+}
@@ -0,0 +1,201 @@
+// Package rule tags expenses with user-defined rules.
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/phuangpheth/assessment/expense"
+)
+
+// ErrNotFound is returned when the rule could not be found.
+var ErrNotFound = errors.New("not found")
+
+// ErrNameEmpty is returned when the rule has no name.
+var ErrNameEmpty = errors.New("empty name")
+
+// ErrConditionsEmpty is returned when the rule has no conditions.
+var ErrConditionsEmpty = errors.New("rule requires at least one condition")
+
+// ErrTagsEmpty is returned when the rule adds no tags.
+var ErrTagsEmpty = errors.New("rule requires at least one tag")
+
+// ErrConditionInvalid is returned when a condition has an unknown field or
+// operator, or a value the operator cannot use.
+var ErrConditionInvalid = errors.New("invalid condition")
+
+// Fields of an expense that conditions test.
+const (
+	FieldTitle    = "title"
+	FieldNote     = "note"
+	FieldCurrency = "currency"
+	FieldTag      = "tag"
+	FieldAmount   = "amount"
+)
+
+// Operators of conditions. Matches, Contains and Equals test text fields;
+// Contains and Equals ignore case. Equals on FieldTag tests whether the
+// expense has the tag. The comparisons test FieldAmount.
+const (
+	OpMatches  = "matches"
+	OpContains = "contains"
+	OpEquals   = "equals"
+	OpGT       = "gt"
+	OpGTE      = "gte"
+	OpLT       = "lt"
+	OpLTE      = "lte"
+)
+
+// Condition tests one field of an expense.
+type Condition struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	// Value is a regular expression in RE2 syntax for OpMatches, where
+	// (?i) makes it ignore case, and a number for the comparisons. Amounts
+	// are compared in the base currency.
+	Value string `json:"value"`
+}
+
+// Rule adds Tags to the expenses that meet all of its Conditions. Rules are
+// evaluated by ascending Priority, then by ID.
+type Rule struct {
+	ID         int64       `json:"id"`
+	Name       string      `json:"name"`
+	Priority   int         `json:"priority"`
+	Conditions []Condition `json:"conditions"`
+	Tags       []string    `json:"tags"`
+	// Stop ends the evaluation of later rules when the rule matched.
+	Stop bool `json:"stop"`
+
+	// tests are the compiled Conditions.
+	tests []func(e *expense.Expense) bool
+}
+
+func (r *Rule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrNameEmpty
+	}
+	if len(r.Conditions) == 0 {
+		return ErrConditionsEmpty
+	}
+	if len(r.Tags) == 0 {
+		return ErrTagsEmpty
+	}
+	return r.compile()
+}
+
+// compile compiles the conditions of the rule once, so that matching many
+// expenses does not compile their regular expressions again.
+func (r *Rule) compile() error {
+	tests := make([]func(e *expense.Expense) bool, 0, len(r.Conditions))
+	for i := range r.Conditions {
+		test, err := r.Conditions[i].compile()
+		if err != nil {
+			return err
+		}
+		tests = append(tests, test)
+	}
+	r.tests = tests
+	return nil
+}
+
+// Match reports whether e meets all conditions of the rule.
+func (r *Rule) Match(e *expense.Expense) (bool, error) {
+	if len(r.tests) != len(r.Conditions) {
+		if err := r.compile(); err != nil {
+			return false, err
+		}
+	}
+	for _, test := range r.tests {
+		if !test(e) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// compile returns the test of the condition.
+func (c *Condition) compile() (func(e *expense.Expense) bool, error) {
+	if c.Field == FieldAmount {
+		want, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: amount %s %q is not a number", ErrConditionInvalid, c.Op, c.Value)
+		}
+		var cmp func(a float64) bool
+		switch c.Op {
+		case OpGT:
+			cmp = func(a float64) bool { return a > want }
+		case OpGTE:
+			cmp = func(a float64) bool { return a >= want }
+		case OpLT:
+			cmp = func(a float64) bool { return a < want }
+		case OpLTE:
+			cmp = func(a float64) bool { return a <= want }
+		case OpEquals:
+			cmp = func(a float64) bool { return a == want }
+		default:
+			return nil, fmt.Errorf("%w: unknown operator %q for amount", ErrConditionInvalid, c.Op)
+		}
+		return func(e *expense.Expense) bool { return cmp(e.BaseAmount) }, nil
+	}
+
+	var test func(s string) bool
+	switch c.Op {
+	case OpMatches:
+		re, err := regexp.Compile(c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrConditionInvalid, err)
+		}
+		test = re.MatchString
+	case OpContains:
+		want := strings.ToLower(c.Value)
+		test = func(s string) bool { return strings.Contains(strings.ToLower(s), want) }
+	case OpEquals:
+		test = func(s string) bool { return strings.EqualFold(s, c.Value) }
+	default:
+		return nil, fmt.Errorf("%w: unknown operator %q for %s", ErrConditionInvalid, c.Op, c.Field)
+	}
+
+	switch c.Field {
+	case FieldTitle:
+		return func(e *expense.Expense) bool { return test(e.Title) }, nil
+	case FieldNote:
+		return func(e *expense.Expense) bool { return test(e.Note) }, nil
+	case FieldCurrency:
+		return func(e *expense.Expense) bool { return test(e.Currency) }, nil
+	case FieldTag:
+		return func(e *expense.Expense) bool {
+			for _, t := range e.Tags {
+				if test(t) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown field %q", ErrConditionInvalid, c.Field)
+}
+
+// Apply adds to e the tags of every rule of rules that e meets, in order,
+// until a matching rule stops the evaluation. It returns the added tags.
+// rules must be sorted by priority.
+func Apply(rules []Rule, e *expense.Expense) ([]string, error) {
+	before := len(e.Tags)
+	for i := range rules {
+		match, err := rules[i].Match(e)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", rules[i].ID, err)
+		}
+		if !match {
+			continue
+		}
+		e.Tags = expense.MergeTags(e.Tags, rules[i].Tags)
+		if rules[i].Stop {
+			break
+		}
+	}
+	return e.Tags[before:], nil
+}
@@ -0,0 +1,75 @@
+package rule
+
+import (
+	"testing"
+
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleValidate(t *testing.T) {
+	valid := func() Rule {
+		return Rule{
+			Name:       "Transport",
+			Conditions: []Condition{{Field: FieldTitle, Op: OpMatches, Value: "(?i)grab|taxi"}},
+			Tags:       []string{"transport"},
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(r *Rule)
+		err    error
+	}{
+		{"valid", func(r *Rule) {}, nil},
+		{"no name", func(r *Rule) { r.Name = " " }, ErrNameEmpty},
+		{"no conditions", func(r *Rule) { r.Conditions = nil }, ErrConditionsEmpty},
+		{"no tags", func(r *Rule) { r.Tags = nil }, ErrTagsEmpty},
+		{"invalid regexp", func(r *Rule) { r.Conditions[0].Value = "(" }, ErrConditionInvalid},
+		{"unknown field", func(r *Rule) { r.Conditions[0].Field = "payee" }, ErrConditionInvalid},
+		{"comparison of text", func(r *Rule) { r.Conditions[0].Op = OpGT }, ErrConditionInvalid},
+		{"amount is not a number", func(r *Rule) {
+			r.Conditions[0] = Condition{Field: FieldAmount, Op: OpGT, Value: "much"}
+		}, ErrConditionInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			tt.modify(&r)
+
+			assert.ErrorIs(t, r.Validate(), tt.err)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	rules := []Rule{
+		{ID: 1, Conditions: []Condition{{Field: FieldTitle, Op: OpMatches, Value: "(?i)grab|taxi"}}, Tags: []string{"transport"}},
+		{ID: 2, Conditions: []Condition{{Field: FieldAmount, Op: OpGT, Value: "1000"}}, Tags: []string{"large"}, Stop: true},
+		{ID: 3, Conditions: []Condition{{Field: FieldTag, Op: OpEquals, Value: "food"}}, Tags: []string{"meals"}},
+	}
+	tests := []struct {
+		name  string
+		e     expense.Expense
+		added []string
+		tags  []string
+	}{
+		{"regexp ignoring case", expense.Expense{Title: "GRAB ride", BaseAmount: 120}, []string{"transport"}, []string{"transport"}},
+		{"stop ends evaluation", expense.Expense{Title: "Taxi", BaseAmount: 1500, Tags: []string{"food"}}, []string{"transport", "large"}, []string{"food", "transport", "large"}},
+		{"tag condition", expense.Expense{Title: "Noodles", BaseAmount: 50, Tags: []string{"food"}}, []string{"meals"}, []string{"food", "meals"}},
+		{"existing tags are not repeated", expense.Expense{Title: "Taxi", BaseAmount: 80, Tags: []string{"transport"}}, []string{}, []string{"transport"}},
+		{"no match", expense.Expense{Title: "Books", BaseAmount: 300}, nil, nil},
+		{"amount in the base currency", expense.Expense{Title: "Books", Amount: 300000, Currency: "LAK", BaseAmount: 480}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.e
+
+			added, err := Apply(rules, &e)
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.added, added)
+				assert.Equal(t, tt.tags, e.Tags)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package rule
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/txn"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier = txn.Querier
+
+// Change is the tags a rule adds to an existing expense.
+type Change struct {
+	ExpenseID int64    `json:"expense_id"`
+	Title     string   `json:"title"`
+	Tags      []string `json:"tags"`
+	Added     []string `json:"added"`
+}
+
+type Service struct {
+	db         *sql.DB
+	expenseSvc *expense.Service
+}
+
+func NewService(db *sql.DB, expenseSvc *expense.Service) *Service {
+	return &Service{
+		db:         db,
+		expenseSvc: expenseSvc,
+	}
+}
+
+// conn returns the transaction of the unit of work of ctx, or the database.
+func (s *Service) conn(ctx context.Context) querier {
+	return txn.From(ctx, s.db)
+}
+
+// inTenant selects the rules of the tenant of ctx.
+func inTenant(ctx context.Context) sq.Eq {
+	return sq.Eq{"tenant_id": expense.TenantFromContext(ctx)}
+}
+
+func (s *Service) Save(ctx context.Context, r *Rule) (*Rule, error) {
+	if err := createRule(ctx, s.conn(ctx), r); err != nil {
+		return nil, fmt.Errorf("createRule(): %w", err)
+	}
+	return r, nil
+}
+
+func (s *Service) Update(ctx context.Context, r *Rule) (*Rule, error) {
+	if err := updateRule(ctx, s.conn(ctx), r); err != nil {
+		return nil, fmt.Errorf("updateRule(%d): %w", r.ID, err)
+	}
+	return r, nil
+}
+
+func (s *Service) GetByID(ctx context.Context, id int64) (*Rule, error) {
+	r, err := getRuleByID(ctx, s.conn(ctx), id)
+	if err != nil {
+		return nil, fmt.Errorf("getRuleByID(%d): %w", id, err)
+	}
+	return r, nil
+}
+
+// List returns every rule of the tenant of ctx in the order they are
+// evaluated.
+func (s *Service) List(ctx context.Context) ([]Rule, error) {
+	rs, err := listRules(ctx, s.conn(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("listRules(): %w", err)
+	}
+	return rs, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	if err := deleteRule(ctx, s.conn(ctx), id); err != nil {
+		return fmt.Errorf("deleteRule(%d): %w", id, err)
+	}
+	return nil
+}
+
+// Tag implements expense.Tagger. It applies every rule of the tenant of ctx
+// to e.
+func (s *Service) Tag(ctx context.Context, e *expense.Expense) error {
+	rs, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = Apply(rs, e)
+	return err
+}
+
+// Backfill applies the rule to every existing expense and returns the
+// changes, oldest expense first. With dryRun the changes are only
+// computed; otherwise they are saved in one unit of work.
+func (s *Service) Backfill(ctx context.Context, id int64, dryRun bool) ([]Change, error) {
+	var changes []Change
+	err := s.expenseSvc.WithTx(ctx, func(ctx context.Context) error {
+		r, err := s.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		exps, err := s.expenseSvc.List(ctx)
+		if err != nil {
+			return err
+		}
+
+		changes = make([]Change, 0)
+		for i := len(exps) - 1; i >= 0; i-- {
+			e := &exps[i]
+			match, err := r.Match(e)
+			if err != nil {
+				return fmt.Errorf("rule %d: %w", r.ID, err)
+			}
+			if !match {
+				continue
+			}
+			tags := expense.MergeTags(e.Tags, r.Tags)
+			if len(tags) == len(e.Tags) {
+				continue
+			}
+			added := tags[len(e.Tags):]
+			if !dryRun {
+				if _, err := s.expenseSvc.AddTags(ctx, e.ID, added); err != nil {
+					return err
+				}
+			}
+			changes = append(changes, Change{
+				ExpenseID: e.ID,
+				Title:     e.Title,
+				Tags:      e.Tags,
+				Added:     added,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func createRule(ctx context.Context, db querier, r *Rule) error {
+	conditions, err := json.Marshal(r.Conditions)
+	if err != nil {
+		return err
+	}
+	query, args, err := sq.Insert("rules").
+		Columns("tenant_id", "name", "priority", "conditions", "tags", "stop").
+		Values(expense.TenantFromContext(ctx), r.Name, r.Priority, conditions, pq.Array(r.Tags), r.Stop).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	return db.QueryRowContext(ctx, query, args...).Scan(&r.ID)
+}
+
+func updateRule(ctx context.Context, db querier, r *Rule) error {
+	conditions, err := json.Marshal(r.Conditions)
+	if err != nil {
+		return err
+	}
+	query, args, err := sq.Update("rules").
+		Set("name", r.Name).
+		Set("priority", r.Priority).
+		Set("conditions", conditions).
+		Set("tags", pq.Array(r.Tags)).
+		Set("stop", r.Stop).
+		Where(sq.Eq{"id": r.ID}).
+		Where(inTenant(ctx)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func getRuleByID(ctx context.Context, db querier, id int64) (*Rule, error) {
+	query, args, err := sq.Select(ruleColumns...).
+		From("rules").
+		Where(sq.Eq{"id": id}).
+		Where(inTenant(ctx)).
+		Limit(1).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	row := db.QueryRowContext(ctx, query, args...)
+	r, err := scanRule(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func listRules(ctx context.Context, db querier) ([]Rule, error) {
+	query, args, err := sq.Select(ruleColumns...).
+		From("rules").
+		Where(inTenant(ctx)).
+		OrderBy("priority", "id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rs := make([]Rule, 0)
+	for rows.Next() {
+		r, err := scanRule(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+func deleteRule(ctx context.Context, db querier, id int64) error {
+	query, args, err := sq.Delete("rules").
+		Where(sq.Eq{"id": id}).
+		Where(inTenant(ctx)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+var ruleColumns = []string{
+	"id",
+	"name",
+	"priority",
+	"conditions",
+	"tags",
+	"stop",
+}
+
+func scanRule(scan func(...any) error) (r Rule, _ error) {
+	var conditions []byte
+	err := scan(
+		&r.ID,
+		&r.Name,
+		&r.Priority,
+		&conditions,
+		pq.Array(&r.Tags),
+		&r.Stop,
+	)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(conditions, &r.Conditions); err != nil {
+		return r, err
+	}
+	return r, r.compile()
+}
@@ -0,0 +1,111 @@
+package rule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+var expenseColumns = []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
+
+func TestService(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	expenseSvc := expense.NewService(db)
+	svc := NewService(db, expenseSvc)
+	expenseSvc.SetTagger(svc)
+	ctx := context.Background()
+	transport := func() *sqlmock.Rows {
+		return sqlmock.NewRows(ruleColumns).
+			AddRow(1, "Transport", 0, []byte(`[{"field":"title","op":"matches","value":"(?i)grab|taxi"}]`), pq.Array([]string{"transport"}), false)
+	}
+	expenses := func() *sqlmock.Rows {
+		return sqlmock.NewRows(expenseColumns).
+			AddRow(2, 80, "Grab", "", pq.Array([]string{"transport"}), "THB", time.Now(), 80, nil, "approved", nil).
+			AddRow(1, 120, "Taxi", "", pq.Array([]string{"work"}), "THB", time.Now(), 120, nil, "approved", nil)
+	}
+
+	t.Run("Save() of the expense service applies the rules", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM rules WHERE tenant_id = \$1 ORDER BY priority, id`).WithArgs("").WillReturnRows(transport())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+			WithArgs(95.0, "Grab to airport", "", pq.Array([]string{"travel", "transport"}), "", sqlmock.AnyArg(), 95.0, nil, expense.StatusDraft, "", "").
+			WillReturnRows(sqlmock.NewRows(expenseColumns).
+				AddRow(3, 95, "Grab to airport", "", pq.Array([]string{"travel", "transport"}), "THB", time.Now(), 95, nil, "draft", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		e, err := expenseSvc.Save(ctx, &expense.Expense{Amount: 95, Title: "Grab to airport", Tags: []string{"travel"}})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"travel", "transport"}, e.Tags)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Backfill() with dryRun saves nothing", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM rules`).WithArgs(1, "").WillReturnRows(transport())
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WillReturnRows(expenses())
+		mock.ExpectCommit()
+
+		changes, err := svc.Backfill(ctx, 1, true)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []Change{{ExpenseID: 1, Title: "Taxi", Tags: []string{"work"}, Added: []string{"transport"}}}, changes)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Backfill() adds the tags", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT (.+) FROM rules`).WithArgs(1, "").WillReturnRows(transport())
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WillReturnRows(expenses())
+		mock.ExpectQuery(`SELECT (.+) FROM expenses`).WithArgs(1, "").
+			WillReturnRows(sqlmock.NewRows(expenseColumns).
+				AddRow(1, 120, "Taxi", "", pq.Array([]string{"work"}), "THB", time.Now(), 120, nil, "approved", nil))
+		mock.ExpectExec(`UPDATE expenses SET tags`).
+			WithArgs(pq.Array([]string{"work", "transport"}), 1, "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		changes, err := svc.Backfill(ctx, 1, false)
+
+		if assert.NoError(t, err) {
+			assert.Len(t, changes, 1)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rules are scoped to the tenant", func(t *testing.T) {
+		acme := expense.WithTenant(ctx, "acme")
+		mock.ExpectQuery(`INSERT INTO rules`).
+			WithArgs("acme", "Transport", 0, sqlmock.AnyArg(), pq.Array([]string{"transport"}), false).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectQuery(`SELECT (.+) FROM rules WHERE tenant_id = \$1`).WithArgs("acme").WillReturnRows(sqlmock.NewRows(ruleColumns))
+		mock.ExpectQuery(`SELECT (.+) FROM rules WHERE id = \$1 AND tenant_id = \$2`).WithArgs(1, "acme").WillReturnRows(sqlmock.NewRows(ruleColumns))
+		mock.ExpectExec(`DELETE FROM rules WHERE id = \$1 AND tenant_id = \$2`).WithArgs(1, "acme").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := svc.Save(acme, &Rule{Name: "Transport", Conditions: []Condition{{Field: FieldTitle, Op: OpContains, Value: "taxi"}}, Tags: []string{"transport"}})
+		assert.NoError(t, err)
+		rs, err := svc.List(acme)
+		assert.NoError(t, err)
+		assert.Empty(t, rs)
+		_, err = svc.GetByID(acme, 1)
+		assert.ErrorIs(t, err, ErrNotFound)
+		err = svc.Delete(acme, 1)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,143 @@
+package split
+
+import (
+	"math"
+	"sort"
+)
+
+// maxExact is the largest number of people with a non-zero balance for which
+// SettleUp searches for the fewest transfers. Larger groups are settled
+// greedily, which needs at most one transfer less than there are people.
+const maxExact = 16
+
+// SettleUp returns the fewest transfers that bring every balance to zero.
+//
+// A group of n people whose balances sum to zero can always be settled with
+// n-1 transfers, so the fewest transfers are found by splitting the people
+// into as many zero-sum groups as possible. The split is searched exactly
+// over subsets for up to maxExact people; each group is then settled by
+// repeatedly paying the largest creditor from the largest debtor.
+func SettleUp(balances []Balance) []Transfer {
+	people := make([]Balance, 0, len(balances))
+	for _, b := range balances {
+		if toCents(b.Amount) != 0 {
+			people = append(people, b)
+		}
+	}
+	transfers := make([]Transfer, 0)
+	if len(people) == 0 {
+		return transfers
+	}
+	if len(people) > maxExact {
+		return append(transfers, settleGreedy(people)...)
+	}
+	for _, group := range zeroSumGroups(people) {
+		transfers = append(transfers, settleGreedy(group)...)
+	}
+	return transfers
+}
+
+// zeroSumGroups splits people into the largest number of groups whose
+// balances each sum to zero.
+func zeroSumGroups(people []Balance) [][]Balance {
+	n := len(people)
+	full := 1<<n - 1
+	sums := make([]int64, full+1)
+	for mask := 1; mask <= full; mask++ {
+		low := mask & -mask
+		i := bitIndex(low)
+		sums[mask] = sums[mask^low] + toCents(people[i].Amount)
+	}
+
+	// groups[mask] is the most zero-sum groups the people in mask can be
+	// split into when the people outside mask are settled separately.
+	groups := make([]int, full+1)
+	for mask := 1; mask <= full; mask++ {
+		best := 0
+		for rest := mask; rest != 0; rest &= rest - 1 {
+			if g := groups[mask^(rest&-rest)]; g > best {
+				best = g
+			}
+		}
+		if sums[mask] == 0 {
+			best++
+		}
+		groups[mask] = best
+	}
+
+	// Walk back from everyone, removing one person at a time along an
+	// optimal path; every zero-sum mask on the way closes a group.
+	var out [][]Balance
+	var group []Balance
+	for mask := full; mask != 0; {
+		want := groups[mask]
+		if sums[mask] == 0 {
+			want--
+		}
+		for rest := mask; rest != 0; rest &= rest - 1 {
+			bit := rest & -rest
+			if groups[mask^bit] == want {
+				group = append(group, people[bitIndex(bit)])
+				mask ^= bit
+				break
+			}
+		}
+		if sums[mask] == 0 {
+			out = append(out, group)
+			group = nil
+		}
+	}
+	return out
+}
+
+// settleGreedy settles people, whose balances sum to zero, with at most one
+// transfer less than there are people.
+func settleGreedy(people []Balance) []Transfer {
+	type person struct {
+		name  string
+		cents int64
+	}
+	var creditors, debtors []person
+	for _, b := range people {
+		switch c := toCents(b.Amount); {
+		case c > 0:
+			creditors = append(creditors, person{b.Name, c})
+		case c < 0:
+			debtors = append(debtors, person{b.Name, -c})
+		}
+	}
+
+	var transfers []Transfer
+	for len(creditors) > 0 && len(debtors) > 0 {
+		sort.SliceStable(creditors, func(i, j int) bool { return creditors[i].cents > creditors[j].cents })
+		sort.SliceStable(debtors, func(i, j int) bool { return debtors[i].cents > debtors[j].cents })
+		c, d := &creditors[0], &debtors[0]
+		amount := c.cents
+		if d.cents < amount {
+			amount = d.cents
+		}
+		transfers = append(transfers, Transfer{From: d.name, To: c.name, Amount: float64(amount) / 100})
+		c.cents -= amount
+		d.cents -= amount
+		if c.cents == 0 {
+			creditors = creditors[1:]
+		}
+		if d.cents == 0 {
+			debtors = debtors[1:]
+		}
+	}
+	return transfers
+}
+
+func bitIndex(bit int) int {
+	i := 0
+	for bit > 1 {
+		bit >>= 1
+		i++
+	}
+	return i
+}
+
+func toCents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
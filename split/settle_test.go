@@ -0,0 +1,60 @@
+package split
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettleUp(t *testing.T) {
+	t.Run("SettleUp() pays creditors from debtors", func(t *testing.T) {
+		got := SettleUp([]Balance{
+			{Name: "Ana", Amount: 60},
+			{Name: "Bo", Amount: -30},
+			{Name: "Chai", Amount: -30},
+		})
+
+		assert.Equal(t, []Transfer{
+			{From: "Bo", To: "Ana", Amount: 30},
+			{From: "Chai", To: "Ana", Amount: 30},
+		}, got)
+	})
+
+	t.Run("SettleUp() settles zero-sum groups separately", func(t *testing.T) {
+		// Matching the largest balances needs four transfers here; settling
+		// Ana with Chai first needs three.
+		balances := map[string]float64{"Ana": -8, "Bo": -7, "Chai": 8, "Dao": -3, "Ek": 10}
+		got := SettleUp([]Balance{
+			{Name: "Ana", Amount: -8},
+			{Name: "Bo", Amount: -7},
+			{Name: "Chai", Amount: 8},
+			{Name: "Dao", Amount: -3},
+			{Name: "Ek", Amount: 10},
+		})
+
+		assert.Len(t, got, 3)
+		assertSettles(t, got, balances)
+	})
+
+	t.Run("SettleUp() ignores settled people", func(t *testing.T) {
+		got := SettleUp([]Balance{{Name: "Ana", Amount: 0}, {Name: "Bo", Amount: 0.001}})
+
+		assert.Empty(t, got)
+	})
+}
+
+// assertSettles checks that transfers bring every balance to zero.
+func assertSettles(t *testing.T, transfers []Transfer, balances map[string]float64) {
+	t.Helper()
+	cents := make(map[string]int64)
+	for name, amount := range balances {
+		cents[name] = toCents(amount)
+	}
+	for _, tr := range transfers {
+		cents[tr.From] += toCents(tr.Amount)
+		cents[tr.To] -= toCents(tr.Amount)
+	}
+	for name, c := range cents {
+		assert.Zerof(t, c, "balance of %s", name)
+	}
+}
@@ -0,0 +1,201 @@
+// Package split computes who owes whom for split expenses and records the
+// settlements between people.
+package split
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/txn"
+)
+
+// ErrPersonEmpty is returned when a settlement has no payer or payee.
+var ErrPersonEmpty = errors.New("from and to must not be empty")
+
+// ErrSamePerson is returned when someone settles with themselves.
+var ErrSamePerson = errors.New("from and to must be different people")
+
+// ErrAmountInvalid is returned when a settlement amount is less than or equal
+// to zero.
+var ErrAmountInvalid = errors.New("amount must be greater than zero")
+
+// Settlement records that From paid Amount to To.
+type Settlement struct {
+	ID        int64     `json:"id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Amount    float64   `json:"amount"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *Settlement) Validate() error {
+	if s.From == "" || s.To == "" {
+		return ErrPersonEmpty
+	}
+	if s.From == s.To {
+		return ErrSamePerson
+	}
+	if s.Amount <= 0 {
+		return ErrAmountInvalid
+	}
+	return nil
+}
+
+// Balance is the net amount a person is owed. It is negative when they owe.
+type Balance struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+// Transfer is a payment that settles debts.
+type Transfer struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Amount float64 `json:"amount"`
+}
+
+// Balances are the balances of everyone who shares expenses and the fewest
+// transfers that settle them.
+type Balances struct {
+	// Currency of every amount, the base currency of the expenses.
+	Currency  string     `json:"currency,omitempty"`
+	Balances  []Balance  `json:"balances"`
+	Transfers []Transfer `json:"transfers"`
+}
+
+type Service struct {
+	db         *sql.DB
+	expenseSvc *expense.Service
+}
+
+func NewService(db *sql.DB, expenseSvc *expense.Service) *Service {
+	return &Service{
+		db:         db,
+		expenseSvc: expenseSvc,
+	}
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier = txn.Querier
+
+// conn returns the transaction of the unit of work of ctx, or the database.
+func (s *Service) conn(ctx context.Context) querier {
+	return txn.From(ctx, s.db)
+}
+
+// inTenant selects the settlements of the tenant of ctx.
+func inTenant(ctx context.Context) sq.Eq {
+	return sq.Eq{"tenant_id": expense.TenantFromContext(ctx)}
+}
+
+// Balances returns who owes whom after every split expense and settlement.
+// Shares are taken from the base amount of the expenses.
+func (s *Service) Balances(ctx context.Context) (*Balances, error) {
+	exps, err := s.expenseSvc.ListSplit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sts, err := listSettlements(ctx, s.conn(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("listSettlements(): %w", err)
+	}
+
+	cents := make(map[string]int64)
+	for _, e := range exps {
+		total := e.BaseAmount
+		if total == 0 {
+			total = e.Amount
+		}
+		cents[e.Split.PaidBy] += toCents(total)
+		for i, share := range e.Split.Shares(total) {
+			cents[e.Split.Participants[i].Name] -= toCents(share)
+		}
+	}
+	for _, st := range sts {
+		cents[st.From] += toCents(st.Amount)
+		cents[st.To] -= toCents(st.Amount)
+	}
+
+	names := make([]string, 0, len(cents))
+	for name := range cents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	b := &Balances{
+		Currency: s.expenseSvc.BaseCurrency(),
+		Balances: make([]Balance, 0, len(names)),
+	}
+	for _, name := range names {
+		b.Balances = append(b.Balances, Balance{Name: name, Amount: float64(cents[name]) / 100})
+	}
+	b.Transfers = SettleUp(b.Balances)
+	return b, nil
+}
+
+// SaveSettlement records the settlement in the tenant of ctx.
+func (s *Service) SaveSettlement(ctx context.Context, st *Settlement) (*Settlement, error) {
+	if err := createSettlement(ctx, s.conn(ctx), st); err != nil {
+		return nil, fmt.Errorf("createSettlement(): %w", err)
+	}
+	return st, nil
+}
+
+// ListSettlements returns the settlements of the tenant of ctx.
+func (s *Service) ListSettlements(ctx context.Context) ([]Settlement, error) {
+	sts, err := listSettlements(ctx, s.conn(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("listSettlements(): %w", err)
+	}
+	return sts, nil
+}
+
+func createSettlement(ctx context.Context, db querier, st *Settlement) error {
+	query, args, err := sq.Insert("settlements").
+		Columns("tenant_id", "from_name", "to_name", "amount", "note").
+		Values(expense.TenantFromContext(ctx), st.From, st.To, st.Amount, st.Note).
+		Suffix("RETURNING id, created_at").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	return db.QueryRowContext(ctx, query, args...).Scan(&st.ID, &st.CreatedAt)
+}
+
+func listSettlements(ctx context.Context, db querier) ([]Settlement, error) {
+	query, args, err := sq.Select("id", "from_name", "to_name", "amount", "note", "created_at").
+		From("settlements").
+		Where(inTenant(ctx)).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sts := make([]Settlement, 0)
+	for rows.Next() {
+		var st Settlement
+		if err := rows.Scan(&st.ID, &st.From, &st.To, &st.Amount, &st.Note, &st.CreatedAt); err != nil {
+			return nil, err
+		}
+		sts = append(sts, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sts, nil
+}
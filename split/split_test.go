@@ -0,0 +1,95 @@
+package split
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceBalances(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	expenseSvc := expense.NewService(db)
+	svc := NewService(db, expenseSvc)
+	expenseColumns := []string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}
+	settlementColumns := []string{"id", "from_name", "to_name", "amount", "note", "created_at"}
+
+	t.Run("Balances() nets shares and settlements", func(t *testing.T) {
+		dinner := `{"paid_by":"Ana","method":"equal","participants":[{"name":"Ana"},{"name":"Bo"},{"name":"Chai"}]}`
+		taxi := `{"paid_by":"Bo","method":"exact","participants":[{"name":"Chai","amount":20000}]}`
+		mock.ExpectQuery(`SELECT (.+) FROM expenses WHERE deleted_at IS NULL AND tenant_id = \$1 AND split IS NOT NULL ORDER BY id`).
+			WillReturnRows(sqlmock.NewRows(expenseColumns).
+				AddRow(1, 900, "Dinner", "", pq.Array([]string{}), "THB", time.Now(), 900, dinner, "draft", nil).
+				AddRow(2, 20000, "Taxi", "", pq.Array([]string{}), "LAK", time.Now(), 30, taxi, "draft", nil))
+		mock.ExpectQuery(`SELECT (.+) FROM settlements WHERE tenant_id = \$1 ORDER BY id`).
+			WithArgs("").
+			WillReturnRows(sqlmock.NewRows(settlementColumns).
+				AddRow(1, "Bo", "Ana", 100, "", time.Now()))
+
+		got, err := svc.Balances(context.Background())
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []Balance{
+				{Name: "Ana", Amount: 500},
+				{Name: "Bo", Amount: -170},
+				{Name: "Chai", Amount: -330},
+			}, got.Balances)
+			assert.Equal(t, []Transfer{
+				{From: "Chai", To: "Ana", Amount: 330},
+				{From: "Bo", To: "Ana", Amount: 170},
+			}, got.Transfers)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("settlements are scoped to the tenant and join its unit of work", func(t *testing.T) {
+		ctx := expense.WithTenant(context.Background(), "acme")
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO settlements (.+) RETURNING id, created_at`).
+			WithArgs("acme", "Bo", "Ana", 100.0, "").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, time.Now()))
+		mock.ExpectRollback()
+		mock.ExpectQuery(`SELECT (.+) FROM settlements WHERE tenant_id = \$1`).
+			WithArgs("acme").
+			WillReturnRows(sqlmock.NewRows(settlementColumns))
+
+		errRollback := errors.New("rollback")
+		err := expenseSvc.WithTx(ctx, func(ctx context.Context) error {
+			if _, err := svc.SaveSettlement(ctx, &Settlement{From: "Bo", To: "Ana", Amount: 100}); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		assert.ErrorIs(t, err, errRollback)
+		sts, err := svc.ListSettlements(ctx)
+
+		if assert.NoError(t, err) {
+			assert.Empty(t, sts)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSettlementValidate(t *testing.T) {
+	t.Run("ErrSamePerson", func(t *testing.T) {
+		st := &Settlement{From: "Ana", To: "Ana", Amount: 10}
+
+		assert.ErrorIs(t, st.Validate(), ErrSamePerson)
+	})
+
+	t.Run("ErrAmountInvalid", func(t *testing.T) {
+		st := &Settlement{From: "Ana", To: "Bo"}
+
+		assert.ErrorIs(t, st.Validate(), ErrAmountInvalid)
+	})
+}
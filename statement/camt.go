@@ -0,0 +1,143 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/phuangpheth/assessment/expense"
+)
+
+// camtDocument is the part of a CAMT.053 bank to customer statement that is
+// imported. Elements are matched by local name, so every version of the
+// camt.053.001 namespace is accepted.
+type camtDocument struct {
+	XMLName    xml.Name `xml:"Document"`
+	Statements []struct {
+		Currency string      `xml:"Acct>Ccy"`
+		IBAN     string      `xml:"Acct>Id>IBAN"`
+		OtherID  string      `xml:"Acct>Id>Othr>Id"`
+		Entries  []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtEntry struct {
+	Amount struct {
+		Value    string `xml:",chardata"`
+		Currency string `xml:"Ccy,attr"`
+	} `xml:"Amt"`
+	Indicator string `xml:"CdtDbtInd"`
+	// Status is BOOK, PDNG or INFO; newer versions wrap it in Cd.
+	Status struct {
+		Value string `xml:",chardata"`
+		Code  string `xml:"Cd"`
+	} `xml:"Sts"`
+	BookingDate camtDate `xml:"BookgDt"`
+	ValueDate   camtDate `xml:"ValDt"`
+	Ref         string   `xml:"AcctSvcrRef"`
+	Details     []struct {
+		Ref        string   `xml:"Refs>AcctSvcrRef"`
+		TxID       string   `xml:"Refs>TxId"`
+		Creditor   string   `xml:"RltdPties>Cdtr>Nm"`
+		CreditorV8 string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+		Remittance []string `xml:"RmtInf>Ustrd"`
+	} `xml:"NtryDtls>TxDtls"`
+	Info string `xml:"AddtlNtryInf"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) day() string {
+	if d.Date != "" {
+		return d.Date
+	}
+	if len(d.DateTime) >= 10 {
+		return d.DateTime[:10]
+	}
+	return ""
+}
+
+// parseCAMT053 reads the booked entries of a CAMT.053 statement. Entries
+// that batch several transactions are imported as one transaction.
+func parseCAMT053(data []byte) ([]Transaction, error) {
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStatementInvalid, err)
+	}
+
+	var txs []Transaction
+	for _, stmt := range doc.Statements {
+		account := stmt.IBAN
+		if account == "" {
+			account = stmt.OtherID
+		}
+		for i := range stmt.Entries {
+			n := &stmt.Entries[i]
+			status := strings.TrimSpace(n.Status.Value + n.Status.Code)
+			if status != "" && status != "BOOK" {
+				continue
+			}
+			tx, err := camtTransaction(n, stmt.Currency, account)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: %w", len(txs)+1, err)
+			}
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil
+}
+
+func camtTransaction(n *camtEntry, currency, account string) (Transaction, error) {
+	amount, err := parseAmount(n.Amount.Value)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("%w: Amt %q", ErrStatementInvalid, n.Amount.Value)
+	}
+	switch n.Indicator {
+	case "DBIT":
+		amount = -amount
+	case "CRDT":
+	default:
+		return Transaction{}, fmt.Errorf("%w: CdtDbtInd %q", ErrStatementInvalid, n.Indicator)
+	}
+	day := n.BookingDate.day()
+	if day == "" {
+		day = n.ValueDate.day()
+	}
+	date, err := time.Parse(expense.DateLayout, day)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("%w: BookgDt %q", ErrStatementInvalid, day)
+	}
+	if n.Amount.Currency != "" {
+		currency = n.Amount.Currency
+	}
+
+	tx := Transaction{
+		Account:  account,
+		ID:       n.Ref,
+		Date:     date.Format(expense.DateLayout),
+		Amount:   amount,
+		Currency: strings.ToUpper(currency),
+		Memo:     n.Info,
+	}
+	if len(n.Details) > 0 {
+		d := n.Details[0]
+		if tx.ID == "" {
+			tx.ID = d.Ref
+		}
+		if tx.ID == "" {
+			tx.ID = d.TxID
+		}
+		tx.Payee = d.Creditor
+		if tx.Payee == "" {
+			tx.Payee = d.CreditorV8
+		}
+		if len(d.Remittance) > 0 {
+			tx.Memo = strings.Join(d.Remittance, " ")
+		}
+	}
+	return tx, nil
+}
@@ -0,0 +1,92 @@
+package statement
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/phuangpheth/assessment/expense"
+)
+
+// parseOFX reads the STMTTRN aggregates of an OFX or QFX statement. Both
+// the SGML syntax of OFX 1.x, whose elements need not be closed, and the
+// XML syntax of OFX 2.x are accepted.
+func parseOFX(data []byte) ([]Transaction, error) {
+	s := string(data)
+	if !strings.Contains(strings.ToUpper(s), "<OFX>") {
+		return nil, fmt.Errorf("%w: no OFX element", ErrStatementInvalid)
+	}
+
+	var txs []Transaction
+	var trn map[string]string
+	currency, account := "", ""
+	for {
+		lt := strings.IndexByte(s, '<')
+		if lt < 0 {
+			break
+		}
+		gt := strings.IndexByte(s[lt:], '>')
+		if gt < 0 {
+			break
+		}
+		tag := strings.ToUpper(strings.TrimSpace(s[lt+1 : lt+gt]))
+		s = s[lt+gt+1:]
+		text := s
+		if next := strings.IndexByte(s, '<'); next >= 0 {
+			text = s[:next]
+		}
+		value := html.UnescapeString(strings.TrimSpace(text))
+
+		switch {
+		case tag == "STMTTRN":
+			trn = make(map[string]string)
+		case tag == "/STMTTRN" && trn != nil:
+			tx, err := ofxTransaction(trn, currency, account)
+			if err != nil {
+				return nil, fmt.Errorf("transaction %d: %w", len(txs)+1, err)
+			}
+			txs = append(txs, tx)
+			trn = nil
+		case strings.HasPrefix(tag, "/"), strings.HasPrefix(tag, "?"), strings.HasPrefix(tag, "!"):
+		case tag == "CURDEF":
+			currency = strings.ToUpper(value)
+		case tag == "ACCTID" && trn == nil:
+			// The ACCTID of BANKACCTFROM or CCACCTFROM; that of the
+			// BANKACCTTO of a transfer is within STMTTRN.
+			account = value
+		case trn != nil && value != "":
+			// The NAME of a PAYEE aggregate does not replace a NAME
+			// element of the transaction itself.
+			if _, ok := trn[tag]; !ok {
+				trn[tag] = value
+			}
+		}
+	}
+	return txs, nil
+}
+
+func ofxTransaction(trn map[string]string, currency, account string) (Transaction, error) {
+	amount, err := parseAmount(trn["TRNAMT"])
+	if err != nil {
+		return Transaction{}, fmt.Errorf("%w: TRNAMT %q", ErrStatementInvalid, trn["TRNAMT"])
+	}
+	// Dates look like 20230501120000.000[-5:EST]; only the day is kept.
+	posted := trn["DTPOSTED"]
+	if len(posted) > 8 {
+		posted = posted[:8]
+	}
+	date, err := time.Parse("20060102", posted)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("%w: DTPOSTED %q", ErrStatementInvalid, trn["DTPOSTED"])
+	}
+	return Transaction{
+		Account:  account,
+		ID:       trn["FITID"],
+		Date:     date.Format(expense.DateLayout),
+		Amount:   amount,
+		Currency: currency,
+		Payee:    trn["NAME"],
+		Memo:     trn["MEMO"],
+	}, nil
+}
@@ -0,0 +1,257 @@
+package statement
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/txn"
+)
+
+// Action is the decision of the user on a previewed row.
+type Action string
+
+// Actions on previewed rows.
+const (
+	ActionConfirm Action = "confirm"
+	ActionSkip    Action = "skip"
+)
+
+// ErrFingerprintEmpty is returned when a decision has no fingerprint.
+var ErrFingerprintEmpty = errors.New("empty fingerprint")
+
+// ErrActionInvalid is returned when a decision is neither confirm nor skip.
+var ErrActionInvalid = errors.New("action must be confirm or skip")
+
+// ErrExpenseMissing is returned when a confirmed decision has no expense.
+var ErrExpenseMissing = errors.New("confirmed rows require an expense")
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier = txn.Querier
+
+// Row is a debit of a statement, previewed as an expense draft.
+type Row struct {
+	Fingerprint string          `json:"fingerprint"`
+	Transaction Transaction     `json:"transaction"`
+	Expense     expense.Expense `json:"expense"`
+	// Duplicate reports whether the transaction was confirmed or skipped
+	// before, or appears earlier in the same statement.
+	Duplicate bool `json:"duplicate"`
+	// Similar are existing expenses the draft is likely a duplicate of.
+	Similar []expense.Match `json:"similar,omitempty"`
+}
+
+// Decision confirms or skips a previewed row.
+type Decision struct {
+	Fingerprint string `json:"fingerprint"`
+	Action      Action `json:"action"`
+	// Expense is the draft, possibly edited, that is saved when the row is
+	// confirmed.
+	Expense *expense.Expense `json:"expense,omitempty"`
+}
+
+func (d *Decision) Validate() error {
+	if d.Fingerprint == "" {
+		return ErrFingerprintEmpty
+	}
+	switch d.Action {
+	case ActionSkip:
+		return nil
+	case ActionConfirm:
+		if d.Expense == nil {
+			return ErrExpenseMissing
+		}
+		return d.Expense.Validate()
+	}
+	return ErrActionInvalid
+}
+
+// Result is the outcome of an Import.
+type Result struct {
+	Imported []expense.Expense `json:"imported"`
+	Skipped  int               `json:"skipped"`
+	// Duplicates are the fingerprints of rows decided by an earlier import,
+	// which were left as they were.
+	Duplicates []string `json:"duplicates"`
+}
+
+type Service struct {
+	db         *sql.DB
+	expenseSvc *expense.Service
+}
+
+func NewService(db *sql.DB, expenseSvc *expense.Service) *Service {
+	return &Service{
+		db:         db,
+		expenseSvc: expenseSvc,
+	}
+}
+
+// conn returns the transaction of the unit of work of ctx, or the database.
+func (s *Service) conn(ctx context.Context) querier {
+	return txn.From(ctx, s.db)
+}
+
+// Preview returns a row for every debit of txs, with the draft tagged as
+// the expense service would tag it and the existing expenses it likely
+// duplicates. Credits are left out.
+func (s *Service) Preview(ctx context.Context, txs []Transaction) ([]Row, error) {
+	tenant := expense.TenantFromContext(ctx)
+	rows := make([]Row, 0, len(txs))
+	fingerprints := make([]string, 0, len(txs))
+	for i := range txs {
+		if !txs[i].Debit() {
+			continue
+		}
+		row := Row{
+			Fingerprint: txs[i].Fingerprint(tenant),
+			Transaction: txs[i],
+			Expense:     txs[i].Draft(),
+		}
+		if err := s.expenseSvc.Tag(ctx, &row.Expense); err != nil {
+			return nil, fmt.Errorf("tag(): %w", err)
+		}
+		similar, err := s.expenseSvc.FindDuplicates(ctx, &row.Expense)
+		if err != nil {
+			return nil, fmt.Errorf("findDuplicates(): %w", err)
+		}
+		row.Similar = similar
+		rows = append(rows, row)
+		fingerprints = append(fingerprints, row.Fingerprint)
+	}
+
+	seen, err := listDecided(ctx, s.conn(ctx), fingerprints)
+	if err != nil {
+		return nil, fmt.Errorf("listDecided(): %w", err)
+	}
+	for i := range rows {
+		rows[i].Duplicate = seen[rows[i].Fingerprint]
+		seen[rows[i].Fingerprint] = true
+	}
+	return rows, nil
+}
+
+// Import saves the expenses of confirmed rows and records every decision,
+// in one unit of work. Rows decided by an earlier import are not saved
+// again. Confirmed rows are saved even when they look like duplicates, as
+// the preview showed them.
+func (s *Service) Import(ctx context.Context, ds []Decision) (*Result, error) {
+	ctx = expense.AllowDuplicates(ctx)
+	var res *Result
+	err := s.expenseSvc.WithTx(ctx, func(ctx context.Context) error {
+		res = &Result{Imported: []expense.Expense{}, Duplicates: []string{}}
+		db := s.conn(ctx)
+		for i := range ds {
+			d := &ds[i]
+			ok, err := createDecision(ctx, db, d.Fingerprint, d.Action)
+			if err != nil {
+				return fmt.Errorf("createDecision(%s): %w", d.Fingerprint, err)
+			}
+			if !ok {
+				res.Duplicates = append(res.Duplicates, d.Fingerprint)
+				continue
+			}
+			if d.Action == ActionSkip {
+				res.Skipped++
+				continue
+			}
+
+			e := *d.Expense
+			e.ID = 0
+			saved, err := s.expenseSvc.Save(ctx, &e)
+			if err != nil {
+				return err
+			}
+			if err := setDecisionExpense(ctx, db, d.Fingerprint, saved.ID); err != nil {
+				return fmt.Errorf("setDecisionExpense(%s): %w", d.Fingerprint, err)
+			}
+			res.Imported = append(res.Imported, *saved)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// decidedIn selects the decisions of the tenant of ctx.
+func decidedIn(ctx context.Context) sq.Eq {
+	return sq.Eq{"tenant_id": expense.TenantFromContext(ctx)}
+}
+
+// listDecided returns which of fingerprints were decided before in the
+// tenant of ctx.
+func listDecided(ctx context.Context, db querier, fingerprints []string) (map[string]bool, error) {
+	seen := make(map[string]bool, len(fingerprints))
+	if len(fingerprints) == 0 {
+		return seen, nil
+	}
+	query, args, err := sq.Select("fingerprint").
+		From("statement_transactions").
+		Where("fingerprint = ANY(?)", pq.Array(fingerprints)).
+		Where(decidedIn(ctx)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fp string
+		if err := rows.Scan(&fp); err != nil {
+			return nil, err
+		}
+		seen[fp] = true
+	}
+	return seen, rows.Err()
+}
+
+// createDecision records the decision on the transaction with fingerprint
+// in the tenant of ctx. It reports false when the transaction was decided
+// before.
+func createDecision(ctx context.Context, db querier, fingerprint string, action Action) (bool, error) {
+	query, args, err := sq.Insert("statement_transactions").
+		Columns("tenant_id", "fingerprint", "action").
+		Values(expense.TenantFromContext(ctx), fingerprint, action).
+		Suffix("ON CONFLICT (tenant_id, fingerprint) DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func setDecisionExpense(ctx context.Context, db querier, fingerprint string, expenseID int64) error {
+	query, args, err := sq.Update("statement_transactions").
+		Set("expense_id", expenseID).
+		Where(sq.Eq{"fingerprint": fingerprint}).
+		Where(decidedIn(ctx)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,98 @@
+package statement
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	svc := NewService(db, expense.NewService(db))
+	ctx := expense.WithTenant(context.Background(), "acme")
+	coffee := Transaction{ID: "1", Date: "2026-10-01", Amount: -4.5, Currency: "THB", Payee: "Coffee"}
+	lunch := Transaction{ID: "2", Date: "2026-10-01", Amount: -120, Currency: "THB", Payee: "Lunch"}
+	salary := Transaction{ID: "3", Date: "2026-10-01", Amount: 1000, Currency: "THB", Payee: "Salary"}
+
+	t.Run("Preview() returns debits and marks duplicates", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT fingerprint FROM statement_transactions`).
+			WithArgs(pq.Array([]string{coffee.Fingerprint("acme"), lunch.Fingerprint("acme"), coffee.Fingerprint("acme")}), "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"fingerprint"}).AddRow(lunch.Fingerprint("acme")))
+
+		rows, err := svc.Preview(ctx, []Transaction{coffee, lunch, salary, coffee})
+
+		if assert.NoError(t, err) && assert.Len(t, rows, 3) {
+			assert.False(t, rows[0].Duplicate)
+			assert.True(t, rows[1].Duplicate, "decided by an earlier import")
+			assert.True(t, rows[2].Duplicate, "repeated in the statement")
+			assert.Equal(t, "Coffee", rows[0].Expense.Title)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Import() saves confirmed rows and records skipped ones", func(t *testing.T) {
+		draft := coffee.Draft()
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO statement_transactions`).
+			WithArgs("acme", coffee.Fingerprint("acme"), ActionConfirm).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO expenses (.+) RETURNING`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "title", "note", "tags", "currency", "spent_on", "base_amount", "split", "status", "updated_at"}).
+				AddRow(7, 4.5, "Coffee", "", pq.Array([]string{}), "THB", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), 4.5, nil, "draft", nil))
+		mock.ExpectExec(`INSERT INTO expense_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE statement_transactions SET expense_id`).
+			WithArgs(int64(7), coffee.Fingerprint("acme"), "acme").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO statement_transactions`).
+			WithArgs("acme", lunch.Fingerprint("acme"), ActionSkip).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO statement_transactions`).
+			WithArgs("acme", salary.Fingerprint("acme"), ActionSkip).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		res, err := svc.Import(ctx, []Decision{
+			{Fingerprint: coffee.Fingerprint("acme"), Action: ActionConfirm, Expense: &draft},
+			{Fingerprint: lunch.Fingerprint("acme"), Action: ActionSkip},
+			{Fingerprint: salary.Fingerprint("acme"), Action: ActionSkip},
+		})
+
+		if assert.NoError(t, err) {
+			assert.Len(t, res.Imported, 1)
+			assert.Equal(t, int64(7), res.Imported[0].ID)
+			assert.Equal(t, 1, res.Skipped)
+			assert.Equal(t, []string{salary.Fingerprint("acme")}, res.Duplicates)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDecisionValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		d    Decision
+		err  error
+	}{
+		{"skip", Decision{Fingerprint: "f", Action: ActionSkip}, nil},
+		{"confirm", Decision{Fingerprint: "f", Action: ActionConfirm, Expense: &expense.Expense{Amount: 1, Title: "Tea"}}, nil},
+		{"no fingerprint", Decision{Action: ActionSkip}, ErrFingerprintEmpty},
+		{"unknown action", Decision{Fingerprint: "f", Action: "keep"}, ErrActionInvalid},
+		{"confirm without expense", Decision{Fingerprint: "f", Action: ActionConfirm}, ErrExpenseMissing},
+		{"invalid expense", Decision{Fingerprint: "f", Action: ActionConfirm, Expense: &expense.Expense{Title: "Tea"}}, expense.ErrAmountInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.d.Validate(), tt.err)
+		})
+	}
+}
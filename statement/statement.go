@@ -0,0 +1,113 @@
+// Package statement imports bank statements in OFX/QFX and ISO 20022
+// CAMT.053 formats as expense drafts.
+package statement
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/phuangpheth/assessment/expense"
+)
+
+// Formats of bank statements.
+const (
+	FormatOFX     = "ofx"
+	FormatQFX     = "qfx"
+	FormatCAMT053 = "camt053"
+)
+
+// ErrFormatUnknown is returned when the format of a statement is not
+// supported or could not be detected.
+var ErrFormatUnknown = errors.New("statement format must be ofx, qfx or camt053")
+
+// ErrStatementInvalid is returned when a statement could not be parsed.
+var ErrStatementInvalid = errors.New("malformed statement")
+
+// Transaction is a booked transaction of a bank statement.
+type Transaction struct {
+	// Account identifies the account of the statement: the ACCTID of OFX,
+	// the IBAN or other ID of CAMT.053.
+	Account string `json:"account"`
+	// ID is the identifier the bank assigned to the transaction.
+	ID string `json:"id"`
+	// Date is the booking date, formatted as expense.DateLayout.
+	Date string `json:"date"`
+	// Amount is negative for debits and positive for credits.
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	Payee    string  `json:"payee"`
+	Memo     string  `json:"memo"`
+}
+
+// Debit reports whether money left the account.
+func (t *Transaction) Debit() bool {
+	return t.Amount < 0
+}
+
+// Fingerprint identifies the transaction of tenant across imports of
+// overlapping statements by its account, bank ID, date and amount. Bank IDs
+// are only unique within an account.
+func (t *Transaction) Fingerprint(tenant string) string {
+	sum := sha256.Sum256([]byte(tenant + "|" + t.Account + "|" + t.ID + "|" + t.Date + "|" + strconv.FormatFloat(t.Amount, 'f', 2, 64)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Draft returns the expense the debit t turns into.
+func (t *Transaction) Draft() expense.Expense {
+	e := expense.Expense{
+		Amount:   -t.Amount,
+		Title:    t.Payee,
+		Note:     t.Memo,
+		Currency: t.Currency,
+		SpentOn:  t.Date,
+	}
+	if e.Title == "" {
+		e.Title, e.Note = t.Memo, ""
+	}
+	if e.Title == "" {
+		e.Title = "Bank transaction " + t.ID
+	}
+	return e
+}
+
+// DetectFormat returns the format of the statement in data, or an empty
+// string.
+func DetectFormat(data []byte) string {
+	switch {
+	case bytes.Contains(data, []byte("BkToCstmrStmt")):
+		return FormatCAMT053
+	case bytes.Contains(data, []byte("OFXHEADER")), bytes.Contains(bytes.ToUpper(data), []byte("<OFX>")):
+		return FormatOFX
+	}
+	return ""
+}
+
+// Parse reads the transactions of a statement in format, which is detected
+// from the content when empty.
+func Parse(r io.Reader, format string) ([]Transaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = DetectFormat(data)
+	}
+	switch strings.ToLower(format) {
+	case FormatOFX, FormatQFX:
+		return parseOFX(data)
+	case FormatCAMT053:
+		return parseCAMT053(data)
+	}
+	return nil, ErrFormatUnknown
+}
+
+// parseAmount parses amounts that use either a point or a comma as the
+// decimal separator.
+func parseAmount(s string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(strings.TrimSpace(s), ",", ".", 1), 64)
+}
@@ -0,0 +1,134 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+const ofxSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>EUR
+<BANKACCTFROM>
+<BANKID>121099999
+<ACCTID>999988
+<ACCTTYPE>CHECKING
+</BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20261001120000.000[+2:CEST]
+<TRNAMT>-12.50
+<FITID>20261001-1
+<NAME>Caf&eacute; Central
+<MEMO>Card payment
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20261002
+<TRNAMT>1000,00
+<FITID>20261002-1
+<NAME>Salary
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const ofxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>USD</CURDEF>
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20261003</DTPOSTED><TRNAMT>-4.25</TRNAMT><FITID>A1</FITID><MEMO>Coffee &amp; cake</MEMO></STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`
+
+const camt053 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+<BkToCstmrStmt><Stmt>
+<Acct><Id><IBAN>CH9300762011623852957</IBAN></Id><Ccy>CHF</Ccy></Acct>
+<Ntry>
+  <Amt Ccy="CHF">45.90</Amt>
+  <CdtDbtInd>DBIT</CdtDbtInd>
+  <Sts>BOOK</Sts>
+  <BookgDt><Dt>2026-10-04</Dt></BookgDt>
+  <AcctSvcrRef>REF-1</AcctSvcrRef>
+  <NtryDtls><TxDtls>
+    <RltdPties><Cdtr><Nm>Migros</Nm></Cdtr></RltdPties>
+    <RmtInf><Ustrd>Groceries</Ustrd></RmtInf>
+  </TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+  <Amt Ccy="CHF">10.00</Amt>
+  <CdtDbtInd>DBIT</CdtDbtInd>
+  <Sts>PDNG</Sts>
+  <BookgDt><DtTm>2026-10-05T08:00:00</DtTm></BookgDt>
+</Ntry>
+</Stmt></BkToCstmrStmt>
+</Document>
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		format string
+		want   []Transaction
+	}{
+		{"OFX 1.x", ofxSGML, "", []Transaction{
+			{Account: "999988", ID: "20261001-1", Date: "2026-10-01", Amount: -12.5, Currency: "EUR", Payee: "Café Central", Memo: "Card payment"},
+			{Account: "999988", ID: "20261002-1", Date: "2026-10-02", Amount: 1000, Currency: "EUR", Payee: "Salary"},
+		}},
+		{"OFX 2.x", ofxXML, FormatQFX, []Transaction{
+			{ID: "A1", Date: "2026-10-03", Amount: -4.25, Currency: "USD", Memo: "Coffee & cake"},
+		}},
+		{"CAMT.053", camt053, "", []Transaction{
+			{Account: "CH9300762011623852957", ID: "REF-1", Date: "2026-10-04", Amount: -45.9, Currency: "CHF", Payee: "Migros", Memo: "Groceries"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.in), tt.format)
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("date,amount\n"), "")
+
+		assert.ErrorIs(t, err, ErrFormatUnknown)
+	})
+
+	t.Run("malformed amount", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("<OFX><STMTTRN><DTPOSTED>20261001<TRNAMT>abc</STMTTRN></OFX>"), FormatOFX)
+
+		assert.ErrorIs(t, err, ErrStatementInvalid)
+	})
+}
+
+func TestTransaction(t *testing.T) {
+	tx := Transaction{Account: "999988", ID: "1", Date: "2026-10-01", Amount: -12.5, Currency: "EUR", Memo: "Card payment"}
+
+	assert.True(t, tx.Debit())
+	assert.Equal(t, expense.Expense{Amount: 12.5, Title: "Card payment", Currency: "EUR", SpentOn: "2026-10-01"}, tx.Draft())
+
+	other := tx
+	other.Memo = "Edited"
+	assert.Equal(t, tx.Fingerprint("acme"), other.Fingerprint("acme"), "only account, ID, date and amount count")
+	assert.NotEqual(t, tx.Fingerprint("acme"), other.Fingerprint("other"))
+	other.Amount = -12.51
+	assert.NotEqual(t, tx.Fingerprint("acme"), other.Fingerprint("acme"))
+	other.Amount, other.Account = tx.Amount, "777766"
+	assert.NotEqual(t, tx.Fingerprint("acme"), other.Fingerprint("acme"))
+}
@@ -0,0 +1,181 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+	"go.uber.org/zap"
+)
+
+// Channel is the Postgres NOTIFY channel raised for every new expense event.
+const Channel = "expense_events"
+
+// heldRetry is how long Run waits before polling again for events that were
+// held back by running transactions, which may end without a notification.
+const heldRetry = time.Second
+
+// bufferSize is the number of events buffered per subscriber. A subscriber
+// that falls further behind is closed and must resume with Last-Event-ID.
+const bufferSize = 64
+
+// Subscriber receives the events of one tenant.
+type Subscriber struct {
+	C      <-chan expense.Event
+	c      chan expense.Event
+	tenant string
+}
+
+// Listener is called with every event the broker publishes, whichever
+// replica recorded it.
+type Listener interface {
+	ExpenseEvent(ev expense.Event)
+}
+
+// Broker fans out expense events to subscribers. It is woken by Postgres
+// NOTIFY, so every replica sees the writes of the others, and reads the
+// events themselves from the expense_events table.
+//
+// Events are published in the order of their positions, as
+// webhook.Dispatcher fans them out, so a subscriber that resumes after the
+// position of the last event it received misses none.
+type Broker struct {
+	expenseSvc *expense.Service
+
+	mu        sync.Mutex
+	subs      map[*Subscriber]struct{}
+	listeners []Listener
+	last      expense.Position
+}
+
+func NewBroker(expenseSvc *expense.Service) *Broker {
+	return &Broker{
+		expenseSvc: expenseSvc,
+		subs:       make(map[*Subscriber]struct{}),
+	}
+}
+
+// AddListener registers l to be called with every published event.
+func (b *Broker) AddListener(l Listener) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, l)
+}
+
+func (b *Broker) Subscribe(tenant string) *Subscriber {
+	c := make(chan expense.Event, bufferSize)
+	s := &Subscriber{C: c, c: c, tenant: tenant}
+
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+func (b *Broker) Unsubscribe(s *Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.c)
+	}
+}
+
+// Start skips the events recorded before the broker started; subscribers
+// that need them resume from the table with Last-Event-ID.
+func (b *Broker) Start(ctx context.Context) error {
+	p, err := b.expenseSvc.LatestPosition(ctx)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.last = p
+	b.mu.Unlock()
+	return nil
+}
+
+// Poll publishes every event recorded since the previous Poll that is not
+// held back by a running transaction.
+func (b *Broker) Poll(ctx context.Context) error {
+	_, err := b.poll(ctx)
+	return err
+}
+
+// poll publishes the events and reports whether some were held back.
+func (b *Broker) poll(ctx context.Context) (bool, error) {
+	const limit = 100
+	for {
+		b.mu.Lock()
+		last := b.last
+		b.mu.Unlock()
+
+		evs, held, err := b.expenseSvc.Events(ctx, last, limit)
+		if err != nil {
+			return false, err
+		}
+		for _, ev := range evs {
+			b.publish(ev)
+		}
+		if held || len(evs) < limit {
+			return held, nil
+		}
+	}
+}
+
+func (b *Broker) publish(ev expense.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p := ev.Position()
+	if !b.last.Less(p) {
+		return
+	}
+	b.last = p
+	for _, l := range b.listeners {
+		l.ExpenseEvent(ev)
+	}
+	for s := range b.subs {
+		if s.tenant != ev.TenantID {
+			continue
+		}
+		select {
+		case s.c <- ev:
+		default:
+			delete(b.subs, s)
+			close(s.c)
+		}
+	}
+}
+
+// Run listens on Channel and polls on every notification until ctx is done.
+// It also polls after a reconnect, when notifications may have been lost,
+// again shortly after events were held back, and pings the connection when
+// idle.
+func (b *Broker) Run(ctx context.Context, l *pq.Listener) {
+	if err := l.Listen(Channel); err != nil {
+		zap.L().Error("failed to listen for expense events", zap.Error(err))
+		return
+	}
+	defer l.Close()
+
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.Notify:
+		case <-retry:
+		case <-time.After(90 * time.Second):
+			go l.Ping()
+		}
+		retry = nil
+		held, err := b.poll(ctx)
+		if err != nil {
+			zap.L().Error("failed to poll expense events", zap.Error(err))
+		}
+		if held {
+			retry = time.After(heldRetry)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+var eventColumns = []string{"id", "type", "expense_id", "tenant_id", "data", "created_at", "tx_id", "ended"}
+
+func TestBrokerPoll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	b := NewBroker(expense.NewService(db))
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT tx_id, id FROM expense_events").
+		WillReturnRows(sqlmock.NewRows([]string{"tx_id", "id"}).AddRow(100, 4))
+	assert.NoError(t, b.Start(ctx))
+
+	t.Run("Poll() publishes to subscribers of the same tenant", func(t *testing.T) {
+		acme := b.Subscribe("acme")
+		other := b.Subscribe("other")
+		defer b.Unsubscribe(acme)
+		defer b.Unsubscribe(other)
+
+		mock.ExpectQuery("SELECT (.+) FROM expense_events").WithArgs(100, 4).
+			WillReturnRows(sqlmock.NewRows(eventColumns).
+				AddRow(5, expense.EventCreated, 1, "acme", []byte(`{"id":1}`), now, 100, true).
+				AddRow(6, expense.EventDeleted, 2, "other", []byte(`{"id":2}`), now, 101, true))
+
+		err := b.Poll(ctx)
+
+		if assert.NoError(t, err) {
+			ev := <-acme.C
+			assert.Equal(t, int64(5), ev.ID)
+			assert.Equal(t, expense.EventDeleted, (<-other.C).Type)
+			assert.Empty(t, acme.C)
+		}
+	})
+
+	t.Run("Poll() closes subscribers that fall behind", func(t *testing.T) {
+		slow := b.Subscribe("")
+
+		rows := sqlmock.NewRows(eventColumns)
+		for i := 0; i <= bufferSize; i++ {
+			rows.AddRow(7+i, expense.EventUpdated, 1, "", []byte(`{}`), now, 102, true)
+		}
+		mock.ExpectQuery("SELECT (.+) FROM expense_events").WithArgs(101, 6).WillReturnRows(rows)
+
+		err := b.Poll(ctx)
+
+		if assert.NoError(t, err) {
+			n := 0
+			for range slow.C {
+				n++
+			}
+			assert.Equal(t, bufferSize, n)
+		}
+	})
+
+	t.Run("Poll() calls listeners with the events of every tenant", func(t *testing.T) {
+		var got recorder
+		b.AddListener(&got)
+		last := int64(7 + bufferSize)
+		mock.ExpectQuery("SELECT (.+) FROM expense_events").WithArgs(102, last).
+			WillReturnRows(sqlmock.NewRows(eventColumns).
+				AddRow(last+1, expense.EventUpdated, 3, "acme", []byte(`{}`), now, 103, true).
+				AddRow(last+2, expense.EventUpdated, 4, "other", []byte(`{}`), now, 103, true))
+
+		err := b.Poll(ctx)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, recorder{3, 4}, got)
+		}
+	})
+
+	t.Run("Poll() holds back events of running transactions", func(t *testing.T) {
+		acme := b.Subscribe("acme")
+		defer b.Unsubscribe(acme)
+		last := int64(9 + bufferSize)
+		mock.ExpectQuery("SELECT (.+) FROM expense_events").WithArgs(103, last).
+			WillReturnRows(sqlmock.NewRows(eventColumns).
+				AddRow(last+2, expense.EventCreated, 5, "acme", []byte(`{}`), now, 104, false))
+		mock.ExpectQuery("SELECT (.+) FROM expense_events").WithArgs(103, last).
+			WillReturnRows(sqlmock.NewRows(eventColumns).
+				AddRow(last+2, expense.EventCreated, 5, "acme", []byte(`{}`), now, 104, true).
+				AddRow(last+1, expense.EventCreated, 6, "acme", []byte(`{}`), now, 105, true))
+
+		held, err := b.poll(ctx)
+
+		if assert.NoError(t, err) && assert.True(t, held) {
+			assert.Empty(t, acme.C)
+		}
+
+		held, err = b.poll(ctx)
+
+		if assert.NoError(t, err) && assert.False(t, held) {
+			assert.Equal(t, last+2, (<-acme.C).ID)
+			assert.Equal(t, last+1, (<-acme.C).ID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// recorder keeps the expense IDs of the events it is called with.
+type recorder []int64
+
+func (r *recorder) ExpenseEvent(ev expense.Event) {
+	*r = append(*r, ev.ExpenseID)
+}
@@ -0,0 +1,82 @@
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigner(t *testing.T) {
+	s, err := NewSigner([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	tk, err := s.Issue("acme", "ana", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Verify() returns the claims", func(t *testing.T) {
+		c, err := s.Verify(tk)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, &Claims{Tenant: "acme", Subject: "ana", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix()}, c)
+			assert.Equal(t, now, c.Issued().UTC())
+		}
+	})
+
+	t.Run("Verify() rejects tokens of another secret", func(t *testing.T) {
+		other, _ := NewSigner([]byte("other"))
+		other.now = s.now
+
+		_, err := other.Verify(tk)
+
+		assert.ErrorIs(t, err, ErrInvalid)
+	})
+
+	t.Run("Verify() rejects changed claims", func(t *testing.T) {
+		bob, _ := s.Issue("acme", "bob", time.Hour)
+		parts := strings.Split(tk, ".")
+		parts[1] = strings.Split(bob, ".")[1]
+
+		_, err := s.Verify(strings.Join(parts, "."))
+
+		assert.ErrorIs(t, err, ErrInvalid)
+	})
+
+	t.Run("Verify() rejects expired tokens", func(t *testing.T) {
+		later, _ := NewSigner([]byte("secret"))
+		later.now = func() time.Time { return now.Add(time.Hour) }
+
+		_, err := later.Verify(tk)
+
+		assert.ErrorIs(t, err, ErrInvalid)
+	})
+
+	t.Run("Verify() rejects malformed tokens", func(t *testing.T) {
+		for _, tk := range []string{"", "a.b", "October 18, 2026", "a.b.c"} {
+			_, err := s.Verify(tk)
+
+			assert.ErrorIs(t, err, ErrInvalid, tk)
+		}
+	})
+
+	t.Run("Issue() requires a subject and a ttl", func(t *testing.T) {
+		_, err := s.Issue("acme", "", time.Hour)
+		assert.ErrorIs(t, err, ErrSubjectEmpty)
+
+		_, err = s.Issue("acme", "ana", 0)
+		assert.ErrorIs(t, err, ErrTTLInvalid)
+	})
+
+	t.Run("NewSigner() requires a secret", func(t *testing.T) {
+		_, err := NewSigner(nil)
+
+		assert.ErrorIs(t, err, ErrSecretEmpty)
+	})
+}
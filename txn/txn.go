@@ -0,0 +1,159 @@
+// Package txn runs units of work in a database transaction. The transaction
+// is carried by the context, so the query functions of every service called
+// within the unit of work join it.
+package txn
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrIsolationInvalid is returned when an isolation level cannot be parsed.
+var ErrIsolationInvalid = errors.New("isolation must be one of read committed, repeatable read or serializable")
+
+// Querier is implemented by both *sql.DB and *sql.Tx.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// unit is a unit of work: its transaction, which is nil once it ended, and
+// what to do once it committed.
+type unit struct {
+	tx    *sql.Tx
+	after []func()
+}
+
+// active returns the unit of work of ctx unless it ended.
+func active(ctx context.Context) (*unit, bool) {
+	u, ok := ctx.Value(txKey{}).(*unit)
+	return u, ok && u.tx != nil
+}
+
+// From returns the transaction of the unit of work ctx belongs to, or db
+// outside of one.
+func From(ctx context.Context, db Querier) Querier {
+	if u, ok := active(ctx); ok {
+		return u.tx
+	}
+	return db
+}
+
+// InTx reports whether ctx belongs to a unit of work that has not ended.
+func InTx(ctx context.Context) bool {
+	_, ok := active(ctx)
+	return ok
+}
+
+// AfterCommit defers fn until the unit of work ctx belongs to committed, so
+// that effects outside of the database, such as notifications and cache
+// invalidations, happen once and only for committed changes. fn is dropped
+// when the unit of work is rolled back or retried. Outside of a unit of work
+// fn is run at once. When fn runs, ctx no longer belongs to the unit of work,
+// so fn may use it to read and write outside of the transaction.
+func AfterCommit(ctx context.Context, fn func()) {
+	if u, ok := active(ctx); ok {
+		u.after = append(u.after, fn)
+		return
+	}
+	fn()
+}
+
+// Options configure the transactions of a Manager.
+type Options struct {
+	// Isolation is the isolation level of each transaction. The zero value
+	// is the default of the database.
+	Isolation sql.IsolationLevel
+	// MaxRetries is how often a unit of work that failed with a
+	// serialization failure is run again.
+	MaxRetries int
+}
+
+// ParseIsolation parses isolation levels such as "serializable". An empty
+// string is the default of the database.
+func ParseIsolation(s string) (sql.IsolationLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return sql.LevelDefault, nil
+	case "read committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	}
+	return 0, ErrIsolationInvalid
+}
+
+type Manager struct {
+	db   *sql.DB
+	opts Options
+}
+
+func NewManager(db *sql.DB, opts Options) *Manager {
+	return &Manager{
+		db:   db,
+		opts: opts,
+	}
+}
+
+// WithTx runs fn in a transaction carried by the context passed to fn. The
+// transaction is committed when fn returns nil and rolled back otherwise.
+// Serialization failures run fn again in a new transaction, up to
+// Options.MaxRetries times, so fn must not have effects outside of the
+// database; defer them with AfterCommit. When ctx already belongs to a unit
+// of work, fn joins it.
+func (m *Manager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if InTx(ctx) {
+		return fn(ctx)
+	}
+	for attempt := 0; ; attempt++ {
+		err := m.run(ctx, fn)
+		if !IsSerializationFailure(err) || attempt >= m.opts.MaxRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * 10 * time.Millisecond):
+		}
+	}
+}
+
+func (m *Manager) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: m.opts.Isolation})
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	u := &unit{tx: tx}
+	err = fn(context.WithValue(ctx, txKey{}, u))
+	if err == nil {
+		err = tx.Commit()
+	}
+	u.tx = nil
+	if err != nil {
+		return err
+	}
+	for _, after := range u.after {
+		after()
+	}
+	return nil
+}
+
+// IsSerializationFailure reports whether err is a serialization failure,
+// SQLSTATE 40001, after which the transaction may succeed when retried.
+func IsSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "40001"
+}
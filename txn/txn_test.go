@@ -0,0 +1,155 @@
+package txn
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIsolation(t *testing.T) {
+	tests := []struct {
+		in   string
+		want sql.IsolationLevel
+		err  error
+	}{
+		{"", sql.LevelDefault, nil},
+		{"read committed", sql.LevelReadCommitted, nil},
+		{"Repeatable Read", sql.LevelRepeatableRead, nil},
+		{"serializable", sql.LevelSerializable, nil},
+		{"snapshot", 0, ErrIsolationInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseIsolation(tt.in)
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestManagerWithTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	m := NewManager(db, Options{MaxRetries: 1})
+	ctx := context.Background()
+	serializationFailure := &pq.Error{Code: "40001"}
+	exec := func(ctx context.Context) error {
+		_, err := From(ctx, db).ExecContext(ctx, "UPDATE expenses SET title = 'tea'")
+		return err
+	}
+
+	t.Run("WithTx() commits and carries the transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE expenses").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE expenses").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := m.WithTx(ctx, func(ctx context.Context) error {
+			assert.True(t, InTx(ctx))
+			if err := exec(ctx); err != nil {
+				return err
+			}
+			return m.WithTx(ctx, exec)
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("WithTx() rolls back on error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := m.WithTx(ctx, func(ctx context.Context) error { return errFailed })
+
+		assert.ErrorIs(t, err, errFailed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("WithTx() retries serialization failures", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE expenses").WillReturnError(serializationFailure)
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE expenses").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := m.WithTx(ctx, exec)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AfterCommit() runs once the unit of work committed", func(t *testing.T) {
+		var ran []string
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE expenses").WillReturnError(serializationFailure)
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE expenses").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := m.WithTx(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() {
+				assert.False(t, InTx(ctx), "the unit of work ended")
+				ran = append(ran, "notify")
+			})
+			if err := exec(ctx); err != nil {
+				return err
+			}
+			assert.Empty(t, ran, "not before commit")
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"notify"}, ran, "once despite the retry")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AfterCommit() is dropped on rollback", func(t *testing.T) {
+		ran := false
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := m.WithTx(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { ran = true })
+			return errors.New("failed")
+		})
+
+		assert.Error(t, err)
+		assert.False(t, ran)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AfterCommit() runs at once outside of a unit of work", func(t *testing.T) {
+		ran := false
+
+		AfterCommit(ctx, func() { ran = true })
+
+		assert.True(t, ran)
+	})
+
+	t.Run("WithTx() gives up after MaxRetries", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE expenses").WillReturnError(serializationFailure)
+			mock.ExpectRollback()
+		}
+
+		err := m.WithTx(ctx, exec)
+
+		assert.True(t, IsSerializationFailure(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,305 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/phuangpheth/assessment/txn"
+	"go.uber.org/zap"
+)
+
+// Headers set on every webhook request.
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Config tunes the Dispatcher. Zero values are replaced by defaults.
+type Config struct {
+	// MaxAttempts is the number of failed attempts after which a delivery is
+	// dead-lettered.
+	MaxAttempts int
+	// MinBackoff is the delay after the first failed attempt; it doubles on
+	// every further failure up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Lease is how long a claimed delivery is hidden from other replicas
+	// while it is being sent.
+	Lease     time.Duration
+	BatchSize uint64
+}
+
+func (c *Config) setDefaults() {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 8
+	}
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = 10 * time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = time.Hour
+	}
+	if c.Lease <= 0 {
+		c.Lease = time.Minute
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = 100
+	}
+}
+
+// Dispatcher fans expense events out to webhook_deliveries and sends them.
+type Dispatcher struct {
+	db         *sql.DB
+	expenseSvc *expense.Service
+	client     *http.Client
+	cfg        Config
+	now        func() time.Time
+}
+
+func NewDispatcher(db *sql.DB, expenseSvc *expense.Service, client *http.Client, cfg Config) *Dispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	cfg.setDefaults()
+	return &Dispatcher{
+		db:         db,
+		expenseSvc: expenseSvc,
+		client:     client,
+		cfg:        cfg,
+		now:        time.Now,
+	}
+}
+
+// Run fans out and delivers on every tick until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.FanOut(ctx); err != nil {
+			zap.L().Error("failed to fan out webhook events", zap.Error(err))
+		}
+		if _, err := d.Deliver(ctx); err != nil {
+			zap.L().Error("failed to deliver webhooks", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FanOut creates a pending delivery for every subscription of every event
+// recorded since the last call, among the subscriptions of the tenant of the
+// event. The cursor row is locked, so only one replica fans out at a time,
+// and holds the position of the last event fanned out: events show up in the
+// order of their positions, so none is skipped. It returns the number of
+// events processed.
+func (d *Dispatcher) FanOut(ctx context.Context) (int, error) {
+	n := 0
+	err := d.expenseSvc.WithTx(ctx, func(ctx context.Context) error {
+		n = 0
+		tx := txn.From(ctx, d.db)
+		var cursor expense.Position
+		if err := tx.QueryRowContext(ctx, `SELECT last_tx_id, last_event_id FROM webhook_cursor WHERE id = 1 FOR UPDATE`).Scan(&cursor.TxID, &cursor.ID); err != nil {
+			return fmt.Errorf("lock webhook_cursor: %w", err)
+		}
+
+		evs, _, err := d.expenseSvc.Events(ctx, cursor, d.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, ev := range evs {
+			if err := createDeliveries(ctx, tx, ev); err != nil {
+				return fmt.Errorf("createDeliveries(%d): %w", ev.ID, err)
+			}
+			cursor = ev.Position()
+			n++
+		}
+		if n == 0 {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE webhook_cursor SET last_tx_id = $1, last_event_id = $2 WHERE id = 1`, cursor.TxID, cursor.ID); err != nil {
+			return fmt.Errorf("update webhook_cursor: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// claimQuery hides due deliveries from other replicas for the lease and
+// returns everything needed to send them.
+const claimQuery = `
+  WITH claimed AS (
+    UPDATE webhook_deliveries
+    SET next_attempt_at = now() + make_interval(secs => $1), updated_at = now()
+    WHERE id IN (
+      SELECT id FROM webhook_deliveries
+      WHERE status = 'pending' AND next_attempt_at <= now()
+      ORDER BY next_attempt_at
+      LIMIT $2
+      FOR UPDATE SKIP LOCKED
+    )
+    RETURNING id, subscription_id, event_id, attempts
+  )
+  SELECT c.id, c.attempts, s.url, s.secret, e.id, e.type, e.expense_id, e.tenant_id, e.data, e.created_at
+  FROM claimed c
+  JOIN webhook_subscriptions s ON s.id = c.subscription_id
+  JOIN expense_events e ON e.id = c.event_id
+`
+
+type claim struct {
+	deliveryID int64
+	attempts   int
+	url        string
+	secret     string
+	event      expense.Event
+}
+
+// Deliver sends the due deliveries. Failed deliveries are retried with
+// exponential backoff and dead-lettered after Config.MaxAttempts. It returns
+// the number of deliveries that succeeded.
+func (d *Dispatcher) Deliver(ctx context.Context) (int, error) {
+	rows, err := d.db.QueryContext(ctx, claimQuery, d.cfg.Lease.Seconds(), d.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim deliveries: %w", err)
+	}
+	var claims []claim
+	for rows.Next() {
+		var c claim
+		if err := rows.Scan(
+			&c.deliveryID,
+			&c.attempts,
+			&c.url,
+			&c.secret,
+			&c.event.ID,
+			&c.event.Type,
+			&c.event.ExpenseID,
+			&c.event.TenantID,
+			&c.event.Data,
+			&c.event.CreatedAt,
+		); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		claims = append(claims, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, c := range claims {
+		sendErr := d.send(ctx, c)
+		if err := d.record(ctx, c, sendErr); err != nil {
+			return delivered, fmt.Errorf("record delivery %d: %w", c.deliveryID, err)
+		}
+		if sendErr == nil {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, c claim) error {
+	body, err := json.Marshal(c.event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	ts := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, strconv.FormatInt(c.deliveryID, 10))
+	req.Header.Set(HeaderEvent, c.event.Type)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, "sha256="+Sign(c.secret, ts, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (d *Dispatcher) record(ctx context.Context, c claim, sendErr error) error {
+	attempts := c.attempts + 1
+	ub := sq.Update("webhook_deliveries").
+		Set("attempts", attempts).
+		Set("updated_at", d.now()).
+		Where(sq.Eq{"id": c.deliveryID}).
+		PlaceholderFormat(sq.Dollar)
+
+	switch {
+	case sendErr == nil:
+		ub = ub.Set("status", StatusDelivered).Set("last_error", "")
+	case attempts >= d.cfg.MaxAttempts:
+		ub = ub.Set("status", StatusDead).Set("last_error", sendErr.Error())
+	default:
+		ub = ub.Set("next_attempt_at", d.now().Add(d.backoff(attempts))).Set("last_error", sendErr.Error())
+	}
+
+	query, args, err := ub.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = d.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// backoff returns the delay before the next attempt after the given number
+// of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.MinBackoff
+	for i := 1; i < attempts && delay < d.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.cfg.MaxBackoff {
+		delay = d.cfg.MaxBackoff
+	}
+	return delay
+}
+
+func createDeliveries(ctx context.Context, tx txn.Querier, ev expense.Event) error {
+	query, args, err := sq.Insert("webhook_deliveries").
+		Columns("subscription_id", "event_id").
+		Select(sq.Select("id").
+			Column("?", ev.ID).
+			From("webhook_subscriptions").
+			Where(sq.Eq{"tenant_id": ev.TenantID}).
+			Where("? = ANY(event_types)", ev.Type)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	return err
+}
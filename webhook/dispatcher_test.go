@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phuangpheth/assessment/expense"
+	"github.com/stretchr/testify/assert"
+)
+
+var claimColumns = []string{"id", "attempts", "url", "secret", "id", "type", "expense_id", "tenant_id", "data", "created_at"}
+
+func TestDispatcherDeliver(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	status := http.StatusOK
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	newDispatcher := func(t *testing.T) (*Dispatcher, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		d := NewDispatcher(db, expense.NewService(db), srv.Client(), Config{MaxAttempts: 3, MinBackoff: time.Second})
+		d.now = func() time.Time { return now }
+		return d, mock
+	}
+	claimRows := func(attempts int) *sqlmock.Rows {
+		return sqlmock.NewRows(claimColumns).
+			AddRow(7, attempts, srv.URL, "s3cr3t", 42, expense.EventCreated, 1, "", []byte(`{"id":1}`), now)
+	}
+
+	t.Run("Deliver() signs and sends the event", func(t *testing.T) {
+		status = http.StatusOK
+		d, mock := newDispatcher(t)
+		mock.ExpectQuery(regexp.QuoteMeta("WITH claimed AS")).WillReturnRows(claimRows(0))
+		mock.ExpectExec("UPDATE webhook_deliveries").
+			WithArgs(1, now, StatusDelivered, "", 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		n, err := d.Deliver(context.Background())
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, n)
+			assert.Equal(t, expense.EventCreated, got.Header.Get(HeaderEvent))
+			assert.Equal(t, "7", got.Header.Get(HeaderID))
+			ts, _ := strconv.ParseInt(got.Header.Get(HeaderTimestamp), 10, 64)
+			sig := strings.TrimPrefix(got.Header.Get(HeaderSignature), "sha256=")
+			assert.True(t, Verify("s3cr3t", ts, gotBody, sig))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("Deliver() retries with backoff", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		d, mock := newDispatcher(t)
+		mock.ExpectQuery(regexp.QuoteMeta("WITH claimed AS")).WillReturnRows(claimRows(1))
+		mock.ExpectExec("UPDATE webhook_deliveries").
+			WithArgs(2, now, now.Add(2*time.Second), "unexpected status 500 Internal Server Error", 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		n, err := d.Deliver(context.Background())
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 0, n)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("Deliver() dead-letters after max attempts", func(t *testing.T) {
+		status = http.StatusBadGateway
+		d, mock := newDispatcher(t)
+		mock.ExpectQuery(regexp.QuoteMeta("WITH claimed AS")).WillReturnRows(claimRows(2))
+		mock.ExpectExec("UPDATE webhook_deliveries").
+			WithArgs(3, now, StatusDead, "unexpected status 502 Bad Gateway", 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		_, err := d.Deliver(context.Background())
+
+		if assert.NoError(t, err) {
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+}
+
+func TestDispatcherFanOut(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	d := NewDispatcher(db, expense.NewService(db), nil, Config{})
+	d.now = func() time.Time { return now }
+
+	t.Run("FanOut() stops at events held back by running transactions", func(t *testing.T) {
+		events := sqlmock.NewRows([]string{"id", "type", "expense_id", "tenant_id", "data", "created_at", "tx_id", "ended"}).
+			AddRow(12, expense.EventCreated, 1, "acme", []byte(`{}`), now, 500, true).
+			AddRow(11, expense.EventUpdated, 1, "", []byte(`{}`), now, 501, false)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT last_tx_id, last_event_id FROM webhook_cursor").
+			WillReturnRows(sqlmock.NewRows([]string{"last_tx_id", "last_event_id"}).AddRow(499, 10))
+		mock.ExpectQuery(`SELECT (.+) FROM expense_events WHERE \(tx_id, id\) > \(\$1, \$2\) ORDER BY tx_id, id`).
+			WithArgs(499, 10).
+			WillReturnRows(events)
+		mock.ExpectExec(`INSERT INTO webhook_deliveries (.+) FROM webhook_subscriptions WHERE tenant_id = \$2 AND \$3 = ANY\(event_types\)`).
+			WithArgs(12, "acme", expense.EventCreated).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("UPDATE webhook_cursor").WithArgs(500, 12).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		n, err := d.FanOut(context.Background())
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, n)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+}
@@ -0,0 +1,338 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/phuangpheth/assessment/expense"
+)
+
+// ErrNotFound is returned when the subscription or delivery could not be found.
+var ErrNotFound = errors.New("not found")
+
+// ErrURLInvalid is returned when the subscription URL is not an absolute http(s) URL.
+var ErrURLInvalid = errors.New("url must be an absolute http or https URL")
+
+// ErrEventTypesEmpty is returned when the subscription has no event types.
+var ErrEventTypesEmpty = errors.New("empty event types")
+
+// ErrEventTypeInvalid is returned when the subscription has an unknown event type.
+var ErrEventTypeInvalid = errors.New("unknown event type")
+
+// EventTypes are the event types a subscription may subscribe to.
+var EventTypes = []string{
+	expense.EventCreated,
+	expense.EventUpdated,
+	expense.EventDeleted,
+	expense.EventStatusChanged,
+}
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+// Subscription receives the events of EventTypes of the expenses of Tenant
+// at URL. Each request is signed with Secret.
+type Subscription struct {
+	ID         int64     `json:"id"`
+	Tenant     string    `json:"-"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (s *Subscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+		return ErrURLInvalid
+	}
+	if len(s.EventTypes) == 0 {
+		return ErrEventTypesEmpty
+	}
+	for _, t := range s.EventTypes {
+		if !contains(EventTypes, t) {
+			return ErrEventTypeInvalid
+		}
+	}
+	return nil
+}
+
+// Delivery is one attempt-tracked send of an event to a subscription.
+type Delivery struct {
+	ID             int64     `json:"id"`
+	SubscriptionID int64     `json:"subscription_id"`
+	EventID        int64     `json:"event_id"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	LastError      string    `json:"last_error,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// Save creates the subscription in the tenant of ctx. A random secret is
+// generated when none is given; it is returned only by Save.
+func (s *Service) Save(ctx context.Context, sub *Subscription) (*Subscription, error) {
+	sub.Tenant = expense.TenantFromContext(ctx)
+	if sub.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		sub.Secret = hex.EncodeToString(b)
+	}
+	if err := createSubscription(ctx, s.db, sub); err != nil {
+		return nil, fmt.Errorf("createSubscription(): %w", err)
+	}
+	return sub, nil
+}
+
+func (s *Service) List(ctx context.Context) ([]Subscription, error) {
+	subs, err := listSubscriptions(ctx, s.db)
+	if err != nil {
+		return nil, fmt.Errorf("listSubscriptions(): %w", err)
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	if err := deleteSubscription(ctx, s.db, id); err != nil {
+		return fmt.Errorf("deleteSubscription(%d): %w", id, err)
+	}
+	return nil
+}
+
+func (s *Service) Deliveries(ctx context.Context, subscriptionID int64) ([]Delivery, error) {
+	ds, err := listDeliveries(ctx, s.db, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("listDeliveries(%d): %w", subscriptionID, err)
+	}
+	return ds, nil
+}
+
+// Replay schedules the delivery to be sent again immediately, including
+// deliveries that were already delivered or dead-lettered.
+func (s *Service) Replay(ctx context.Context, id int64) error {
+	if err := replayDelivery(ctx, s.db, id); err != nil {
+		return fmt.Errorf("replayDelivery(%d): %w", id, err)
+	}
+	return nil
+}
+
+// Sign returns the signature of a webhook request: the hex-encoded
+// HMAC-SHA256 of "{timestamp}.{body}" keyed with the subscription secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(h, "%d.", timestamp)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify reports whether signature is valid for the request. Receivers should
+// also reject timestamps that are too old to prevent replays.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// inTenant selects the subscriptions of the tenant of ctx.
+func inTenant(ctx context.Context) sq.Eq {
+	return sq.Eq{"tenant_id": expense.TenantFromContext(ctx)}
+}
+
+// ofTenant selects the deliveries of the subscriptions of the tenant of ctx.
+func ofTenant(ctx context.Context) sq.Sqlizer {
+	return sq.Expr("subscription_id IN (SELECT id FROM webhook_subscriptions WHERE tenant_id = ?)", expense.TenantFromContext(ctx))
+}
+
+func createSubscription(ctx context.Context, db *sql.DB, sub *Subscription) error {
+	query, args, err := sq.Insert("webhook_subscriptions").
+		Columns("tenant_id", "url", "secret", "event_types").
+		Values(sub.Tenant, sub.URL, sub.Secret, pq.Array(sub.EventTypes)).
+		Suffix("RETURNING id, created_at").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	return db.QueryRowContext(ctx, query, args...).Scan(&sub.ID, &sub.CreatedAt)
+}
+
+func listSubscriptions(ctx context.Context, db *sql.DB) ([]Subscription, error) {
+	query, args, err := sq.Select(subscriptionColumns...).
+		From("webhook_subscriptions").
+		Where(inTenant(ctx)).
+		OrderBy("id DESC").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := make([]Subscription, 0)
+	for rows.Next() {
+		var sub Subscription
+		if err := rows.Scan(
+			&sub.ID,
+			&sub.Tenant,
+			&sub.URL,
+			&sub.Secret,
+			pq.Array(&sub.EventTypes),
+			&sub.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+func deleteSubscription(ctx context.Context, db *sql.DB, id int64) error {
+	query, args, err := sq.Delete("webhook_subscriptions").
+		Where(sq.Eq{"id": id}).
+		Where(inTenant(ctx)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func listDeliveries(ctx context.Context, db *sql.DB, subscriptionID int64) ([]Delivery, error) {
+	query, args, err := sq.Select(deliveryColumns...).
+		From("webhook_deliveries").
+		Where(sq.Eq{"subscription_id": subscriptionID}).
+		Where(ofTenant(ctx)).
+		OrderBy("id DESC").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ds := make([]Delivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+func replayDelivery(ctx context.Context, db *sql.DB, id int64) error {
+	query, args, err := sq.Update("webhook_deliveries").
+		Set("status", StatusPending).
+		Set("attempts", 0).
+		Set("next_attempt_at", sq.Expr("now()")).
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"id": id}).
+		Where(ofTenant(ctx)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+var subscriptionColumns = []string{
+	"id",
+	"tenant_id",
+	"url",
+	"secret",
+	"event_types",
+	"created_at",
+}
+
+var deliveryColumns = []string{
+	"id",
+	"subscription_id",
+	"event_id",
+	"status",
+	"attempts",
+	"next_attempt_at",
+	"last_error",
+	"updated_at",
+}
+
+func scanDelivery(scan func(...any) error) (d Delivery, _ error) {
+	return d, scan(
+		&d.ID,
+		&d.SubscriptionID,
+		&d.EventID,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastError,
+		&d.UpdatedAt,
+	)
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	sig := Sign("s3cr3t", 1700000000, body)
+
+	assert.True(t, Verify("s3cr3t", 1700000000, body, sig))
+	assert.False(t, Verify("other", 1700000000, body, sig))
+	assert.False(t, Verify("s3cr3t", 1700000001, body, sig))
+}
+
+func TestSubscriptionValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  Subscription
+		want error
+	}{
+		{"ErrURLInvalid", Subscription{URL: "/hooks", EventTypes: []string{"expense.created"}}, ErrURLInvalid},
+		{"ErrEventTypesEmpty", Subscription{URL: "https://example.com/hooks"}, ErrEventTypesEmpty},
+		{"ErrEventTypeInvalid", Subscription{URL: "https://example.com/hooks", EventTypes: []string{"expense.exploded"}}, ErrEventTypeInvalid},
+		{"Validate No Error", Subscription{URL: "https://example.com/hooks", EventTypes: []string{"expense.created"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.sub.Validate())
+		})
+	}
+}